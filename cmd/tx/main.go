@@ -1,11 +1,17 @@
 package tx
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/manifoldco/promptui"
@@ -17,6 +23,24 @@ import (
 
 func Main() {
 	errorColor := color.New(color.FgRed).SprintfFunc()
+
+	// Ctrl-C stops pending tasks and cancels requests in progress; pressing it
+	// a second time exits right away
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	interrupted := make(chan os.Signal, 1)
+	signal.Notify(interrupted, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-interrupted
+		// Restore the default behaviour, so that the next signal exits
+		signal.Stop(interrupted)
+		cancel()
+		fmt.Fprintln(
+			os.Stderr,
+			"\nInterrupted, stopping (press Ctrl-C again to exit immediately)",
+		)
+	}()
+
 	cli.VersionPrinter = func(c *cli.Context) {
 		fmt.Println("TX Client, version=" + c.App.Version)
 	}
@@ -36,6 +60,11 @@ func Main() {
 			Usage:   "The api token to use",
 			EnvVars: []string{"TX_TOKEN"},
 		},
+		&cli.StringFlag{
+			Name:    "token-file",
+			Usage:   "Read the api token from `FILE`",
+			EnvVars: []string{"TX_TOKEN_FILE"},
+		},
 		&cli.StringFlag{
 			Name:    "hostname",
 			Aliases: []string{"H"},
@@ -47,6 +76,288 @@ func Main() {
 			Usage:   "Path to CA certificate bundle file",
 			EnvVars: []string{"TX_CACERT"},
 		},
+		&cli.StringFlag{
+			Name:    "client-cert",
+			Usage:   "Client certificate `FILE` for mutual TLS",
+			EnvVars: []string{"TX_CLIENT_CERT"},
+		},
+		&cli.StringFlag{
+			Name: "client-key",
+			Usage: "Private key `FILE` of the client certificate, if it is " +
+				"not in the certificate file",
+			EnvVars: []string{"TX_CLIENT_KEY"},
+		},
+		&cli.StringFlag{
+			Name: "proxy",
+			Usage: "Connect through this proxy `URL` instead of the one in " +
+				"HTTPS_PROXY",
+			EnvVars: []string{"TX_PROXY"},
+		},
+		&cli.StringFlag{
+			Name:    "no-proxy",
+			Usage:   "Comma-separated hosts or domains to connect to directly",
+			EnvVars: []string{"TX_NO_PROXY"},
+		},
+		&cli.DurationFlag{
+			Name: "request-timeout",
+			Usage: "How long to wait for the server to respond to each " +
+				"request (default 2m)",
+			EnvVars: []string{"TX_REQUEST_TIMEOUT"},
+		},
+		&cli.StringSliceFlag{
+			Name:  "header",
+			Usage: "Extra `'NAME: VALUE'` header for API requests (repeatable)",
+		},
+		&cli.IntFlag{
+			Name: "retries",
+			Usage: "How many times to retry API requests that fail because of " +
+				"transient errors (default 3)",
+			EnvVars: []string{"TX_RETRIES"},
+		},
+		&cli.StringFlag{
+			Name: "retry-status-codes",
+			Usage: "Comma-separated HTTP status codes to retry " +
+				"(default 500,502,503,504)",
+			EnvVars: []string{"TX_RETRY_STATUS_CODES"},
+		},
+		&cli.DurationFlag{
+			Name: "timeout",
+			Usage: "Give up on async jobs (uploads, downloads, merges) that " +
+				"take longer than this (eg '10m'); 0 means no limit",
+			EnvVars: []string{"TX_TIMEOUT"},
+		},
+		&cli.BoolFlag{
+			Name: "debug-http",
+			Usage: "Log every HTTP request and response to stderr, with " +
+				"tokens and signed URLs redacted",
+			EnvVars: []string{"TX_DEBUG"},
+		},
+		&cli.BoolFlag{
+			Name:    "debug-http-bodies",
+			Usage:   "Also log the beginning of request and response bodies",
+			EnvVars: []string{"TX_DEBUG_BODIES"},
+		},
+		&cli.StringFlag{
+			Name:    "debug-http-file",
+			Usage:   "Append the HTTP log to `FILE` instead of stderr",
+			EnvVars: []string{"TX_DEBUG_FILE"},
+		},
+		&cli.BoolFlag{
+			Name: "no-interactive",
+			Usage: "Fail instead of asking for input; the default if CI=true " +
+				"or if the input is not a terminal",
+			EnvVars: []string{"TX_NO_INTERACTIVE"},
+		},
+	}
+	// The 'tx auth' subcommands have their own '--hostname' flag, which
+	// shadows the global one
+	getHostname := func(c *cli.Context) string {
+		for _, lineageContext := range c.Lineage() {
+			if lineageContext.App != nil &&
+				lineageContext.String("hostname") != "" {
+				return lineageContext.String("hostname")
+			}
+		}
+		return ""
+	}
+	getRetryPolicy := func(
+		c *cli.Context, cfg *config.Config,
+	) (*jsonapi.RetryPolicy, error) {
+		retries := -1
+		if c.IsSet("retries") {
+			retries = c.Int("retries")
+		}
+		return txlib.GetRetryPolicy(
+			cfg, getHostname(c), retries, c.String("retry-status-codes"),
+		)
+	}
+	getHostAndToken := func(
+		c *cli.Context, cfg *config.Config,
+	) (string, string, error) {
+		token := c.String("token")
+		if token == "" && c.String("token-file") != "" {
+			var err error
+			token, err = txlib.ReadTokenFile(c.String("token-file"))
+			if err != nil {
+				return "", "", err
+			}
+		}
+		return txlib.GetHostAndToken(cfg, getHostname(c), token)
+	}
+	// With '--debug-http', every request (including the file downloads the
+	// API redirects to) goes through a transport that logs it. The headers
+	// returned are for the API connection.
+	getClient := func(
+		c *cli.Context, cfg *config.Config,
+	) (http.Client, map[string]string, error) {
+		headers, err := txlib.ParseHeaders(c.StringSlice("header"))
+		if err != nil {
+			return http.Client{}, nil, err
+		}
+		settings, err := txlib.GetHTTPSettings(
+			cfg, getHostname(c), txlib.HTTPSettings{
+				CACert:         c.String("cacert"),
+				ClientCert:     c.String("client-cert"),
+				ClientKey:      c.String("client-key"),
+				Proxy:          c.String("proxy"),
+				NoProxy:        c.String("no-proxy"),
+				RequestTimeout: c.Duration("request-timeout"),
+				Headers:        headers,
+			},
+		)
+		if err != nil {
+			return http.Client{}, nil, err
+		}
+		headers = settings.APIHeaders(c.Command.FullName())
+		client, err := txlib.GetClient(settings)
+		if err != nil {
+			return client, nil, err
+		}
+		if !c.Bool("debug-http") && !c.Bool("debug-http-bodies") &&
+			c.String("debug-http-file") == "" {
+			return client, headers, nil
+		}
+		var output io.Writer = os.Stderr
+		if c.String("debug-http-file") != "" {
+			file, err := os.OpenFile(
+				c.String("debug-http-file"),
+				os.O_WRONLY|os.O_CREATE|os.O_APPEND,
+				0600,
+			)
+			if err != nil {
+				return client, nil, err
+			}
+			output = file
+		}
+		// Custom headers often carry gateway keys, so none of them is logged
+		var secretHeaders []string
+		for name := range settings.Headers {
+			secretHeaders = append(secretHeaders, name)
+		}
+		client.Transport = &jsonapi.TracingTransport{
+			Transport:     client.Transport,
+			Output:        output,
+			Bodies:        c.Bool("debug-http-bodies"),
+			SecretHeaders: secretHeaders,
+		}
+		return client, headers, nil
+	}
+	// The 'tx auth' subcommands also work outside of a project
+	authHostnameFlag := &cli.StringFlag{
+		Name: "hostname",
+		Usage: "The host, as a section name or API hostname of the root " +
+			"configuration",
+	}
+	authAction := func(
+		command func(
+			*config.Config, jsonapi.Connection, txlib.AuthCommandArguments,
+		) error,
+	) cli.ActionFunc {
+		return func(c *cli.Context) error {
+			cfg, err := config.LoadFromPathsWithoutLocal(
+				c.String("root-config"), c.String("config"),
+			)
+			if err != nil {
+				return cli.Exit(errorColor(
+					"Error loading configuration: %s", err,
+				), 1)
+			}
+			args := txlib.AuthCommandArguments{
+				Hostname:         getHostname(c),
+				RestHostname:     c.String("rest-hostname"),
+				Token:            c.String("token"),
+				TokenSource:      "from --token or TX_TOKEN",
+				CredentialHelper: c.String("credential-helper"),
+			}
+			if args.Token == "" && c.String("token-file") != "" {
+				args.Token, err = txlib.ReadTokenFile(c.String("token-file"))
+				if err != nil {
+					return cli.Exit(errorColor(err.Error()), 1)
+				}
+				args.TokenSource = fmt.Sprintf(
+					"from '%s'", c.String("token-file"),
+				)
+			}
+
+			client, headers, err := getClient(c, &cfg)
+			if err != nil {
+				return cli.Exit(errorColor(
+					"Error getting HTTP client configuration: %s", err,
+				), 1)
+			}
+			retryPolicy, err := getRetryPolicy(c, &cfg)
+			if err != nil {
+				return cli.Exit(errorColor(err.Error()), 1)
+			}
+			api := jsonapi.Connection{
+				Client:  client,
+				Retry:   retryPolicy,
+				Context: ctx,
+				Headers: headers,
+			}
+
+			err = command(&cfg, api, args)
+			if err != nil {
+				return cli.Exit(errorColor(err.Error()), 1)
+			}
+			return nil
+		}
+	}
+	// Push, pull and status can also combine every configuration of a
+	// workspace with '--recursive'
+	loadConfig := func(c *cli.Context) (config.Config, error) {
+		if !c.Bool("recursive") {
+			return config.LoadFromPaths(
+				c.String("root-config"), c.String("config"),
+			)
+		}
+		if c.String("config") != "" {
+			return config.Config{}, errors.New(
+				"you cannot use both '--config' and '--recursive'",
+			)
+		}
+		var ignore []string
+		if c.String("ignore") != "" {
+			ignore = strings.Split(c.String("ignore"), ",")
+		}
+		cfg, _, err := config.LoadWorkspace(c.String("root-config"), ".", ignore)
+		return cfg, err
+	}
+	// The 'tx config' subcommands only differ in the function they call and
+	// in how many positional arguments they expect
+	configAction := func(
+		command func(*config.Config, txlib.ConfigCommandArguments) error,
+		usage string,
+		argCount int,
+	) cli.ActionFunc {
+		return func(c *cli.Context) error {
+			if c.NArg() != argCount {
+				return cli.Exit(errorColor("Usage: %s", usage), 1)
+			}
+			cfg, err := config.LoadFromPaths(
+				c.String("root-config"), c.String("config"),
+			)
+			if err != nil {
+				return cli.Exit(errorColor(
+					"Error loading configuration: %s", err,
+				), 1)
+			}
+			err = command(&cfg, txlib.ConfigCommandArguments{
+				ResourceId: c.String("resource"),
+				Key:        c.Args().Get(0),
+				Value:      c.Args().Get(1),
+			})
+			if err != nil {
+				return cli.Exit(errorColor(err.Error()), 1)
+			}
+			return nil
+		}
+	}
+	configResourceFlag := &cli.StringFlag{
+		Name:    "resource",
+		Aliases: []string{"r"},
+		Usage: "The resource (<project_slug>.<resource_slug>) to operate " +
+			"on, instead of the '[main]' section",
 	}
 	app := &cli.App{
 		Version:                txlib.Version,
@@ -64,13 +375,14 @@ func Main() {
 						return cli.Exit(err, 1)
 					}
 
-					client, err := txlib.GetClient(c.String("cacert"))
+					client, headers, err := getClient(c, &cfg)
 					if err != nil {
 						return cli.Exit(err, 1)
 					}
 
 					api := jsonapi.Connection{
-						Client: client,
+						Client:  client,
+						Headers: headers,
 					}
 
 					backUpFilePath, err := txlib.MigrateLegacyConfigFile(&cfg,
@@ -89,8 +401,13 @@ func Main() {
 			},
 			{
 				Name:  "merge",
-				Usage: "tx merge [options] [resource_id]",
+				Usage: "tx merge [options] [resource_id...]",
 				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name: "exclude-resources",
+						Usage: "Comma-separated resources to leave out of " +
+							"the selection",
+					},
 					&cli.StringFlag{
 						Name: "branch",
 						Usage: "Merge specific branch (omit " +
@@ -118,11 +435,19 @@ func Main() {
 					},
 				},
 				Action: func(c *cli.Context) error {
-					if c.Args().Len() != 1 {
-						return cli.Exit(errorColor("Please provide one resource"), 1)
+					if c.Args().Len() == 0 {
+						return cli.Exit(
+							errorColor("Please provide at least one resource"), 1,
+						)
+					}
+					var excludeResourceIds []string
+					if c.String("exclude-resources") != "" {
+						excludeResourceIds = strings.Split(
+							c.String("exclude-resources"),
+							",",
+						)
 					}
 
-					resourceId := c.Args().First()
 					cfg, err := config.LoadFromPaths(
 						c.String("root-config"),
 						c.String("config"),
@@ -136,9 +461,7 @@ func Main() {
 							1,
 						)
 					}
-					hostname, token, err := txlib.GetHostAndToken(
-						&cfg, c.String("hostname"), c.String("token"),
-					)
+					hostname, token, err := getHostAndToken(c, &cfg)
 					if err != nil {
 						return cli.Exit(
 							errorColor(
@@ -149,7 +472,7 @@ func Main() {
 						)
 					}
 
-					client, err := txlib.GetClient(c.String("cacert"))
+					client, headers, err := getClient(c, &cfg)
 					if err != nil {
 						return cli.Exit(
 							errorColor(
@@ -160,24 +483,33 @@ func Main() {
 						)
 					}
 
+					retryPolicy, err := getRetryPolicy(c, &cfg)
+					if err != nil {
+						return cli.Exit(errorColor(err.Error()), 1)
+					}
 					api := jsonapi.Connection{
-						Host:   hostname,
-						Token:  token,
-						Client: client,
-						Headers: map[string]string{
-							"Integration": "txclient",
-						},
+						Host:    hostname,
+						Token:   token,
+						Client:  client,
+						Retry:   retryPolicy,
+						Context: ctx,
+						Headers: headers,
 					}
 
 					args := txlib.MergeCommandArguments{
-						ResourceId:         resourceId,
+						ResourceIds:        c.Args().Slice(),
+						ExcludeResourceIds: excludeResourceIds,
 						Branch:             c.String("branch"),
 						ConflictResolution: c.String("conflict-resolution"),
 						Force:              c.Bool("force"),
 						Skip:               c.Bool("skip"),
 						Silent:             c.Bool("silent"),
+						Timeout:            c.Duration("timeout"),
+					}
+					err = txlib.MergeCommand(ctx, &cfg, api, args)
+					if errors.Is(err, txlib.ErrInterrupted) {
+						return cli.Exit("", 130)
 					}
-					err = txlib.MergeCommand(&cfg, api, args)
 					if err != nil {
 						return cli.Exit(err, 1)
 					}
@@ -236,6 +568,34 @@ func Main() {
 						Usage: "Specify which resources you want to push " +
 							"the translations",
 					},
+					&cli.StringFlag{
+						Name: "exclude-resources",
+						Usage: "Comma-separated resources to leave out of " +
+							"the selection (supports the same patterns as " +
+							"--resources)",
+					},
+					&cli.StringFlag{
+						Name: "tag",
+						Usage: "Only select resources with one of these " +
+							"comma-separated tags",
+					},
+					&cli.StringFlag{
+						Name: "exclude-tag",
+						Usage: "Leave out resources with any of these " +
+							"comma-separated tags",
+					},
+					&cli.BoolFlag{
+						Name:    "recursive",
+						Aliases: []string{"workspace"},
+						Usage: "Use every '.tx/config' file under the current " +
+							"directory",
+					},
+					&cli.StringFlag{
+						Name: "ignore",
+						Usage: "Comma-separated directories to skip with " +
+							"--recursive (on top of '.git', 'node_modules', " +
+							"'vendor' and the ones in '.txignore')",
+					},
 					&cli.StringFlag{
 						Name: "branch",
 						Usage: "Push to specific branch (use empty argument " +
@@ -269,12 +629,39 @@ func Main() {
 						Usage: "Whether to not discard translations if a source string with a " +
 							"pre-existing key changes",
 					},
+					&cli.BoolFlag{
+						Name: "allow-deletions",
+						Usage: "Whether to push source files even if they would " +
+							"delete more source strings than allowed",
+					},
+					&cli.BoolFlag{
+						Name: "dry-run",
+						Usage: "Print what would be pushed without making any " +
+							"changes on Transifex",
+					},
+					&cli.StringFlag{
+						Name:  "report",
+						Usage: "Write a JSON report of the outcome of every file to `FILE`",
+					},
+					&cli.BoolFlag{
+						Name: "watch",
+						Usage: "Keep running and push source files whenever " +
+							"they change",
+					},
+					&cli.DurationFlag{
+						Name: "debounce",
+						Usage: "With --watch, wait until source files have " +
+							"not changed for this long before pushing them",
+						Value: time.Second,
+					},
+					&cli.BoolFlag{
+						Name: "pseudo",
+						Usage: "With --watch, pull pseudo-translations after " +
+							"pushing source files",
+					},
 				},
 				Action: func(c *cli.Context) error {
-					cfg, err := config.LoadFromPaths(
-						c.String("root-config"),
-						c.String("config"),
-					)
+					cfg, err := loadConfig(c)
 					if err != nil {
 						return cli.Exit(
 							errorColor(
@@ -284,9 +671,7 @@ func Main() {
 							1,
 						)
 					}
-					hostname, token, err := txlib.GetHostAndToken(
-						&cfg, c.String("hostname"), c.String("token"),
-					)
+					hostname, token, err := getHostAndToken(c, &cfg)
 					if err != nil {
 						return cli.Exit(
 							errorColor(
@@ -297,7 +682,7 @@ func Main() {
 						)
 					}
 
-					client, err := txlib.GetClient(c.String("cacert"))
+					client, headers, err := getClient(c, &cfg)
 					if err != nil {
 						return cli.Exit(
 							errorColor(
@@ -308,13 +693,17 @@ func Main() {
 						)
 					}
 
+					retryPolicy, err := getRetryPolicy(c, &cfg)
+					if err != nil {
+						return cli.Exit(errorColor(err.Error()), 1)
+					}
 					api := jsonapi.Connection{
-						Host:   hostname,
-						Token:  token,
-						Client: client,
-						Headers: map[string]string{
-							"Integration": "txclient",
-						},
+						Host:    hostname,
+						Token:   token,
+						Client:  client,
+						Retry:   retryPolicy,
+						Context: ctx,
+						Headers: headers,
 					}
 
 					resourceIds := c.Args().Slice()
@@ -325,6 +714,13 @@ func Main() {
 						)
 						resourceIds = append(resourceIds, extraResourceIds...)
 					}
+					var excludeResourceIds []string
+					if c.String("exclude-resources") != "" {
+						excludeResourceIds = strings.Split(
+							c.String("exclude-resources"),
+							",",
+						)
+					}
 
 					var languages []string
 					if c.String("languages") != "" {
@@ -344,6 +740,9 @@ func Main() {
 						Xliff:                c.Bool("xliff"),
 						Languages:            languages,
 						ResourceIds:          resourceIds,
+						ExcludeResourceIds:   excludeResourceIds,
+						Tags:                 config.ParseTags(c.String("tag")),
+						ExcludeTags:          config.ParseTags(c.String("exclude-tag")),
 						UseGitTimestamps:     c.Bool("use-git-timestamps"),
 						Branch:               c.String("branch"),
 						Base:                 c.String("base"),
@@ -352,6 +751,10 @@ func Main() {
 						Silent:               c.Bool("silent"),
 						ReplaceEditedStrings: c.Bool("replace-edited-strings"),
 						KeepTranslations:     c.Bool("keep-translations"),
+						DryRun:               c.Bool("dry-run"),
+						ReportPath:           c.String("report"),
+						Timeout:              c.Duration("timeout"),
+						AllowDeletions:       c.Bool("allow-deletions"),
 					}
 
 					if args.All && len(args.Languages) > 0 {
@@ -384,7 +787,45 @@ func Main() {
 						), 1)
 					}
 
-					err = txlib.PushCommand(&cfg, api, args)
+					if c.Bool("watch") {
+						if args.Translation {
+							return cli.Exit(errorColor(
+								"--watch only pushes source files, it "+
+									"cannot be used with '--translation'",
+							), 1)
+						}
+						if args.ReportPath != "" {
+							return cli.Exit(errorColor(
+								"--report cannot be used with '--watch'",
+							), 1)
+						}
+						err = txlib.WatchCommand(ctx, &cfg, api,
+							txlib.WatchCommandArguments{
+								Push:     args,
+								Pseudo:   c.Bool("pseudo"),
+								Debounce: c.Duration("debounce"),
+								Interval: 500 * time.Millisecond,
+							})
+						if err != nil {
+							return cli.Exit(errorColor(err.Error()), 1)
+						}
+						return nil
+					}
+					if c.Bool("pseudo") {
+						return cli.Exit(errorColor(
+							"--pseudo only makes sense when used with "+
+								"'--watch'",
+						), 1)
+					}
+
+					err = txlib.PushCommand(ctx, &cfg, api, args)
+					if errors.Is(err, txlib.ErrInterrupted) {
+						return cli.Exit("", 130)
+					}
+					var tasksFailedError *txlib.TasksFailedError
+					if errors.As(err, &tasksFailedError) {
+						return cli.Exit("", 2)
+					}
 					if err != nil {
 						return cli.Exit("", 1)
 					}
@@ -483,6 +924,34 @@ func Main() {
 						Usage: "Backwards compatibility with old client " +
 							"to fetch resource ids",
 					},
+					&cli.StringFlag{
+						Name: "exclude-resources",
+						Usage: "Comma-separated resources to leave out of " +
+							"the selection (supports the same patterns as " +
+							"--resources)",
+					},
+					&cli.StringFlag{
+						Name: "tag",
+						Usage: "Only select resources with one of these " +
+							"comma-separated tags",
+					},
+					&cli.StringFlag{
+						Name: "exclude-tag",
+						Usage: "Leave out resources with any of these " +
+							"comma-separated tags",
+					},
+					&cli.BoolFlag{
+						Name:    "recursive",
+						Aliases: []string{"workspace"},
+						Usage: "Use every '.tx/config' file under the current " +
+							"directory",
+					},
+					&cli.StringFlag{
+						Name: "ignore",
+						Usage: "Comma-separated directories to skip with " +
+							"--recursive (on top of '.git', 'node_modules', " +
+							"'vendor' and the ones in '.txignore')",
+					},
 					&cli.IntFlag{
 						Name: "minimum-perc",
 						Usage: "Specify the minimum acceptable percentage of " +
@@ -504,32 +973,56 @@ func Main() {
 						Usage: "Generate mock string translations",
 						Value: false,
 					},
+					&cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "Print what would be pulled without downloading any files",
+					},
+					&cli.StringFlag{
+						Name:  "report",
+						Usage: "Write a JSON report of the outcome of every file to `FILE`",
+					},
+					&cli.BoolFlag{
+						Name: "backup",
+						Usage: "Keep the previous version of every overwritten " +
+							"file with a '.bak' suffix",
+					},
+					&cli.StringFlag{
+						Name: "backup-dir",
+						Usage: "Keep the previous version of every overwritten " +
+							"file under `DIR` instead of next to it",
+					},
+					&cli.BoolFlag{
+						Name: "preserve-mode",
+						Usage: "Keep the permissions of overwritten files " +
+							"instead of using the default ones",
+					},
 				},
 				Action: func(c *cli.Context) error {
-					cfg, err := config.LoadFromPaths(c.String("root-config"),
-						c.String("config"))
+					cfg, err := loadConfig(c)
 					if err != nil {
 						return err
 					}
 
-					hostname, token, err := txlib.GetHostAndToken(
-						&cfg, c.String("hostname"), c.String("token"),
-					)
+					hostname, token, err := getHostAndToken(c, &cfg)
 					if err != nil {
 						return err
 					}
 
-					client, err := txlib.GetClient(c.String("cacert"))
+					client, headers, err := getClient(c, &cfg)
 					if err != nil {
 						return err
 					}
+					retryPolicy, err := getRetryPolicy(c, &cfg)
+					if err != nil {
+						return cli.Exit(errorColor(err.Error()), 1)
+					}
 					api := jsonapi.Connection{
-						Host:   hostname,
-						Token:  token,
-						Client: client,
-						Headers: map[string]string{
-							"Integration": "txclient",
-						},
+						Host:    hostname,
+						Token:   token,
+						Client:  client,
+						Retry:   retryPolicy,
+						Context: ctx,
+						Headers: headers,
 					}
 
 					resourceIds := c.Args().Slice()
@@ -540,6 +1033,13 @@ func Main() {
 						)
 						resourceIds = append(resourceIds, extraResourceIds...)
 					}
+					var excludeResourceIds []string
+					if c.String("exclude-resources") != "" {
+						excludeResourceIds = strings.Split(
+							c.String("exclude-resources"),
+							",",
+						)
+					}
 
 					workers := c.Int("workers")
 					if workers > 20 {
@@ -547,22 +1047,31 @@ func Main() {
 					}
 
 					arguments := txlib.PullCommandArguments{
-						ContentEncoding:   c.String("content_encoding"),
-						Mode:              c.String("mode"),
-						Force:             c.Bool("force"),
-						Skip:              c.Bool("skip"),
-						Source:            c.Bool("source"),
-						Translations:      c.Bool("translations"),
-						DisableOverwrite:  c.Bool("disable-overwrite"),
-						KeepNewFiles:      c.Bool("keep-new-files"),
-						All:               c.Bool("all"),
-						ResourceIds:       resourceIds,
-						UseGitTimestamps:  c.Bool("use-git-timestamps"),
-						Branch:            c.String("branch"),
-						MinimumPercentage: c.Int("minimum-perc"),
-						Workers:           workers,
-						Silent:            c.Bool("silent"),
-						Pseudo:            c.Bool("pseudo"),
+						ContentEncoding:    c.String("content_encoding"),
+						Mode:               c.String("mode"),
+						Force:              c.Bool("force"),
+						Skip:               c.Bool("skip"),
+						Source:             c.Bool("source"),
+						Translations:       c.Bool("translations"),
+						DisableOverwrite:   c.Bool("disable-overwrite"),
+						KeepNewFiles:       c.Bool("keep-new-files"),
+						All:                c.Bool("all"),
+						ResourceIds:        resourceIds,
+						ExcludeResourceIds: excludeResourceIds,
+						Tags:               config.ParseTags(c.String("tag")),
+						ExcludeTags:        config.ParseTags(c.String("exclude-tag")),
+						UseGitTimestamps:   c.Bool("use-git-timestamps"),
+						Branch:             c.String("branch"),
+						MinimumPercentage:  c.Int("minimum-perc"),
+						Workers:            workers,
+						Silent:             c.Bool("silent"),
+						Pseudo:             c.Bool("pseudo"),
+						DryRun:             c.Bool("dry-run"),
+						ReportPath:         c.String("report"),
+						Timeout:            c.Duration("timeout"),
+						Backup:             c.Bool("backup"),
+						BackupDir:          c.String("backup-dir"),
+						PreserveMode:       c.Bool("preserve-mode"),
 					}
 
 					if c.Bool("xliff") && c.Bool("json") {
@@ -615,7 +1124,290 @@ func Main() {
 						), 1)
 					}
 
-					err = txlib.PullCommand(&cfg, &api, &arguments)
+					err = txlib.PullCommand(ctx, &cfg, &api, &arguments)
+					if errors.Is(err, txlib.ErrInterrupted) {
+						return cli.Exit("", 130)
+					}
+					var tasksFailedError *txlib.TasksFailedError
+					if errors.As(err, &tasksFailedError) {
+						return cli.Exit(errorColor(err.Error()), 2)
+					}
+					if err != nil {
+						return cli.Exit(err, 1)
+					}
+					return nil
+				},
+			},
+			{
+				Name: "sync",
+				Usage: "tx sync [options] [resource_id...]; push the source " +
+					"files and then pull the translations",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:    "translation",
+						Aliases: []string{"t"},
+						Usage:   "Also push the translation files",
+					},
+					&cli.BoolFlag{
+						Name:    "force",
+						Aliases: []string{"f"},
+						Usage: "Push and pull files without checking " +
+							"modification times",
+					},
+					&cli.BoolFlag{
+						Name:  "skip",
+						Usage: "Whether to skip on errors",
+					},
+					&cli.BoolFlag{
+						Name: "use-git-timestamps",
+						Usage: "Compare local files to their Transifex " +
+							"version by their latest commit timestamps",
+					},
+					&cli.BoolFlag{
+						Name:    "all",
+						Aliases: []string{"a"},
+						Usage: "Pull all the languages of the resources, " +
+							"even if they don't have local files",
+					},
+					&cli.StringFlag{
+						Name:    "languages",
+						Aliases: []string{"l"},
+						Usage: "Only push and pull these comma-separated " +
+							"languages",
+					},
+					&cli.StringFlag{
+						Name:    "resources",
+						Aliases: []string{"r"},
+						Usage:   "Comma-separated resources to sync",
+					},
+					&cli.StringFlag{
+						Name: "exclude-resources",
+						Usage: "Comma-separated resources to leave out of " +
+							"the selection (supports the same patterns as " +
+							"--resources)",
+					},
+					&cli.StringFlag{
+						Name: "tag",
+						Usage: "Only select resources with one of these " +
+							"comma-separated tags",
+					},
+					&cli.StringFlag{
+						Name: "exclude-tag",
+						Usage: "Leave out resources with any of these " +
+							"comma-separated tags",
+					},
+					&cli.BoolFlag{
+						Name:    "recursive",
+						Aliases: []string{"workspace"},
+						Usage: "Use every '.tx/config' file under the current " +
+							"directory",
+					},
+					&cli.StringFlag{
+						Name: "ignore",
+						Usage: "Comma-separated directories to skip with " +
+							"--recursive (on top of '.git', 'node_modules', " +
+							"'vendor' and the ones in '.txignore')",
+					},
+					&cli.StringFlag{
+						Name: "branch",
+						Usage: "Sync a specific branch (use empty argument " +
+							"'' to use the current branch, if it can be " +
+							"determined)",
+						Value: "-1",
+					},
+					&cli.StringFlag{
+						Name: "base",
+						Usage: "Push current branch with a specific base branch. " +
+							"If omitted the main resource will be used as base",
+						Value: "-1",
+					},
+					&cli.StringFlag{
+						Name:    "mode",
+						Aliases: []string{"m"},
+						Value:   "default",
+						Usage:   "The translation mode of the downloaded files",
+					},
+					&cli.IntFlag{
+						Name: "minimum-perc",
+						Usage: "Specify the minimum acceptable percentage of " +
+							"a translation mode in order to download it.",
+						Value: -1,
+					},
+					&cli.BoolFlag{
+						Name: "wait",
+						Usage: "Wait for Transifex to finish processing the " +
+							"pushed source files before pulling",
+					},
+					&cli.IntFlag{
+						Name:    "workers",
+						Usage:   "How many parallel workers to use (max 20)",
+						Aliases: []string{"w"},
+						Value:   5,
+					},
+					&cli.BoolFlag{
+						Name:  "silent",
+						Usage: "Whether to reduce verbosity of the output",
+					},
+					&cli.BoolFlag{
+						Name: "replace-edited-strings",
+						Usage: "Whether to replace source strings that have been edited in the " +
+							"meantime",
+					},
+					&cli.BoolFlag{
+						Name: "keep-translations",
+						Usage: "Whether to not discard translations if a source string with a " +
+							"pre-existing key changes",
+					},
+					&cli.BoolFlag{
+						Name: "allow-deletions",
+						Usage: "Whether to push source files even if they would " +
+							"delete more source strings than allowed",
+					},
+					&cli.BoolFlag{
+						Name: "dry-run",
+						Usage: "Print what would be pushed and pulled without " +
+							"changing anything",
+					},
+					&cli.StringFlag{
+						Name:  "report",
+						Usage: "Write a JSON report of the outcome of every file to `FILE`",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					cfg, err := loadConfig(c)
+					if err != nil {
+						return cli.Exit(
+							errorColor("Error loading configuration: %s", err), 1,
+						)
+					}
+					hostname, token, err := getHostAndToken(c, &cfg)
+					if err != nil {
+						return cli.Exit(
+							errorColor("Error getting API token: %s", err), 1,
+						)
+					}
+
+					client, headers, err := getClient(c, &cfg)
+					if err != nil {
+						return cli.Exit(
+							errorColor(
+								"Error getting HTTP client configuration: %s",
+								err,
+							),
+							1,
+						)
+					}
+
+					retryPolicy, err := getRetryPolicy(c, &cfg)
+					if err != nil {
+						return cli.Exit(errorColor(err.Error()), 1)
+					}
+					api := jsonapi.Connection{
+						Host:    hostname,
+						Token:   token,
+						Client:  client,
+						Retry:   retryPolicy,
+						Context: ctx,
+						Headers: headers,
+					}
+
+					resourceIds := c.Args().Slice()
+					if c.String("resources") != "" {
+						extraResourceIds := strings.Split(
+							c.String("resources"),
+							",",
+						)
+						resourceIds = append(resourceIds, extraResourceIds...)
+					}
+					var excludeResourceIds []string
+					if c.String("exclude-resources") != "" {
+						excludeResourceIds = strings.Split(
+							c.String("exclude-resources"),
+							",",
+						)
+					}
+
+					var languages []string
+					if c.String("languages") != "" {
+						languages = strings.Split(c.String("languages"), ",")
+					}
+					if len(languages) > 0 && c.Bool("all") {
+						return cli.Exit(errorColor(
+							"You cannot use both flags '%s' and '%s'.",
+							"languages", "all",
+						), 1)
+					}
+					if c.Bool("force") && c.Bool("use-git-timestamps") {
+						return cli.Exit(errorColor(
+							"It doesn't make sense to use the '--force' "+
+								"flag with the '--use-git-timestamps' flag",
+						), 1)
+					}
+
+					workers := c.Int("workers")
+					if workers > 20 {
+						workers = 20
+					}
+
+					args := txlib.SyncCommandArguments{
+						Push: txlib.PushCommandArguments{
+							Source:               true,
+							Translation:          c.Bool("translation"),
+							Force:                c.Bool("force"),
+							Skip:                 c.Bool("skip"),
+							ResourceIds:          resourceIds,
+							ExcludeResourceIds:   excludeResourceIds,
+							Tags:                 config.ParseTags(c.String("tag")),
+							ExcludeTags:          config.ParseTags(c.String("exclude-tag")),
+							UseGitTimestamps:     c.Bool("use-git-timestamps"),
+							Branch:               c.String("branch"),
+							Base:                 c.String("base"),
+							Workers:              workers,
+							Silent:               c.Bool("silent"),
+							ReplaceEditedStrings: c.Bool("replace-edited-strings"),
+							KeepTranslations:     c.Bool("keep-translations"),
+							DryRun:               c.Bool("dry-run"),
+							ReportPath:           c.String("report"),
+							Timeout:              c.Duration("timeout"),
+							AllowDeletions:       c.Bool("allow-deletions"),
+						},
+						Pull: txlib.PullCommandArguments{
+							FileType:           "default",
+							Mode:               c.String("mode"),
+							ContentEncoding:    "text",
+							Force:              c.Bool("force"),
+							Skip:               c.Bool("skip"),
+							Languages:          languages,
+							Translations:       true,
+							All:                c.Bool("all"),
+							ResourceIds:        resourceIds,
+							ExcludeResourceIds: excludeResourceIds,
+							Tags:               config.ParseTags(c.String("tag")),
+							ExcludeTags:        config.ParseTags(c.String("exclude-tag")),
+							UseGitTimestamps:   c.Bool("use-git-timestamps"),
+							Branch:             c.String("branch"),
+							MinimumPercentage:  c.Int("minimum-perc"),
+							Workers:            workers,
+							Silent:             c.Bool("silent"),
+							DryRun:             c.Bool("dry-run"),
+							Timeout:            c.Duration("timeout"),
+						},
+						Wait: c.Bool("wait"),
+					}
+					// Which translations are pushed follows the same
+					// languages as the pull
+					if args.Push.Translation {
+						args.Push.Languages = languages
+					}
+
+					err = txlib.SyncCommand(ctx, &cfg, api, args)
+					if errors.Is(err, txlib.ErrInterrupted) {
+						return cli.Exit("", 130)
+					}
+					var tasksFailedError *txlib.TasksFailedError
+					if errors.As(err, &tasksFailedError) {
+						return cli.Exit(errorColor(err.Error()), 2)
+					}
 					if err != nil {
 						return cli.Exit(err, 1)
 					}
@@ -677,18 +1469,25 @@ func Main() {
 					}
 
 					if missingFlagsCount == len(requiredFlagList) {
-						hostname, token, err := txlib.GetHostAndToken(
-							&cfg, c.String("hostname"), c.String("token"),
-						)
+						hostname, token, err := getHostAndToken(c, &cfg)
 						if err != nil {
 							return cli.Exit(err, 1)
 						}
+						retryPolicy, err := getRetryPolicy(c, &cfg)
+						if err != nil {
+							return cli.Exit(errorColor(err.Error()), 1)
+						}
+						client, headers, err := getClient(c, &cfg)
+						if err != nil {
+							return cli.Exit(errorColor(err.Error()), 1)
+						}
 						api := jsonapi.Connection{
-							Host:  hostname,
-							Token: token,
-							Headers: map[string]string{
-								"Integration": "txclient",
-							},
+							Host:    hostname,
+							Token:   token,
+							Client:  client,
+							Retry:   retryPolicy,
+							Context: ctx,
+							Headers: headers,
 						}
 						err = txlib.AddCommandInteractive(&cfg, api)
 						if err != nil {
@@ -782,9 +1581,7 @@ func Main() {
 									1,
 								)
 							}
-							hostname, token, err := txlib.GetHostAndToken(
-								&cfg, c.String("hostname"), c.String("token"),
-							)
+							hostname, token, err := getHostAndToken(c, &cfg)
 							if err != nil {
 								return cli.Exit(
 									errorColor(
@@ -794,7 +1591,7 @@ func Main() {
 									1,
 								)
 							}
-							client, err := txlib.GetClient(c.String("cacert"))
+							client, headers, err := getClient(c, &cfg)
 							if err != nil {
 								return cli.Exit(
 									errorColor(
@@ -804,13 +1601,17 @@ func Main() {
 									1,
 								)
 							}
+							retryPolicy, err := getRetryPolicy(c, &cfg)
+							if err != nil {
+								return cli.Exit(errorColor(err.Error()), 1)
+							}
 							api := jsonapi.Connection{
-								Host:   hostname,
-								Token:  token,
-								Client: client,
-								Headers: map[string]string{
-									"Integration": "txclient",
-								},
+								Host:    hostname,
+								Token:   token,
+								Client:  client,
+								Retry:   retryPolicy,
+								Context: ctx,
+								Headers: headers,
 							}
 
 							projectUrls := c.Args().Slice()
@@ -875,6 +1676,22 @@ func Main() {
 						Aliases: []string{"r"},
 						Usage:   "Resource ids to delete",
 					},
+					&cli.StringFlag{
+						Name: "exclude-resources",
+						Usage: "Comma-separated resources to leave out of " +
+							"the selection (supports the same patterns as " +
+							"--resources)",
+					},
+					&cli.StringFlag{
+						Name: "tag",
+						Usage: "Only select resources with one of these " +
+							"comma-separated tags",
+					},
+					&cli.StringFlag{
+						Name: "exclude-tag",
+						Usage: "Leave out resources with any of these " +
+							"comma-separated tags",
+					},
 					&cli.BoolFlag{
 						Name:    "force",
 						Aliases: []string{"f"},
@@ -901,25 +1718,27 @@ func Main() {
 						return err
 					}
 
-					hostname, token, err := txlib.GetHostAndToken(
-						&cfg, c.String("hostname"), c.String("token"),
-					)
+					hostname, token, err := getHostAndToken(c, &cfg)
 					if err != nil {
 						return err
 					}
 
-					client, err := txlib.GetClient(c.String("cacert"))
+					client, headers, err := getClient(c, &cfg)
 					if err != nil {
 						return err
 					}
 
+					retryPolicy, err := getRetryPolicy(c, &cfg)
+					if err != nil {
+						return cli.Exit(errorColor(err.Error()), 1)
+					}
 					api := jsonapi.Connection{
-						Host:   hostname,
-						Token:  token,
-						Client: client,
-						Headers: map[string]string{
-							"Integration": "txclient",
-						},
+						Host:    hostname,
+						Token:   token,
+						Client:  client,
+						Retry:   retryPolicy,
+						Context: ctx,
+						Headers: headers,
 					}
 
 					// Get extra resource ids
@@ -931,13 +1750,23 @@ func Main() {
 						)
 						resourceIds = append(resourceIds, extraResourceIds...)
 					}
+					var excludeResourceIds []string
+					if c.String("exclude-resources") != "" {
+						excludeResourceIds = strings.Split(
+							c.String("exclude-resources"),
+							",",
+						)
+					}
 
 					// Construct arguments
 					arguments := txlib.DeleteCommandArguments{
-						ResourceIds: resourceIds,
-						Force:       c.Bool("force"),
-						Skip:        c.Bool("skip"),
-						Branch:      c.String("branch"),
+						ResourceIds:        resourceIds,
+						ExcludeResourceIds: excludeResourceIds,
+						Tags:               config.ParseTags(c.String("tag")),
+						ExcludeTags:        config.ParseTags(c.String("exclude-tag")),
+						Force:              c.Bool("force"),
+						Skip:               c.Bool("skip"),
+						Branch:             c.String("branch"),
 					}
 					// Proceed with deletion
 					err = txlib.DeleteCommand(&cfg, api, &arguments)
@@ -947,6 +1776,76 @@ func Main() {
 					return nil
 				},
 			},
+			{
+				Name: "dev-server",
+				Usage: "Run a fake Transifex API locally, for trying out " +
+					"and testing the client without network access",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "address",
+						Usage: "Listen on this `HOST:PORT`",
+						Value: "127.0.0.1:8080",
+					},
+					&cli.StringFlag{
+						Name: "require-token",
+						Usage: "Only accept requests with this API token " +
+							"(by default any token is accepted)",
+					},
+					&cli.StringFlag{
+						Name:  "source-language",
+						Usage: "Source language of the projects that are created",
+						Value: "en",
+					},
+					&cli.StringFlag{
+						Name: "languages",
+						Usage: "Comma-separated target languages of the " +
+							"projects that are created",
+					},
+					&cli.StringSliceFlag{
+						Name: "project",
+						Usage: "Create this project (eg 'o:org:p:project') " +
+							"on top of the ones of the local configuration",
+					},
+					&cli.IntFlag{
+						Name: "throttle-every",
+						Usage: "Throttle every Nth request that starts an " +
+							"upload, download or merge",
+					},
+					&cli.BoolFlag{
+						Name:  "quiet",
+						Usage: "Do not print a line for every request",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					cfg, err := config.LoadFromPaths(
+						c.String("root-config"), c.String("config"),
+					)
+					if err != nil {
+						return cli.Exit(errorColor(
+							"Error loading configuration: %s", err,
+						), 1)
+					}
+					var languages []string
+					if c.String("languages") != "" {
+						languages = strings.Split(c.String("languages"), ",")
+					}
+					err = txlib.DevServerCommand(
+						ctx, &cfg, txlib.DevServerCommandArguments{
+							Address:        c.String("address"),
+							Token:          c.String("require-token"),
+							SourceLanguage: c.String("source-language"),
+							Languages:      languages,
+							Projects:       c.StringSlice("project"),
+							ThrottleEvery:  c.Int("throttle-every"),
+							Quiet:          c.Bool("quiet"),
+						},
+					)
+					if err != nil {
+						return cli.Exit(errorColor(err.Error()), 1)
+					}
+					return nil
+				},
+			},
 			{
 				Name:  "update",
 				Usage: "Update the `tx` application if there is a newer version",
@@ -957,8 +1856,10 @@ func Main() {
 						Usage:   "Check if there is a new version of tx",
 					},
 					&cli.BoolFlag{
-						Name:    "no-interactive",
-						Aliases: []string{"ni"},
+						Name: "yes",
+						// '--no-interactive' is kept for existing scripts,
+						// even though the global flag means something else
+						Aliases: []string{"y", "no-interactive", "ni"},
 						Usage:   "Update if there is a newer version without prompt",
 					},
 					&cli.BoolFlag{
@@ -970,10 +1871,10 @@ func Main() {
 				Action: func(c *cli.Context) error {
 					version := c.App.Version
 					arguments := txlib.UpdateCommandArguments{
-						Version:       version,
-						Check:         c.Bool("check"),
-						NoInteractive: c.Bool("no-interactive"),
-						Debug:         c.Bool("debug"),
+						Version: version,
+						Check:   c.Bool("check"),
+						Yes:     c.Bool("yes"),
+						Debug:   c.Bool("debug"),
 					}
 
 					err := txlib.UpdateCommand(arguments)
@@ -997,33 +1898,72 @@ func Main() {
 						Usage: "Resource ids to get status for that are " +
 							"included in your config file",
 					},
+					&cli.StringFlag{
+						Name: "exclude-resources",
+						Usage: "Comma-separated resources to leave out of " +
+							"the selection (supports the same patterns as " +
+							"--resources)",
+					},
+					&cli.StringFlag{
+						Name: "tag",
+						Usage: "Only select resources with one of these " +
+							"comma-separated tags",
+					},
+					&cli.StringFlag{
+						Name: "exclude-tag",
+						Usage: "Leave out resources with any of these " +
+							"comma-separated tags",
+					},
+					&cli.BoolFlag{
+						Name:    "recursive",
+						Aliases: []string{"workspace"},
+						Usage: "Use every '.tx/config' file under the current " +
+							"directory",
+					},
+					&cli.StringFlag{
+						Name: "ignore",
+						Usage: "Comma-separated directories to skip with " +
+							"--recursive (on top of '.git', 'node_modules', " +
+							"'vendor' and the ones in '.txignore')",
+					},
+					&cli.BoolFlag{
+						Name:  "json",
+						Usage: "Print the status as JSON (same as '--format json')",
+					},
+					&cli.StringFlag{
+						Name: "format",
+						Usage: "Output format, one of 'text', 'json', 'yaml' " +
+							"or 'table'",
+						Value: "text",
+					},
 				},
 				Action: func(c *cli.Context) error {
-					cfg, err := config.LoadFromPaths(c.String("root-config"),
-						c.String("config"))
+					cfg, err := loadConfig(c)
 					if err != nil {
 						return err
 					}
 
-					hostname, token, err := txlib.GetHostAndToken(
-						&cfg, c.String("hostname"), c.String("token"),
-					)
+					hostname, token, err := getHostAndToken(c, &cfg)
 					if err != nil {
 						return err
 					}
 
-					client, err := txlib.GetClient(c.String("cacert"))
+					client, headers, err := getClient(c, &cfg)
 					if err != nil {
 						return err
 					}
 
+					retryPolicy, err := getRetryPolicy(c, &cfg)
+					if err != nil {
+						return cli.Exit(errorColor(err.Error()), 1)
+					}
 					api := jsonapi.Connection{
-						Host:   hostname,
-						Token:  token,
-						Client: client,
-						Headers: map[string]string{
-							"Integration": "txclient",
-						},
+						Host:    hostname,
+						Token:   token,
+						Client:  client,
+						Retry:   retryPolicy,
+						Context: ctx,
+						Headers: headers,
 					}
 
 					// Get extra resource ids
@@ -1035,21 +1975,318 @@ func Main() {
 						)
 						resourceIds = append(resourceIds, extraResourceIds...)
 					}
+					var excludeResourceIds []string
+					if c.String("exclude-resources") != "" {
+						excludeResourceIds = strings.Split(
+							c.String("exclude-resources"),
+							",",
+						)
+					}
+
+					format := c.String("format")
+					if c.Bool("json") {
+						if c.IsSet("format") && format != "json" {
+							return cli.Exit(errorColor(
+								"You cannot use both flags '%s' and '%s'.",
+								"json", "format",
+							), 1)
+						}
+						format = "json"
+					}
 
 					// Construct arguments
 					arguments := txlib.StatusCommandArguments{
-						ResourceIds: resourceIds,
+						ResourceIds:        resourceIds,
+						ExcludeResourceIds: excludeResourceIds,
+						Tags:               config.ParseTags(c.String("tag")),
+						ExcludeTags:        config.ParseTags(c.String("exclude-tag")),
+						Format:             format,
 					}
-					// Proceed with deletion
 					err = txlib.StatusCommand(&cfg, api, &arguments)
+					var tasksFailedError *txlib.TasksFailedError
+					if errors.As(err, &tasksFailedError) {
+						return cli.Exit(errorColor(
+							"Could not fetch the status of %d resource(s)",
+							tasksFailedError.Failed,
+						), 2)
+					}
 					if err != nil {
 						return cli.Exit(err, 1)
 					}
 					return nil
 				},
 			},
+			{
+				Name: "diff",
+				Usage: "tx diff [resource_id...]; compare local source files " +
+					"with the source strings on Transifex",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "resources",
+						Aliases: []string{"r"},
+						Usage: "Resource ids to compare that are included in " +
+							"your config file",
+					},
+					&cli.StringFlag{
+						Name: "exclude-resources",
+						Usage: "Comma-separated resources to leave out of " +
+							"the selection (supports the same patterns as " +
+							"--resources)",
+					},
+					&cli.StringFlag{
+						Name: "tag",
+						Usage: "Only select resources with one of these " +
+							"comma-separated tags",
+					},
+					&cli.StringFlag{
+						Name: "exclude-tag",
+						Usage: "Leave out resources with any of these " +
+							"comma-separated tags",
+					},
+					&cli.StringFlag{
+						Name: "branch",
+						Usage: "Compare with specific branch (use empty " +
+							"argument '' to use the current branch, if it " +
+							"can be determined)",
+						Value: "-1",
+					},
+					&cli.IntFlag{
+						Name:    "workers",
+						Usage:   "How many parallel workers to use (max 20)",
+						Aliases: []string{"w"},
+						Value:   5,
+					},
+					&cli.BoolFlag{
+						Name:  "silent",
+						Usage: "Whether to reduce verbosity of the output",
+					},
+					&cli.BoolFlag{
+						Name:  "summary",
+						Usage: "Only print the number of changed strings per resource",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					cfg, err := config.LoadFromPaths(c.String("root-config"),
+						c.String("config"))
+					if err != nil {
+						return err
+					}
+
+					hostname, token, err := getHostAndToken(c, &cfg)
+					if err != nil {
+						return err
+					}
+
+					client, headers, err := getClient(c, &cfg)
+					if err != nil {
+						return err
+					}
+
+					retryPolicy, err := getRetryPolicy(c, &cfg)
+					if err != nil {
+						return cli.Exit(errorColor(err.Error()), 1)
+					}
+					api := jsonapi.Connection{
+						Host:    hostname,
+						Token:   token,
+						Client:  client,
+						Retry:   retryPolicy,
+						Context: ctx,
+						Headers: headers,
+					}
+
+					resourceIds := c.Args().Slice()
+					if c.String("resources") != "" {
+						extraResourceIds := strings.Split(
+							c.String("resources"),
+							",",
+						)
+						resourceIds = append(resourceIds, extraResourceIds...)
+					}
+					var excludeResourceIds []string
+					if c.String("exclude-resources") != "" {
+						excludeResourceIds = strings.Split(
+							c.String("exclude-resources"),
+							",",
+						)
+					}
+
+					workers := c.Int("workers")
+					if workers > 20 {
+						workers = 20
+					}
+
+					err = txlib.DiffCommand(ctx, &cfg, api, txlib.DiffCommandArguments{
+						ResourceIds:        resourceIds,
+						ExcludeResourceIds: excludeResourceIds,
+						Tags:               config.ParseTags(c.String("tag")),
+						ExcludeTags:        config.ParseTags(c.String("exclude-tag")),
+						Branch:             c.String("branch"),
+						Workers:            workers,
+						Silent:             c.Bool("silent"),
+						Summary:            c.Bool("summary"),
+						Timeout:            c.Duration("timeout"),
+					})
+					if errors.Is(err, txlib.ErrInterrupted) {
+						return cli.Exit("", 130)
+					}
+					if err != nil {
+						return cli.Exit(errorColor(err.Error()), 1)
+					}
+					return nil
+				},
+			},
+			{
+				Name:  "auth",
+				Usage: "Manage the API tokens in the root configuration",
+				Subcommands: []*cli.Command{
+					{
+						Name: "login",
+						Usage: "tx auth login [--hostname HOST]; check an API " +
+							"token and save it",
+						Flags: []cli.Flag{
+							authHostnameFlag,
+							&cli.StringFlag{
+								Name: "rest-hostname",
+								Usage: "The API hostname, if the host is not " +
+									"in the root configuration yet",
+							},
+							&cli.StringFlag{
+								Name: "credential-helper",
+								Usage: "Store the token with this `COMMAND` " +
+									"instead of in the root configuration",
+							},
+						},
+						Action: authAction(func(
+							cfg *config.Config,
+							api jsonapi.Connection,
+							args txlib.AuthCommandArguments,
+						) error {
+							return txlib.AuthLoginCommand(cfg, api, args)
+						}),
+					},
+					{
+						Name: "logout",
+						Usage: "tx auth logout [--hostname HOST]; remove a " +
+							"saved API token",
+						Flags: []cli.Flag{authHostnameFlag},
+						Action: authAction(func(
+							cfg *config.Config,
+							api jsonapi.Connection,
+							args txlib.AuthCommandArguments,
+						) error {
+							return txlib.AuthLogoutCommand(cfg, args)
+						}),
+					},
+					{
+						Name: "status",
+						Usage: "tx auth status [--hostname HOST]; show which " +
+							"host and token would be used here and check them",
+						Flags: []cli.Flag{authHostnameFlag},
+						Action: authAction(func(
+							cfg *config.Config,
+							api jsonapi.Connection,
+							args txlib.AuthCommandArguments,
+						) error {
+							return txlib.AuthStatusCommand(cfg, api, args)
+						}),
+					},
+					{
+						Name: "list",
+						Usage: "tx auth list; list the hosts of the root " +
+							"configuration",
+						Action: authAction(func(
+							cfg *config.Config,
+							api jsonapi.Connection,
+							args txlib.AuthCommandArguments,
+						) error {
+							return txlib.AuthListCommand(cfg, args)
+						}),
+					},
+				},
+			},
+			{
+				Name:  "config",
+				Usage: "Read and change the local configuration file",
+				Subcommands: []*cli.Command{
+					{
+						Name:  "get",
+						Usage: "tx config get [--resource RESOURCE_ID] KEY",
+						Flags: []cli.Flag{configResourceFlag},
+						Action: configAction(
+							txlib.ConfigGetCommand,
+							"tx config get [--resource RESOURCE_ID] KEY",
+							1,
+						),
+					},
+					{
+						Name:  "set",
+						Usage: "tx config set [--resource RESOURCE_ID] KEY VALUE",
+						Flags: []cli.Flag{configResourceFlag},
+						Action: configAction(
+							txlib.ConfigSetCommand,
+							"tx config set [--resource RESOURCE_ID] KEY VALUE",
+							2,
+						),
+					},
+					{
+						Name:  "unset",
+						Usage: "tx config unset [--resource RESOURCE_ID] KEY",
+						Flags: []cli.Flag{configResourceFlag},
+						Action: configAction(
+							txlib.ConfigUnsetCommand,
+							"tx config unset [--resource RESOURCE_ID] KEY",
+							1,
+						),
+					},
+					{
+						Name:  "list",
+						Usage: "tx config list [--resource RESOURCE_ID]",
+						Flags: []cli.Flag{configResourceFlag},
+						Action: configAction(
+							txlib.ConfigListCommand,
+							"tx config list [--resource RESOURCE_ID]",
+							0,
+						),
+					},
+					{
+						Name: "lint",
+						Usage: "tx config lint; check the local configuration " +
+							"for mistakes without contacting Transifex",
+						Flags: []cli.Flag{
+							&cli.BoolFlag{
+								Name:  "strict",
+								Usage: "Whether to also fail if there are only warnings",
+							},
+						},
+						Action: func(c *cli.Context) error {
+							cfg, err := config.LoadFromPaths(
+								c.String("root-config"), c.String("config"),
+							)
+							if err != nil {
+								return cli.Exit(errorColor(
+									"Error loading configuration: %s", err,
+								), 1)
+							}
+							err = txlib.LintCommand(&cfg, txlib.LintCommandArguments{
+								Strict: c.Bool("strict"),
+							})
+							if err != nil {
+								return cli.Exit(errorColor(err.Error()), 1)
+							}
+							return nil
+						},
+					},
+				},
+			},
 		},
 		Flags: flags,
+		// Commands that would wait for input that may never come (eg in CI)
+		// fail right away instead
+		Before: func(c *cli.Context) error {
+			txlib.Interactive = txlib.DetectInteractive(c.Bool("no-interactive"))
+			return nil
+		},
 	}
 
 	err := app.Run(os.Args)
@@ -125,6 +125,15 @@ func getInputTemplate(str string) *promptui.PromptTemplates {
 }
 
 func AddCommandInteractive(cfg *config.Config, api jsonapi.Connection) error {
+	err := checkInteractive(
+		"the resource to add",
+		"run 'tx add' with --organization, --project, --resource, "+
+			"--file-filter and --type",
+	)
+	if err != nil {
+		return err
+	}
+
 	type selectedItem struct {
 		Name  string
 		Value string
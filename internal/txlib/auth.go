@@ -0,0 +1,321 @@
+package txlib
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/transifex/cli/internal/txlib/config"
+	"github.com/transifex/cli/pkg/jsonapi"
+	"github.com/transifex/cli/pkg/txapi"
+)
+
+const (
+	defaultHostname     = "https://app.transifex.com"
+	defaultRestHostname = "https://rest.api.transifex.com"
+)
+
+/*
+AuthCommandArguments
+'Hostname' selects the host (a section of the root configuration, by name or
+by 'rest_hostname'); if empty, the host of the local configuration is used,
+like in every other command. 'Token' is the token given on the command line
+and 'TokenSource' describes where it came from (eg the '--token' flag).
+'RestHostname' and 'CredentialHelper' are only used by 'login', to set up a
+host that is not in the root configuration yet.
+*/
+type AuthCommandArguments struct {
+	Hostname         string
+	RestHostname     string
+	Token            string
+	TokenSource      string
+	CredentialHelper string
+}
+
+type resolvedHost struct {
+	name         string
+	restHostname string
+	// nil if the root configuration doesn't have a section for the host
+	host *config.Host
+	// Why this host was picked, for 'tx auth status'
+	reason string
+}
+
+/*
+Figure out which host the commands would talk to; see 'GetHostAndToken' for
+the rules
+*/
+func resolveHost(cfg *config.Config, hostname string) resolvedHost {
+	if hostname != "" {
+		result := resolvedHost{
+			name:         hostname,
+			restHostname: hostname,
+			reason:       "from --hostname or TX_HOSTNAME",
+		}
+		if cfg.Root != nil {
+			result.host = cfg.FindHost(hostname)
+		}
+		if result.host != nil {
+			result.restHostname = result.host.RestHostname
+		}
+		return result
+	}
+
+	if cfg.Root != nil {
+		activeHost := cfg.GetActiveHost()
+		if activeHost != nil {
+			return resolvedHost{
+				name:         activeHost.Name,
+				restHostname: activeHost.RestHostname,
+				host:         activeHost,
+				reason: fmt.Sprintf(
+					"from 'host' in '%s'", cfg.Local.Path,
+				),
+			}
+		}
+	}
+
+	result := resolvedHost{
+		name:         defaultHostname,
+		restHostname: defaultRestHostname,
+		reason:       "default",
+	}
+	if cfg.Local != nil && cfg.Local.Host != "" {
+		result.reason = fmt.Sprintf(
+			"default, because the host '%s' of '%s' is not in the root "+
+				"configuration",
+			cfg.Local.Host, cfg.Local.Path,
+		)
+	}
+	return result
+}
+
+/*
+AuthLoginCommand
+Save a token for a host in the root configuration (or give it to the host's
+credential helper), after checking that the API accepts it. The user is asked
+for the token if it wasn't given.
+*/
+func AuthLoginCommand(
+	cfg *config.Config, api jsonapi.Connection, args AuthCommandArguments,
+) error {
+	resolved := resolveHost(cfg, args.Hostname)
+	restHostname := resolved.restHostname
+	if args.RestHostname != "" {
+		restHostname = args.RestHostname
+	} else if resolved.host == nil && resolved.name == defaultHostname {
+		restHostname = defaultRestHostname
+	}
+
+	token := args.Token
+	if token == "" {
+		err := checkInteractive(
+			"an API token", "provide it with --token, TX_TOKEN or --token-file",
+		)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Please provide the API token for '%s'.\n", resolved.name)
+		fmt.Println("If you don't have an API token, you can generate " +
+			"one in https://app.transifex.com/user/settings/api/")
+		fmt.Print("> ")
+		_, err = fmt.Scanln(&token)
+		if err != nil {
+			return err
+		}
+	}
+
+	api.Host = restHostname
+	api.Token = token
+	organizations, err := getOrganizationSlugs(&api)
+	if err != nil {
+		return fmt.Errorf("the token was not saved: %w", err)
+	}
+
+	if cfg.Root == nil {
+		rootConfigPath, err := config.GetRootPath()
+		if err != nil {
+			return err
+		}
+		cfg.Root = &config.RootConfig{Path: rootConfigPath}
+	}
+	host := resolved.host
+	if host == nil {
+		cfg.Root.Hosts = append(cfg.Root.Hosts, config.Host{
+			Name: resolved.name,
+		})
+		host = &cfg.Root.Hosts[len(cfg.Root.Hosts)-1]
+	}
+	host.RestHostname = restHostname
+	if args.CredentialHelper != "" {
+		host.CredentialHelper = args.CredentialHelper
+	}
+	if host.CredentialHelper != "" {
+		helper := CredentialHelper{Command: host.CredentialHelper}
+		err = helper.Store(host, token)
+		if err != nil {
+			return err
+		}
+		// Don't leave a stale token behind in plain text
+		host.Token = ""
+	} else {
+		host.Token = token
+	}
+	err = cfg.Save()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Logged in to '%s' (%s)\n", resolved.name, restHostname)
+	printOrganizations(organizations)
+	return nil
+}
+
+/*
+AuthLogoutCommand
+Remove the token of a host from the root configuration and from its
+credential helper. The rest of the host's settings are kept.
+*/
+func AuthLogoutCommand(cfg *config.Config, args AuthCommandArguments) error {
+	resolved := resolveHost(cfg, args.Hostname)
+	host := resolved.host
+	if host == nil || (host.Token == "" && host.CredentialHelper == "") {
+		return fmt.Errorf("not logged in to '%s'", resolved.name)
+	}
+	if host.CredentialHelper != "" {
+		helper := CredentialHelper{Command: host.CredentialHelper}
+		err := helper.Erase(host)
+		if err != nil {
+			return err
+		}
+	}
+	host.Token = ""
+	err := cfg.Save()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Logged out of '%s'\n", resolved.name)
+	return nil
+}
+
+/*
+AuthStatusCommand
+Show which host and token the other commands would use in the current
+directory, where they come from and which organizations the token can access.
+Fails if there is no token or if the API rejects it.
+*/
+func AuthStatusCommand(
+	cfg *config.Config, api jsonapi.Connection, args AuthCommandArguments,
+) error {
+	resolved := resolveHost(cfg, args.Hostname)
+	fmt.Printf("Host: %s (%s)\n", resolved.name, resolved.reason)
+	fmt.Printf("API: %s\n", resolved.restHostname)
+
+	token := args.Token
+	source := args.TokenSource
+	if token == "" && resolved.host != nil {
+		host := resolved.host
+		if host.CredentialHelper != "" {
+			helper := CredentialHelper{Command: host.CredentialHelper}
+			var err error
+			token, err = helper.Get(host)
+			if err != nil {
+				return err
+			}
+			source = fmt.Sprintf(
+				"from the credential helper '%s'", host.CredentialHelper,
+			)
+		} else {
+			token = host.Token
+			source = fmt.Sprintf("from '%s'", cfg.Root.Path)
+		}
+	}
+	if token == "" {
+		return errors.New("not logged in, run 'tx auth login'")
+	}
+	fmt.Printf("Token: %s (%s)\n", maskToken(token), source)
+
+	api.Host = resolved.restHostname
+	api.Token = token
+	organizations, err := getOrganizationSlugs(&api)
+	if err != nil {
+		return err
+	}
+	printOrganizations(organizations)
+	return nil
+}
+
+/*
+AuthListCommand
+List the hosts of the root configuration and how their tokens are stored,
+marking the one that would be used in the current directory with a '*'
+*/
+func AuthListCommand(cfg *config.Config, args AuthCommandArguments) error {
+	if cfg.Root == nil || len(cfg.Root.Hosts) == 0 {
+		fmt.Println("No hosts configured, run 'tx auth login'")
+		return nil
+	}
+	active := resolveHost(cfg, args.Hostname).host
+	for i := range cfg.Root.Hosts {
+		host := &cfg.Root.Hosts[i]
+		marker := " "
+		if host == active {
+			marker = "*"
+		}
+		fmt.Printf("%s %s\n", marker, host.Name)
+		fmt.Printf("    API: %s\n", host.RestHostname)
+		if host.CredentialHelper != "" {
+			fmt.Printf(
+				"    Token: credential helper '%s'\n", host.CredentialHelper,
+			)
+		} else if host.Token != "" {
+			fmt.Printf(
+				"    Token: %s (in '%s')\n", maskToken(host.Token), cfg.Root.Path,
+			)
+		} else {
+			fmt.Println("    Token: none")
+		}
+	}
+	return nil
+}
+
+func getOrganizationSlugs(api *jsonapi.Connection) ([]string, error) {
+	organizations, err := txapi.GetOrganizations(api)
+	if err != nil {
+		var apiError *jsonapi.Error
+		if errors.As(err, &apiError) && apiError.StatusCode == 401 {
+			return nil, fmt.Errorf("the token was rejected by '%s'", api.Host)
+		}
+		return nil, fmt.Errorf(
+			"could not check the token with '%s': %w", api.Host, err,
+		)
+	}
+	var result []string
+	for _, organization := range organizations {
+		var attributes txapi.OrganizationAttributes
+		err := organization.MapAttributes(&attributes)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, attributes.Slug)
+	}
+	return result, nil
+}
+
+func printOrganizations(organizations []string) {
+	if len(organizations) == 0 {
+		fmt.Println("The token cannot access any organizations")
+		return
+	}
+	fmt.Printf(
+		"Organizations: %s\n", strings.Join(organizations, ", "),
+	)
+}
+
+// Enough of a token to tell tokens apart without revealing them
+func maskToken(token string) string {
+	if len(token) <= 8 {
+		return strings.Repeat("*", len(token))
+	}
+	return strings.Repeat("*", 8) + token[len(token)-4:]
+}
@@ -0,0 +1,109 @@
+package txlib
+
+import (
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/transifex/cli/internal/txlib/config"
+	"github.com/transifex/cli/pkg/fakeapi"
+	"github.com/transifex/cli/pkg/jsonapi"
+)
+
+func TestAuthLoginAndLogout(t *testing.T) {
+	server := fakeapi.New()
+	server.Token = "good"
+	server.AddProject("orgslug", "projslug", "en")
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	rootPath := filepath.Join(t.TempDir(), ".transifexrc")
+	cfg := config.Config{Root: &config.RootConfig{Path: rootPath}}
+	args := AuthCommandArguments{
+		Hostname:     "https://app.transifex.com",
+		RestHostname: ts.URL,
+		Token:        "bad",
+	}
+
+	err := AuthLoginCommand(&cfg, jsonapi.Connection{}, args)
+	if err == nil {
+		t.Error("Did not get error when logging in with a rejected token")
+	}
+	if len(cfg.Root.Hosts) != 0 {
+		t.Errorf("Rejected token was saved: %+v", cfg.Root.Hosts)
+	}
+
+	args.Token = "good"
+	err = AuthLoginCommand(&cfg, jsonapi.Connection{}, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved, err := config.LoadFromPathsWithoutLocal(rootPath, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	host := saved.FindHost("https://app.transifex.com")
+	if host == nil || host.RestHostname != ts.URL || host.Token != "good" {
+		t.Fatalf("Token was not saved: %+v", saved.Root.Hosts)
+	}
+
+	// Status finds the saved token through the host
+	err = AuthStatusCommand(&saved, jsonapi.Connection{}, AuthCommandArguments{
+		Hostname: "https://app.transifex.com",
+	})
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = AuthLogoutCommand(&saved, AuthCommandArguments{
+		Hostname: "https://app.transifex.com",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved, err = config.LoadFromPathsWithoutLocal(rootPath, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	host = saved.FindHost("https://app.transifex.com")
+	if host == nil || host.Token != "" || host.RestHostname != ts.URL {
+		t.Errorf("Logging out did not only remove the token: %+v", host)
+	}
+
+	err = AuthStatusCommand(&saved, jsonapi.Connection{}, AuthCommandArguments{
+		Hostname: "https://app.transifex.com",
+	})
+	if err == nil {
+		t.Error("Did not get error for status after logging out")
+	}
+}
+
+func TestResolveHost(t *testing.T) {
+	cfg := config.Config{
+		Root: &config.RootConfig{Hosts: []config.Host{
+			{Name: "https://a.example.com", RestHostname: "https://api.a.example.com"},
+			{Name: "https://b.example.com", RestHostname: "https://api.b.example.com"},
+		}},
+		Local: &config.LocalConfig{
+			Host: "https://b.example.com", Path: ".tx/config",
+		},
+	}
+
+	resolved := resolveHost(&cfg, "")
+	if resolved.host != &cfg.Root.Hosts[1] ||
+		resolved.reason != "from 'host' in '.tx/config'" {
+		t.Errorf("Did not resolve the active host: %+v", resolved)
+	}
+
+	resolved = resolveHost(&cfg, "https://api.a.example.com")
+	if resolved.host != &cfg.Root.Hosts[0] ||
+		resolved.restHostname != "https://api.a.example.com" {
+		t.Errorf("Did not resolve host by API hostname: %+v", resolved)
+	}
+
+	cfg.Local.Host = "https://c.example.com"
+	resolved = resolveHost(&cfg, "")
+	if resolved.host != nil || resolved.restHostname != defaultRestHostname {
+		t.Errorf("Did not fall back to the default host: %+v", resolved)
+	}
+}
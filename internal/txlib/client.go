@@ -3,21 +3,175 @@ package txlib
 import (
 	"crypto/tls"
 	"crypto/x509"
+	"errors"
 	"fmt"
-	"io"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/transifex/cli/internal/txlib/config"
+	"github.com/transifex/cli/pkg/jsonapi"
 )
 
-func GetClient(cacert string) (http.Client, error) {
-	transport := http.DefaultTransport.(*http.Transport).Clone()
+/*
+HTTPSettings
+How the client connects to a host. Each setting can be given with a global
+flag (or environment variable) or per host in the root configuration:
 
-	if cacert != "" {
-		file, err := os.Open(cacert)
-		if err != nil {
-			return http.Client{}, err
+	[https://tx.example.com]
+	rest_hostname = https://rest.tx.example.com
+	request_timeout = 30s
+	proxy = http://proxy.example.com:3128
+	no_proxy = localhost,.internal.example.com
+	cacert = /etc/ssl/certs/gateway.pem
+	client_cert = /home/me/tx.crt
+	client_key = /home/me/tx.key
+	header.X-Gateway-Key = ...
+
+Flags take precedence (see 'GetHTTPSettings').
+*/
+type HTTPSettings struct {
+	CACert string
+	// For mutual TLS; 'ClientKey' can be left empty if the certificate file
+	// also contains the key
+	ClientCert string
+	ClientKey  string
+	// If empty, the HTTPS_PROXY/HTTP_PROXY environment variables are used
+	Proxy string
+	// Comma-separated hosts or domains (eg '.example.com') or IP ranges that
+	// are connected to directly
+	NoProxy string
+	// How long to wait for the server to start responding to a request,
+	// after it has been sent; 0 means 'defaultRequestTimeout'
+	RequestTimeout time.Duration
+	// Extra headers for API requests; they are not sent to the (external)
+	// URLs that file downloads redirect to
+	Headers map[string]string
+}
+
+const defaultRequestTimeout = 2 * time.Minute
+
+/*
+GetHTTPSettings
+Combine the HTTP settings given with flags (or environment variables) with
+the settings of the host in the root configuration; the host is picked the
+same way as in 'GetHostAndToken'. Headers are merged, with the flags winning
+for headers with the same name.
+*/
+func GetHTTPSettings(
+	cfg *config.Config, hostname string, flags HTTPSettings,
+) (HTTPSettings, error) {
+	settings := flags
+	settings.Headers = make(map[string]string)
+	host := resolveHost(cfg, hostname).host
+	if host != nil {
+		if settings.CACert == "" {
+			settings.CACert = host.CACert
+		}
+		if settings.ClientCert == "" {
+			settings.ClientCert = host.ClientCert
+			if settings.ClientKey == "" {
+				settings.ClientKey = host.ClientKey
+			}
+		}
+		if settings.Proxy == "" {
+			settings.Proxy = host.Proxy
+		}
+		if settings.NoProxy == "" {
+			settings.NoProxy = host.NoProxy
+		}
+		if settings.RequestTimeout == 0 && host.RequestTimeout != "" {
+			value, err := time.ParseDuration(host.RequestTimeout)
+			if err != nil || value <= 0 {
+				return HTTPSettings{}, fmt.Errorf(
+					"invalid 'request_timeout' setting for host '%s': %s",
+					host.Name, host.RequestTimeout,
+				)
+			}
+			settings.RequestTimeout = value
+		}
+		for name, value := range host.Headers {
+			settings.Headers[name] = value
+		}
+	}
+	for name, value := range flags.Headers {
+		settings.Headers[name] = value
+	}
+
+	if settings.RequestTimeout < 0 {
+		return HTTPSettings{}, fmt.Errorf(
+			"invalid request timeout: %s", settings.RequestTimeout,
+		)
+	}
+	if settings.ClientKey != "" && settings.ClientCert == "" {
+		return HTTPSettings{}, errors.New(
+			"a client key was given without a client certificate",
+		)
+	}
+	return settings, nil
+}
+
+/*
+ParseHeaders
+Parse headers given on the command line as 'Name: value'
+*/
+func ParseHeaders(lines []string) (map[string]string, error) {
+	headers := make(map[string]string)
+	for _, line := range lines {
+		parts := strings.SplitN(line, ":", 2)
+		name := strings.TrimSpace(parts[0])
+		if len(parts) != 2 || name == "" || strings.ContainsAny(name, " \t") {
+			return nil, fmt.Errorf(
+				"invalid header '%s', expected 'Name: value'", line,
+			)
 		}
-		data, err := io.ReadAll(file)
+		headers[name] = strings.TrimSpace(parts[1])
+	}
+	return headers, nil
+}
+
+/*
+APIHeaders
+The headers to send with every API request: the custom headers, an
+'Integration' header and a 'User-Agent' with the version of the client and
+the command being run
+*/
+func (settings HTTPSettings) APIHeaders(command string) map[string]string {
+	// Set when releases are built
+	version := Version
+	if version == "" {
+		version = "dev"
+	}
+	userAgent := fmt.Sprintf(
+		"txclient/%s (%s/%s)", version, runtime.GOOS, runtime.GOARCH,
+	)
+	if command != "" {
+		userAgent = fmt.Sprintf(
+			"txclient/%s (%s; %s/%s)",
+			version, command, runtime.GOOS, runtime.GOARCH,
+		)
+	}
+	headers := map[string]string{
+		"Integration": "txclient",
+		"User-Agent":  userAgent,
+	}
+	for name, value := range settings.Headers {
+		headers[name] = value
+	}
+	return headers
+}
+
+func GetClient(settings HTTPSettings) (http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	var tlsConfig *tls.Config
+	if settings.CACert != "" {
+		data, err := os.ReadFile(settings.CACert)
 		if err != nil {
 			return http.Client{}, err
 		}
@@ -25,12 +179,142 @@ func GetClient(cacert string) (http.Client, error) {
 		if !certPool.AppendCertsFromPEM(data) {
 			return http.Client{}, fmt.Errorf(
 				"could not load certificates from file '%s'",
-				cacert,
+				settings.CACert,
+			)
+		}
+		tlsConfig = &tls.Config{RootCAs: certPool}
+	}
+	if settings.ClientCert != "" {
+		keyPath := settings.ClientKey
+		if keyPath == "" {
+			keyPath = settings.ClientCert
+		}
+		certificate, err := tls.LoadX509KeyPair(settings.ClientCert, keyPath)
+		if err != nil {
+			return http.Client{}, fmt.Errorf(
+				"could not load client certificate '%s': %w",
+				settings.ClientCert, err,
 			)
 		}
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{}
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+	if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig
+	}
 
-		transport.TLSClientConfig = &tls.Config{RootCAs: certPool}
+	if settings.Proxy != "" {
+		proxyUrl, err := url.Parse(settings.Proxy)
+		if err != nil || proxyUrl.Scheme == "" || proxyUrl.Host == "" {
+			return http.Client{}, fmt.Errorf(
+				"invalid proxy URL '%s'", settings.Proxy,
+			)
+		}
+		transport.Proxy = http.ProxyURL(proxyUrl)
+	}
+	if settings.NoProxy != "" && transport.Proxy != nil {
+		proxy := transport.Proxy
+		transport.Proxy = func(request *http.Request) (*url.URL, error) {
+			if matchesNoProxy(settings.NoProxy, request.URL.Hostname()) {
+				return nil, nil
+			}
+			return proxy(request)
+		}
+	}
+
+	// Not 'http.Client.Timeout', which would also limit how long uploads and
+	// downloads of big files can take
+	transport.ResponseHeaderTimeout = settings.RequestTimeout
+	if transport.ResponseHeaderTimeout == 0 {
+		transport.ResponseHeaderTimeout = defaultRequestTimeout
 	}
 
 	return http.Client{Transport: transport}, nil
 }
+
+/*
+Whether 'hostname' is excluded from proxying by 'noProxy', a comma-separated
+list in the format of the NO_PROXY environment variable: '*', hostnames
+(which also match their subdomains), domains with a leading dot, IP addresses
+and IP ranges (eg '10.0.0.0/8')
+*/
+func matchesNoProxy(noProxy, hostname string) bool {
+	hostname = strings.ToLower(hostname)
+	ip := net.ParseIP(hostname)
+	for _, entry := range strings.Split(noProxy, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if entry == "*" {
+			return true
+		}
+		if _, ipRange, err := net.ParseCIDR(entry); err == nil {
+			if ip != nil && ipRange.Contains(ip) {
+				return true
+			}
+			continue
+		}
+		if host, _, err := net.SplitHostPort(entry); err == nil {
+			entry = host
+		}
+		domain := strings.TrimPrefix(entry, ".")
+		if hostname == domain || strings.HasSuffix(hostname, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+GetRetryPolicy
+Figure out how API requests should be retried. The 'retries' and 'statusCodes'
+arguments (from the command-line flags or the environment) take precedence over
+the 'retries' and 'retry_status_codes' settings of the host in the root
+configuration, which take precedence over the defaults. 'retries' should be -1
+and 'statusCodes' empty if they were not provided.
+*/
+func GetRetryPolicy(
+	cfg *config.Config, hostname string, retries int, statusCodes string,
+) (*jsonapi.RetryPolicy, error) {
+	policy := jsonapi.NewRetryPolicy()
+
+	host := resolveHost(cfg, hostname).host
+	if host != nil {
+		if retries == -1 && host.Retries != "" {
+			value, err := strconv.Atoi(host.Retries)
+			if err != nil || value < 0 {
+				return nil, fmt.Errorf(
+					"invalid 'retries' setting for host '%s': %s",
+					host.Name, host.Retries,
+				)
+			}
+			retries = value
+		}
+		if statusCodes == "" {
+			statusCodes = host.RetryStatusCodes
+		}
+	}
+
+	if retries < -1 {
+		return nil, fmt.Errorf("invalid number of retries: %d", retries)
+	}
+	if retries > -1 {
+		policy.MaxAttempts = retries + 1
+	}
+	if statusCodes != "" {
+		policy.StatusCodes = nil
+		for _, part := range strings.Split(statusCodes, ",") {
+			code, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil || code < 400 || code > 599 {
+				return nil, fmt.Errorf(
+					"invalid retry status code '%s'", strings.TrimSpace(part),
+				)
+			}
+			policy.StatusCodes = append(policy.StatusCodes, code)
+		}
+	}
+	return policy, nil
+}
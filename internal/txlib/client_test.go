@@ -0,0 +1,232 @@
+package txlib
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/transifex/cli/internal/txlib/config"
+	"github.com/transifex/cli/pkg/assert"
+	"github.com/transifex/cli/pkg/jsonapi"
+)
+
+func TestGetRetryPolicy(t *testing.T) {
+	cfg := &config.Config{
+		Root: &config.RootConfig{Hosts: []config.Host{{
+			Name:             "https://app.transifex.com",
+			RestHostname:     "https://rest.api.transifex.com",
+			Retries:          "5",
+			RetryStatusCodes: "502, 503",
+		}}},
+		Local: &config.LocalConfig{Host: "https://app.transifex.com"},
+	}
+
+	// Defaults
+	policy, err := GetRetryPolicy(&config.Config{Root: &config.RootConfig{}}, "", -1, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, policy.MaxAttempts, 4)
+	assert.Equal(t, len(policy.StatusCodes), len(jsonapi.DefaultRetryStatusCodes))
+
+	// From the active host
+	policy, err = GetRetryPolicy(cfg, "", -1, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, policy.MaxAttempts, 6)
+	assert.Equal(t, len(policy.StatusCodes), 2)
+	assert.Equal(t, policy.StatusCodes[1], 503)
+
+	// Flags win over the host's settings
+	policy, err = GetRetryPolicy(cfg, "https://rest.api.transifex.com", 0, "500")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, policy.MaxAttempts, 1)
+	assert.Equal(t, len(policy.StatusCodes), 1)
+	assert.Equal(t, policy.StatusCodes[0], 500)
+
+	_, err = GetRetryPolicy(cfg, "", -1, "50x")
+	assert.True(t, err != nil)
+}
+
+func TestGetHTTPSettings(t *testing.T) {
+	cfg := &config.Config{
+		Root: &config.RootConfig{Hosts: []config.Host{{
+			Name:           "https://tx.example.com",
+			RestHostname:   "https://rest.tx.example.com",
+			RequestTimeout: "30s",
+			Proxy:          "http://proxy.example.com:3128",
+			ClientCert:     "host.crt",
+			ClientKey:      "host.key",
+			Headers:        map[string]string{"X-A": "host", "X-B": "host"},
+		}}},
+		Local: &config.LocalConfig{Host: "https://tx.example.com"},
+	}
+
+	settings, err := GetHTTPSettings(cfg, "", HTTPSettings{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, settings.RequestTimeout, 30*time.Second)
+	assert.Equal(t, settings.Proxy, "http://proxy.example.com:3128")
+	assert.Equal(t, settings.ClientKey, "host.key")
+	assert.Equal(t, settings.Headers["X-A"], "host")
+
+	// Flags win over the host's settings; a certificate from the flags is
+	// not combined with the host's key
+	settings, err = GetHTTPSettings(cfg, "", HTTPSettings{
+		RequestTimeout: time.Second,
+		ClientCert:     "flag.pem",
+		Headers:        map[string]string{"X-A": "flag"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, settings.RequestTimeout, time.Second)
+	assert.Equal(t, settings.ClientCert, "flag.pem")
+	assert.Equal(t, settings.ClientKey, "")
+	assert.Equal(t, settings.Headers["X-A"], "flag")
+	assert.Equal(t, settings.Headers["X-B"], "host")
+
+	cfg.Root.Hosts[0].RequestTimeout = "soon"
+	_, err = GetHTTPSettings(cfg, "", HTTPSettings{})
+	assert.True(t, err != nil)
+
+	headers, err := ParseHeaders([]string{"X-Gateway-Key:  abc "})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, headers["X-Gateway-Key"], "abc")
+	_, err = ParseHeaders([]string{"no colon"})
+	assert.True(t, err != nil)
+}
+
+func TestMatchesNoProxy(t *testing.T) {
+	noProxy := "localhost, .internal.example.com,example.org:443,10.0.0.0/8"
+	for hostname, expected := range map[string]bool{
+		"localhost":                true,
+		"api.internal.example.com": true,
+		"internal.example.com":     true,
+		"example.org":              true,
+		"www.example.org":          true,
+		"10.1.2.3":                 true,
+		"rest.api.transifex.com":   false,
+		"notinternal.example.com":  false,
+		"11.0.0.1":                 false,
+		"example.org.attacker.com": false,
+	} {
+		if matchesNoProxy(noProxy, hostname) != expected {
+			t.Errorf("matchesNoProxy(%s) should be %v", hostname, expected)
+		}
+	}
+	assert.True(t, matchesNoProxy("*", "anything"))
+}
+
+func TestGetClientUsesProxy(t *testing.T) {
+	var proxiedUrl string
+	proxy := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			proxiedUrl = r.URL.String()
+		},
+	))
+	defer proxy.Close()
+
+	client, err := GetClient(HTTPSettings{Proxy: proxy.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := client.Get("http://tx.example.com/organizations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	assert.Equal(t, proxiedUrl, "http://tx.example.com/organizations")
+
+	_, err = GetClient(HTTPSettings{Proxy: "not a url"})
+	assert.True(t, err != nil)
+}
+
+func TestGetClientWithClientCertificate(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if len(r.TLS.PeerCertificates) == 0 {
+				w.WriteHeader(401)
+			}
+		},
+	))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	dir := t.TempDir()
+	caPath := filepath.Join(dir, "ca.pem")
+	writePem(t, caPath, "CERTIFICATE", server.Certificate().Raw)
+
+	// The server doesn't verify client certificates, so a self-signed one
+	// will do
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	certificate, err := x509.CreateCertificate(
+		rand.Reader, &template, &template, &key.PublicKey, key,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyBytes, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPath := filepath.Join(dir, "client.crt")
+	keyPath := filepath.Join(dir, "client.key")
+	writePem(t, certPath, "CERTIFICATE", certificate)
+	writePem(t, keyPath, "EC PRIVATE KEY", keyBytes)
+
+	client, err := GetClient(HTTPSettings{CACert: caPath})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.Get(server.URL)
+	assert.True(t, err != nil)
+
+	client, err = GetClient(HTTPSettings{
+		CACert: caPath, ClientCert: certPath, ClientKey: keyPath,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	assert.Equal(t, response.StatusCode, 200)
+}
+
+func writePem(t *testing.T, path, blockType string, data []byte) {
+	t.Helper()
+	content := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: data})
+	err := os.WriteFile(path, content, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
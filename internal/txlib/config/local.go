@@ -7,9 +7,9 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
-	"strconv"
 	"strings"
 
+	"github.com/transifex/cli/pkg/atomicfile"
 	"gopkg.in/ini.v1"
 )
 
@@ -34,6 +34,36 @@ type Resource struct {
 	ResourceName         string
 	ReplaceEditedStrings bool
 	KeepTranslations     bool
+	// Limits to how many source strings a push may delete; nil if not set
+	MaxDeletions       *int
+	MaxDeletionPercent *int
+	// Groups the resource belongs to, for selecting it with '--tag'
+	Tags []string
+	// The local configuration file the resource was loaded from, if it is not
+	// the one of 'Config.Local' (see 'LoadWorkspace'); not saved
+	ConfigPath string
+}
+
+// The options of the '[main]' section that the parser understands
+var MainKeys = []string{"host", "lang_map"}
+
+/*
+The options of resource sections that the parser understands, in the order
+'tx config list' prints them; 'trans.<lang>' options come on top of these
+*/
+var ResourceKeys = []string{
+	"file_filter",
+	"source_file",
+	"source_lang",
+	"type",
+	"minimum_perc",
+	"resource_name",
+	"replace_edited_strings",
+	"keep_translations",
+	"max_deletions",
+	"max_deletion_percent",
+	"tags",
+	"lang_map",
 }
 
 func loadLocalConfig() (*LocalConfig, error) {
@@ -83,22 +113,11 @@ func loadLocalConfigFromBytes(data []byte) (*LocalConfig, error) {
 	if result.Host == "" {
 		return nil, errors.New("local config's main section has no host")
 	}
-	languageMappings := mainSection.Key("lang_map").String()
-	if languageMappings != "" {
-		for _, mapping := range strings.Split(languageMappings, ",") {
-			err := fmt.Errorf("invalid language mapping '%s'", mapping)
-
-			split := strings.Split(mapping, ":")
-			if len(split) != 2 {
-				return nil, err
-			}
-			key := strings.Trim(split[0], " ")
-			value := strings.Trim(split[1], " ")
-			if key == "" || value == "" {
-				return nil, err
-			}
-			result.LanguageMappings[key] = value
-		}
+	result.LanguageMappings, err = ParseLanguageMappings(
+		mainSection.Key("lang_map").String(),
+	)
+	if err != nil {
+		return nil, err
 	}
 
 	for _, section := range cfg.Sections() {
@@ -168,21 +187,36 @@ func loadLocalConfigFromBytes(data []byte) (*LocalConfig, error) {
 			}
 		}
 
-		languageMappings := section.Key("lang_map").String()
-		if languageMappings != "" {
-			for _, mapping := range strings.Split(languageMappings, ",") {
-				err := fmt.Errorf("invalid language mapping %s", mapping)
-				split := strings.Split(mapping, ":")
-				if len(split) != 2 {
-					return nil, err
-				}
-				key := strings.Trim(split[0], " ")
-				value := strings.Trim(split[1], " ")
-				if key == "" || value == "" {
-					return nil, err
-				}
-				resource.LanguageMappings[key] = value
+		if section.HasKey("max_deletions") {
+			maxDeletions, err := section.Key("max_deletions").Int()
+			if err != nil || maxDeletions < 0 {
+				return nil, fmt.Errorf(
+					"'max_deletions' needs to be a non-negative number, got '%s'",
+					section.Key("max_deletions").String(),
+				)
+			}
+			resource.MaxDeletions = &maxDeletions
+		}
+
+		if section.HasKey("max_deletion_percent") {
+			maxDeletionPercent, err := section.Key("max_deletion_percent").Int()
+			if err != nil || maxDeletionPercent < 0 || maxDeletionPercent > 100 {
+				return nil, fmt.Errorf(
+					"'max_deletion_percent' needs to be a number between 0 "+
+						"and 100, got '%s'",
+					section.Key("max_deletion_percent").String(),
+				)
 			}
+			resource.MaxDeletionPercent = &maxDeletionPercent
+		}
+
+		resource.Tags = ParseTags(section.Key("tags").String())
+
+		resource.LanguageMappings, err = ParseLanguageMappings(
+			section.Key("lang_map").String(),
+		)
+		if err != nil {
+			return nil, err
 		}
 
 		for _, key := range section.Keys() {
@@ -201,11 +235,90 @@ func loadLocalConfigFromBytes(data []byte) (*LocalConfig, error) {
 	return &result, nil
 }
 
+/*
+ParseLanguageMappings
+Parse the value of a 'lang_map' option, eg 'pt_BR: pt-br, de: de-de'
+*/
+func ParseLanguageMappings(value string) (map[string]string, error) {
+	result := make(map[string]string)
+	if strings.TrimSpace(value) == "" {
+		return result, nil
+	}
+	for _, mapping := range strings.Split(value, ",") {
+		err := fmt.Errorf("invalid language mapping '%s'", mapping)
+
+		split := strings.Split(mapping, ":")
+		if len(split) != 2 {
+			return nil, err
+		}
+		key := strings.Trim(split[0], " ")
+		value := strings.Trim(split[1], " ")
+		if key == "" || value == "" {
+			return nil, err
+		}
+		result[key] = value
+	}
+	return result, nil
+}
+
+/*
+FormatLanguageMappings
+The opposite of 'ParseLanguageMappings', sorted by key
+*/
+func FormatLanguageMappings(mappings map[string]string) string {
+	var keys []string
+	for key := range mappings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var result []string
+	for _, key := range keys {
+		result = append(result, fmt.Sprintf("%s: %s", key, mappings[key]))
+	}
+	return strings.Join(result, ", ")
+}
+
+/*
+ParseTags
+Parse the value of a 'tags' option, eg 'mobile, ios'; empty and repeated tags
+are dropped
+*/
+func ParseTags(value string) []string {
+	var result []string
+	seen := make(map[string]bool)
+	for _, tag := range strings.Split(value, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		result = append(result, tag)
+	}
+	return result
+}
+
 func (localCfg LocalConfig) Save() error {
 	return localCfg.saveToPath(localCfg.Path)
 }
 
+/*
+If the file already exists, only the parts of it that changed since it was
+loaded are rewritten, see 'patchLocalConfig'
+*/
 func (localCfg LocalConfig) saveToPath(path string) error {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		oldCfg, err := loadLocalConfigFromBytes(data)
+		if err == nil {
+			return atomicfile.WriteFile(
+				path,
+				patchLocalConfig(data, oldCfg, &localCfg),
+				0644,
+				atomicfile.Options{PreserveMode: true},
+			)
+		}
+	}
+
 	file, err := os.OpenFile(path,
 		os.O_RDWR|os.O_CREATE|os.O_TRUNC,
 		0755)
@@ -219,104 +332,20 @@ func (localCfg LocalConfig) saveToPath(path string) error {
 func (localCfg LocalConfig) saveToWriter(file io.Writer) error {
 	cfg := ini.Empty(ini.LoadOptions{})
 
-	main, err := cfg.NewSection("main")
-	if err != nil {
-		return err
-	}
-	_, err = main.NewKey("host", localCfg.Host)
-	if err != nil {
-		return err
-	}
-	if len(localCfg.LanguageMappings) != 0 {
-		var mappings []string
-		for key, value := range localCfg.LanguageMappings {
-			mappings = append(mappings, fmt.Sprintf("%s: %s", key, value))
-		}
-		_, err = main.NewKey("lang_map", strings.Join(mappings, ", "))
-		if err != nil {
-			return err
-		}
-	}
-
-	for _, resource := range localCfg.Resources {
-		section, err := cfg.NewSection(resource.Name())
+	for _, iniSection := range localCfg.sections() {
+		section, err := cfg.NewSection(iniSection.name)
 		if err != nil {
 			return err
 		}
-
-		if resource.FileFilter != "" {
-			_, err := section.NewKey("file_filter", resource.FileFilter)
-			if err != nil {
-				return err
-			}
-		}
-
-		if resource.SourceFile != "" {
-			_, err := section.NewKey("source_file", resource.SourceFile)
-			if err != nil {
-				return err
-			}
-		}
-
-		if resource.SourceLanguage != "" {
-			_, err := section.NewKey("source_lang", resource.SourceLanguage)
-			if err != nil {
-				return err
-			}
-		}
-
-		if resource.Type != "" {
-			_, err := section.NewKey("type", resource.Type)
-			if err != nil {
-				return err
-			}
-		}
-
-		if resource.MinimumPercentage != -1 {
-			_, err := section.NewKey("minimum_perc",
-				strconv.Itoa(resource.MinimumPercentage))
-			if err != nil {
-				return err
-			}
-		}
-
-		if len(resource.LanguageMappings) != 0 {
-			var mappings []string
-			for key, value := range resource.LanguageMappings {
-				mappings = append(mappings, fmt.Sprintf("%s: %s", key, value))
-			}
-			_, err = section.NewKey("lang_map", strings.Join(mappings, ", "))
-			if err != nil {
-				return err
-			}
-		}
-
-		if len(resource.Overrides) != 0 {
-			for key, value := range resource.Overrides {
-				_, err = section.NewKey(fmt.Sprintf("trans.%s", key), value)
-				if err != nil {
-					return err
-				}
-			}
-		}
-
-		if resource.ResourceName != "" {
-			_, err := section.NewKey("resource_name", resource.ResourceName)
+		for _, option := range iniSection.options {
+			_, err := section.NewKey(option.key, option.value)
 			if err != nil {
 				return err
 			}
 		}
-
-		section.NewKey(
-			"replace_edited_strings", strconv.FormatBool(resource.ReplaceEditedStrings),
-		)
-
-		section.NewKey(
-			"keep_translations", strconv.FormatBool(resource.KeepTranslations),
-		)
 	}
 
-	_, err = cfg.WriteTo(file)
+	_, err := cfg.WriteTo(file)
 	return err
 }
 
@@ -402,6 +431,26 @@ func localConfigsEqual(left, right *LocalConfig) bool {
 		if leftResource.ReplaceEditedStrings != rightResource.ReplaceEditedStrings {
 			return false
 		}
+		if leftResource.KeepTranslations != rightResource.KeepTranslations {
+			return false
+		}
+		if leftResource.ResourceName != rightResource.ResourceName {
+			return false
+		}
+
+		if !intPointersEqual(leftResource.MaxDeletions, rightResource.MaxDeletions) {
+			return false
+		}
+		if !intPointersEqual(
+			leftResource.MaxDeletionPercent, rightResource.MaxDeletionPercent,
+		) {
+			return false
+		}
+
+		if strings.Join(leftResource.Tags, ",") !=
+			strings.Join(rightResource.Tags, ",") {
+			return false
+		}
 	}
 
 	return true
@@ -494,3 +543,23 @@ func (localCfg *Resource) GetAPv3Id() string {
 		localCfg.ResourceSlug,
 	)
 }
+
+func intPointersEqual(left, right *int) bool {
+	if left == nil || right == nil {
+		return left == right
+	}
+	return *left == *right
+}
+
+/*
+HasTag Return whether the resource has been tagged with 'tag' in the
+configuration file
+*/
+func (resource *Resource) HasTag(tag string) bool {
+	for _, resourceTag := range resource.Tags {
+		if resourceTag == tag {
+			return true
+		}
+	}
+	return false
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"bytes"
+	"strings"
 	"testing"
 )
 
@@ -184,3 +185,108 @@ func TestChangeSaveAndLoadLocalConfig(t *testing.T) {
 		)
 	}
 }
+
+func TestSaveAndLoadMaxDeletions(t *testing.T) {
+	maxDeletions := 0
+	maxDeletionPercent := 10
+	initial := LocalConfig{
+		Host: "My Host",
+		Resources: []Resource{
+			{
+				OrganizationSlug:   "My Organization Slug",
+				ProjectSlug:        "My Project Slug",
+				ResourceSlug:       "My Resource Slug",
+				MaxDeletions:       &maxDeletions,
+				MaxDeletionPercent: &maxDeletionPercent,
+			},
+			{
+				OrganizationSlug: "My Organization Slug",
+				ProjectSlug:      "My Project Slug",
+				ResourceSlug:     "My Other Resource Slug",
+			},
+		},
+	}
+	var buffer bytes.Buffer
+	err := initial.saveToWriter(&buffer)
+	if err != nil {
+		t.Error(err)
+	}
+
+	loaded, err := loadLocalConfigFromBytes(buffer.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	getResource := func(slug string) Resource {
+		for _, resource := range loaded.Resources {
+			if resource.ResourceSlug == slug {
+				return resource
+			}
+		}
+		t.Fatalf("Resource '%s' was not saved", slug)
+		return Resource{}
+	}
+	resource := getResource("My Resource Slug")
+	if resource.MaxDeletions == nil || *resource.MaxDeletions != 0 {
+		t.Errorf("Read wrong max_deletions %v, expected 0", resource.MaxDeletions)
+	}
+	if resource.MaxDeletionPercent == nil || *resource.MaxDeletionPercent != 10 {
+		t.Errorf(
+			"Read wrong max_deletion_percent %v, expected 10",
+			resource.MaxDeletionPercent,
+		)
+	}
+	resource = getResource("My Other Resource Slug")
+	if resource.MaxDeletions != nil || resource.MaxDeletionPercent != nil {
+		t.Error("Limits were set for a resource that doesn't have any")
+	}
+}
+
+func TestLoadInvalidMaxDeletionPercent(t *testing.T) {
+	_, err := loadLocalConfigFromBytes([]byte(`[main]
+host = https://app.transifex.com
+
+[o:org:p:proj:r:res]
+file_filter = locale/<lang>.json
+source_file = locale/en.json
+max_deletion_percent = 150
+`))
+	if err == nil {
+		t.Error("Expected an error for max_deletion_percent = 150")
+	}
+}
+
+func TestLoadTags(t *testing.T) {
+	loaded, err := loadLocalConfigFromBytes([]byte(`[main]
+host = https://app.transifex.com
+
+[o:org:p:proj:r:ui]
+file_filter = locale/<lang>.po
+tags = mobile, ios,, mobile ,legacy
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resource := loaded.Resources[0]
+	if strings.Join(resource.Tags, "|") != "mobile|ios|legacy" {
+		t.Errorf("Read wrong tags %v", resource.Tags)
+	}
+	if !resource.HasTag("ios") || resource.HasTag("web") {
+		t.Errorf("HasTag is wrong for tags %v", resource.Tags)
+	}
+
+	var buffer bytes.Buffer
+	err = loaded.saveToWriter(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buffer.String(), "= mobile, ios, legacy\n") {
+		t.Errorf("Tags were not saved:\n%s", buffer.String())
+	}
+	reloaded, err := loadLocalConfigFromBytes(buffer.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !localConfigsEqual(reloaded, loaded) {
+		t.Errorf("Got %+v, expected %+v", reloaded, loaded)
+	}
+}
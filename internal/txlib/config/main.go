@@ -93,6 +93,35 @@ func LoadFromPaths(rootPath, localPath string) (Config, error) {
 	return Config{Root: rootConfig, Local: localConfig}, nil
 }
 
+/*
+LoadFromPathsWithoutLocal
+Like 'LoadFromPaths', but for commands that can also be run outside of a
+project: if 'localPath' is empty and there is no local configuration in the
+current directory or its parents, 'Local' is set to nil instead of failing.
+*/
+func LoadFromPathsWithoutLocal(rootPath, localPath string) (Config, error) {
+	if localPath == "" {
+		path, err := findLocalPath("")
+		if err != nil {
+			return Config{}, err
+		}
+		if path == "" {
+			var rootConfig *RootConfig
+			if rootPath == "" {
+				rootConfig, err = loadRootConfig()
+			} else {
+				rootConfig, err = loadRootConfigFromPath(rootPath)
+			}
+			if err != nil {
+				return Config{}, err
+			}
+			return Config{Root: rootConfig}, nil
+		}
+		localPath = path
+	}
+	return LoadFromPaths(rootPath, localPath)
+}
+
 /*
 GetActiveHost
 Return the URL that will be used based on the configuration.
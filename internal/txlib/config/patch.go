@@ -0,0 +1,388 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type iniOption struct {
+	key   string
+	value string
+}
+
+type iniSection struct {
+	name    string
+	options []iniOption
+}
+
+/*
+Return the options of the local configuration as they are saved, section by
+section. Both writing a new file and patching an existing one go through this
+so that they agree on what each field looks like in the file.
+*/
+func (localCfg *LocalConfig) sections() []iniSection {
+	main := iniSection{name: "main"}
+	main.options = append(main.options, iniOption{"host", localCfg.Host})
+	if len(localCfg.LanguageMappings) != 0 {
+		main.options = append(main.options, iniOption{
+			"lang_map", FormatLanguageMappings(localCfg.LanguageMappings),
+		})
+	}
+	result := []iniSection{main}
+
+	for _, resource := range localCfg.Resources {
+		section := iniSection{name: resource.Name()}
+		add := func(key, value string) {
+			section.options = append(section.options, iniOption{key, value})
+		}
+
+		if resource.FileFilter != "" {
+			add("file_filter", resource.FileFilter)
+		}
+		if resource.SourceFile != "" {
+			add("source_file", resource.SourceFile)
+		}
+		if resource.SourceLanguage != "" {
+			add("source_lang", resource.SourceLanguage)
+		}
+		if resource.Type != "" {
+			add("type", resource.Type)
+		}
+		if resource.MinimumPercentage != -1 {
+			add("minimum_perc", strconv.Itoa(resource.MinimumPercentage))
+		}
+		if len(resource.LanguageMappings) != 0 {
+			add("lang_map", FormatLanguageMappings(resource.LanguageMappings))
+		}
+		var languageCodes []string
+		for languageCode := range resource.Overrides {
+			languageCodes = append(languageCodes, languageCode)
+		}
+		sort.Strings(languageCodes)
+		for _, languageCode := range languageCodes {
+			add(
+				fmt.Sprintf("trans.%s", languageCode),
+				resource.Overrides[languageCode],
+			)
+		}
+		if resource.ResourceName != "" {
+			add("resource_name", resource.ResourceName)
+		}
+		add(
+			"replace_edited_strings",
+			strconv.FormatBool(resource.ReplaceEditedStrings),
+		)
+		add("keep_translations", strconv.FormatBool(resource.KeepTranslations))
+		if resource.MaxDeletions != nil {
+			add("max_deletions", strconv.Itoa(*resource.MaxDeletions))
+		}
+		if resource.MaxDeletionPercent != nil {
+			add(
+				"max_deletion_percent",
+				strconv.Itoa(*resource.MaxDeletionPercent),
+			)
+		}
+		if len(resource.Tags) != 0 {
+			add("tags", strings.Join(resource.Tags, ", "))
+		}
+
+		result = append(result, section)
+	}
+	return result
+}
+
+/*
+configBlock
+A section of a configuration file, as lines [start, end). 'start' includes the
+comments right above the section header, which are considered to be about the
+section.
+*/
+type configBlock struct {
+	name   string
+	start  int
+	header int
+	end    int
+}
+
+func findConfigBlocks(lines []string) []configBlock {
+	var blocks []configBlock
+	for i, line := range lines {
+		name, isHeader := parseSectionHeader(line)
+		if !isHeader {
+			continue
+		}
+		start := i
+		for start > 0 && isCommentLine(lines[start-1]) {
+			start--
+		}
+		if len(blocks) > 0 {
+			if start < blocks[len(blocks)-1].header+1 {
+				start = blocks[len(blocks)-1].header + 1
+			}
+			blocks[len(blocks)-1].end = start
+		}
+		blocks = append(blocks, configBlock{name, start, i, len(lines)})
+	}
+	return blocks
+}
+
+func parseSectionHeader(line string) (string, bool) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "[") || !strings.HasSuffix(line, "]") {
+		return "", false
+	}
+	return strings.TrimSpace(line[1 : len(line)-1]), true
+}
+
+func isCommentLine(line string) bool {
+	line = strings.TrimSpace(line)
+	return strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";")
+}
+
+/*
+Return the key of an option line and the position of its delimiter, or -1 if
+the line is not an option
+*/
+func parseOptionLine(line string) (string, int) {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || isCommentLine(trimmed) {
+		return "", -1
+	}
+	if _, isHeader := parseSectionHeader(trimmed); isHeader {
+		return "", -1
+	}
+	position := strings.IndexAny(line, "=:")
+	if position == -1 {
+		return "", -1
+	}
+	return strings.TrimSpace(line[:position]), position
+}
+
+/*
+Quote values the way the 'ini' package does when it writes them, so that they
+are read back the same
+*/
+func quoteIniValue(value string) string {
+	if strings.Contains(value, "\n") || strings.Contains(value, "`") {
+		return `"""` + value + `"""`
+	}
+	if strings.ContainsAny(value, "#;") || strings.TrimSpace(value) != value {
+		return "`" + value + "`"
+	}
+	return value
+}
+
+/*
+Apply the changes between 'oldCfg', which was loaded from 'data', and
+'newCfg' to 'data'. Only the options whose values changed are touched; new
+options are added at the end of their section and new sections at the end of
+the file, so comments, ordering, formatting and options the client doesn't
+know about are left as they were.
+*/
+func patchLocalConfig(data []byte, oldCfg, newCfg *LocalConfig) []byte {
+	text := string(data)
+	newline := "\n"
+	if strings.Contains(text, "\r\n") {
+		newline = "\r\n"
+		text = strings.ReplaceAll(text, "\r\n", "\n")
+	}
+	trailingNewline := text == "" || strings.HasSuffix(text, "\n")
+	var lines []string
+	if text != "" {
+		lines = strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	}
+
+	oldSections := make(map[string]map[string]string)
+	for _, section := range oldCfg.sections() {
+		options := make(map[string]string)
+		for _, option := range section.options {
+			options[option.key] = option.value
+		}
+		oldSections[section.name] = options
+	}
+	newSections := newCfg.sections()
+	newNames := make(map[string]bool)
+	for _, section := range newSections {
+		newNames[section.name] = true
+	}
+
+	// Resources whose name changed (eg because 'tx migrate' added the
+	// organization) keep their place in the file
+	renames := make(map[string]string)
+	for _, newResource := range newCfg.Resources {
+		if _, exists := oldSections[newResource.Name()]; exists {
+			continue
+		}
+		for _, oldResource := range oldCfg.Resources {
+			if newNames[oldResource.Name()] {
+				continue
+			}
+			if oldResource.ProjectSlug == newResource.ProjectSlug &&
+				oldResource.ResourceSlug == newResource.ResourceSlug {
+				renames[oldResource.Name()] = newResource.Name()
+				break
+			}
+		}
+	}
+
+	// What to do with every line of the file, by line number: a nil entry
+	// removes the line, otherwise the line is replaced by the entry's lines
+	edits := make(map[int][]string)
+	blocks := findConfigBlocks(lines)
+	blocksByName := make(map[string][]configBlock)
+	for _, block := range blocks {
+		blocksByName[block.name] = append(blocksByName[block.name], block)
+	}
+
+	for _, block := range blocks {
+		newName := block.name
+		if renamed, exists := renames[block.name]; exists {
+			newName = renamed
+			edits[block.header] = []string{
+				strings.Replace(lines[block.header], block.name, newName, 1),
+			}
+		}
+		// Sections the client doesn't read (eg 'DEFAULT') are left alone
+		if _, known := oldSections[block.name]; known && !newNames[newName] {
+			for i := block.start; i < block.end; i++ {
+				edits[i] = nil
+			}
+		}
+	}
+
+	var appended []string
+	for _, section := range newSections {
+		oldName := section.name
+		for old, renamed := range renames {
+			if renamed == section.name {
+				oldName = old
+			}
+		}
+		oldOptions, exists := oldSections[oldName]
+		sectionBlocks := blocksByName[oldName]
+		if !exists || len(sectionBlocks) == 0 {
+			if len(lines)+len(appended) > 0 {
+				appended = append(appended, "")
+			}
+			appended = append(appended, fmt.Sprintf("[%s]", section.name))
+			for _, option := range section.options {
+				appended = append(appended, fmt.Sprintf(
+					"%s = %s", option.key, quoteIniValue(option.value),
+				))
+			}
+			continue
+		}
+
+		// The last line of every option, which is the one that counts
+		optionLines := make(map[string][]int)
+		for _, block := range sectionBlocks {
+			for i := block.header + 1; i < block.end; i++ {
+				key, _ := parseOptionLine(lines[i])
+				if key != "" {
+					optionLines[key] = append(optionLines[key], i)
+				}
+			}
+		}
+
+		newOptions := make(map[string]bool)
+		var inserted []string
+		lastBlock := sectionBlocks[len(sectionBlocks)-1]
+		for _, option := range section.options {
+			newOptions[option.key] = true
+			oldValue, existed := oldOptions[option.key]
+			if existed && oldValue == option.value {
+				continue
+			}
+			value := quoteIniValue(option.value)
+			if occurrences := optionLines[option.key]; len(occurrences) > 0 {
+				i := occurrences[len(occurrences)-1]
+				_, position := parseOptionLine(lines[i])
+				rest := lines[i][position+1:]
+				spacing := rest[:len(rest)-len(strings.TrimLeft(rest, " \t"))]
+				edits[i] = []string{lines[i][:position+1] + spacing + value}
+				continue
+			}
+			inserted = append(
+				inserted, formatOption(lines, lastBlock, option.key, value),
+			)
+		}
+		for key := range oldOptions {
+			if newOptions[key] {
+				continue
+			}
+			for _, i := range optionLines[key] {
+				edits[i] = nil
+			}
+		}
+
+		if len(inserted) > 0 {
+			// After the last option of the section, or its header
+			position := lastBlock.header
+			for i := lastBlock.header + 1; i < lastBlock.end; i++ {
+				if key, _ := parseOptionLine(lines[i]); key != "" {
+					position = i
+				}
+			}
+			line := lines[position]
+			if edit, exists := edits[position]; exists {
+				edits[position] = append(edit, inserted...)
+			} else {
+				edits[position] = append([]string{line}, inserted...)
+			}
+		}
+	}
+
+	var result []string
+	for i, line := range lines {
+		edit, exists := edits[i]
+		if !exists {
+			result = append(result, line)
+			continue
+		}
+		result = append(result, edit...)
+	}
+	// Don't leave blank lines behind at the end of the file when removing the
+	// last section
+	if edit, exists := edits[len(lines)-1]; exists && edit == nil {
+		for len(result) > 0 && strings.TrimSpace(result[len(result)-1]) == "" {
+			result = result[:len(result)-1]
+		}
+	}
+	result = append(result, appended...)
+	if len(result) == 0 {
+		return []byte{}
+	}
+	output := strings.Join(result, newline)
+	if trailingNewline || len(appended) > 0 {
+		output += newline
+	}
+	return []byte(output)
+}
+
+/*
+Format a new option for 'block', aligning it with the other options of the
+section if they are aligned
+*/
+func formatOption(lines []string, block configBlock, key, value string) string {
+	column := -1
+	count := 0
+	for i := block.header + 1; i < block.end; i++ {
+		optionKey, position := parseOptionLine(lines[i])
+		if optionKey == "" {
+			continue
+		}
+		count++
+		if column == -1 {
+			column = position
+		} else if column != position {
+			column = -1
+			break
+		}
+	}
+	if count < 2 || column <= len(key) {
+		return fmt.Sprintf("%s = %s", key, value)
+	}
+	return fmt.Sprintf("%-*s= %s", column, key, value)
+}
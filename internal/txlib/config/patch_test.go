@@ -0,0 +1,173 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+const patchTestConfig = `# Managed by the release scripts
+[main]
+host = https://app.transifex.com
+legacy_option = kept
+
+# The UI strings
+[o:org:p:proj:r:ui]
+file_filter  = locale/<lang>/ui.po
+source_file  = locale/en/ui.po
+type         = PO
+custom_key   = used by other tools
+
+; The docs
+[o:org:p:proj:r:docs]
+source_file = docs/en.md
+file_filter = docs/<lang>.md
+type = GITHUBMARKDOWN
+`
+
+func patchTest(
+	t *testing.T, data string, change func(*LocalConfig),
+) string {
+	t.Helper()
+	oldCfg, err := loadLocalConfigFromBytes([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	newCfg, err := loadLocalConfigFromBytes([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	change(newCfg)
+	result := string(patchLocalConfig([]byte(data), oldCfg, newCfg))
+
+	// Whatever the patch did, it has to be read back as the new configuration
+	reloaded, err := loadLocalConfigFromBytes([]byte(result))
+	if err != nil {
+		t.Fatalf("Could not load patched configuration: %s\n%s", err, result)
+	}
+	newCfg.sortResources()
+	if !localConfigsEqual(reloaded, newCfg) {
+		t.Errorf(
+			"Patched configuration is wrong; got %+v, expected %+v\n%s",
+			reloaded, newCfg, result,
+		)
+	}
+	return result
+}
+
+func findTestResource(localCfg *LocalConfig, slug string) *Resource {
+	for i := range localCfg.Resources {
+		if localCfg.Resources[i].ResourceSlug == slug {
+			return &localCfg.Resources[i]
+		}
+	}
+	return nil
+}
+
+func TestPatchWithoutChanges(t *testing.T) {
+	result := patchTest(t, patchTestConfig, func(localCfg *LocalConfig) {})
+	if result != patchTestConfig {
+		t.Errorf("File changed without any changes:\n%s", result)
+	}
+}
+
+func TestPatchChangedOptions(t *testing.T) {
+	result := patchTest(t, patchTestConfig, func(localCfg *LocalConfig) {
+		resource := findTestResource(localCfg, "ui")
+		resource.SourceFile = "locale/en_US/ui.po"
+		resource.MinimumPercentage = 50
+		resource.Overrides["el"] = "locale/greek.po"
+		findTestResource(localCfg, "docs").Type = ""
+		localCfg.LanguageMappings["pt_BR"] = "pt-br"
+	})
+	expected := `# Managed by the release scripts
+[main]
+host = https://app.transifex.com
+legacy_option = kept
+lang_map = pt_BR: pt-br
+
+# The UI strings
+[o:org:p:proj:r:ui]
+file_filter  = locale/<lang>/ui.po
+source_file  = locale/en_US/ui.po
+type         = PO
+custom_key   = used by other tools
+minimum_perc = 50
+trans.el     = locale/greek.po
+
+; The docs
+[o:org:p:proj:r:docs]
+source_file = docs/en.md
+file_filter = docs/<lang>.md
+`
+	if result != expected {
+		t.Errorf("Got:\n%s\nExpected:\n%s", result, expected)
+	}
+}
+
+func TestPatchAddAndRemoveResources(t *testing.T) {
+	result := patchTest(t, patchTestConfig, func(localCfg *LocalConfig) {
+		var resources []Resource
+		for _, resource := range localCfg.Resources {
+			if resource.ResourceSlug != "ui" {
+				resources = append(resources, resource)
+			}
+		}
+		resources = append(resources, Resource{
+			OrganizationSlug:  "org",
+			ProjectSlug:       "proj",
+			ResourceSlug:      "new",
+			FileFilter:        "new/<lang>.json",
+			SourceFile:        "new/en.json",
+			Type:              "KEYVALUEJSON",
+			MinimumPercentage: -1,
+		})
+		localCfg.Resources = resources
+	})
+	expected := `# Managed by the release scripts
+[main]
+host = https://app.transifex.com
+legacy_option = kept
+
+; The docs
+[o:org:p:proj:r:docs]
+source_file = docs/en.md
+file_filter = docs/<lang>.md
+type = GITHUBMARKDOWN
+
+[o:org:p:proj:r:new]
+file_filter = new/<lang>.json
+source_file = new/en.json
+type = KEYVALUEJSON
+replace_edited_strings = false
+keep_translations = false
+`
+	if result != expected {
+		t.Errorf("Got:\n%s\nExpected:\n%s", result, expected)
+	}
+
+	// Removing the last section doesn't leave blank lines behind
+	result = patchTest(t, patchTestConfig, func(localCfg *LocalConfig) {
+		localCfg.Resources = []Resource{*findTestResource(localCfg, "ui")}
+	})
+	if !strings.HasSuffix(result, "custom_key   = used by other tools\n") {
+		t.Errorf("Got:\n%s", result)
+	}
+}
+
+func TestPatchMigratedResource(t *testing.T) {
+	data := "[main]\r\nhost = https://www.transifex.com\r\n\r\n" +
+		"# Legacy section\r\n[proj.ui]\r\nfile_filter = locale/<lang>.po\r\n" +
+		"source_lang = en\r\nmode = developer\r\n"
+	result := patchTest(t, data, func(localCfg *LocalConfig) {
+		localCfg.Host = "https://app.transifex.com"
+		localCfg.Resources[0].OrganizationSlug = "org"
+		localCfg.Resources[0].SourceFile = "locale/en.po"
+	})
+	expected := "[main]\r\nhost = https://app.transifex.com\r\n\r\n" +
+		"# Legacy section\r\n[o:org:p:proj:r:ui]\r\n" +
+		"file_filter = locale/<lang>.po\r\nsource_lang = en\r\n" +
+		"mode = developer\r\nsource_file = locale/en.po\r\n"
+	if result != expected {
+		t.Errorf("Got:\n%q\nExpected:\n%q", result, expected)
+	}
+}
@@ -24,8 +24,30 @@ type Host struct {
 	Password     string
 	RestHostname string
 	Token        string
+
+	// Kept as strings so that they are saved back as they were found;
+	// validated when the API connection is set up
+	Retries          string
+	RetryStatusCodes string
+
+	// A command that stores the token instead of 'token' (see
+	// 'txlib.CredentialHelper')
+	CredentialHelper string
+
+	// How to connect to the host (see 'txlib.HTTPSettings'); also kept as
+	// strings
+	RequestTimeout string
+	Proxy          string
+	NoProxy        string
+	CACert         string
+	ClientCert     string
+	ClientKey      string
+	// Extra headers for API requests, saved as 'header.<name> = <value>'
+	Headers map[string]string
 }
 
+const headerKeyPrefix = "header."
+
 func loadRootConfig() (*RootConfig, error) {
 	rootPath, err := GetRootPath()
 	if err != nil {
@@ -70,6 +92,26 @@ func loadRootConfigFromBytes(data []byte) (*RootConfig, error) {
 			Password:     section.Key("password").String(),
 			RestHostname: section.Key("rest_hostname").String(),
 			Token:        section.Key("token").String(),
+
+			Retries:          section.Key("retries").String(),
+			RetryStatusCodes: section.Key("retry_status_codes").String(),
+			CredentialHelper: section.Key("credential_helper").String(),
+
+			RequestTimeout: section.Key("request_timeout").String(),
+			Proxy:          section.Key("proxy").String(),
+			NoProxy:        section.Key("no_proxy").String(),
+			CACert:         section.Key("cacert").String(),
+			ClientCert:     section.Key("client_cert").String(),
+			ClientKey:      section.Key("client_key").String(),
+		}
+		for _, key := range section.Keys() {
+			if strings.HasPrefix(key.Name(), headerKeyPrefix) {
+				if host.Headers == nil {
+					host.Headers = make(map[string]string)
+				}
+				name := strings.TrimPrefix(key.Name(), headerKeyPrefix)
+				host.Headers[name] = key.String()
+			}
 		}
 		result.Hosts = append(result.Hosts, host)
 	}
@@ -94,7 +136,7 @@ func (rootCfg *RootConfig) save() error {
 func (rootCfg *RootConfig) saveToPath() error {
 	file, err := os.OpenFile(rootCfg.Path,
 		os.O_RDWR|os.O_CREATE|os.O_TRUNC,
-		0755)
+		0600)
 	if err != nil {
 		return err
 	}
@@ -152,6 +194,54 @@ func (rootCfg *RootConfig) saveToWriter(file io.Writer) error {
 				return err
 			}
 		}
+
+		if host.Retries != "" {
+			_, err := section.NewKey("retries", host.Retries)
+			if err != nil {
+				return err
+			}
+		}
+
+		if host.RetryStatusCodes != "" {
+			_, err := section.NewKey("retry_status_codes", host.RetryStatusCodes)
+			if err != nil {
+				return err
+			}
+		}
+
+		if host.CredentialHelper != "" {
+			_, err := section.NewKey("credential_helper", host.CredentialHelper)
+			if err != nil {
+				return err
+			}
+		}
+
+		httpSettings := [][2]string{
+			{"request_timeout", host.RequestTimeout},
+			{"proxy", host.Proxy},
+			{"no_proxy", host.NoProxy},
+			{"cacert", host.CACert},
+			{"client_cert", host.ClientCert},
+			{"client_key", host.ClientKey},
+		}
+		var headerNames []string
+		for name := range host.Headers {
+			headerNames = append(headerNames, name)
+		}
+		sort.Strings(headerNames)
+		for _, name := range headerNames {
+			httpSettings = append(httpSettings, [2]string{
+				headerKeyPrefix + name, host.Headers[name],
+			})
+		}
+		for _, setting := range httpSettings {
+			if setting[1] != "" {
+				_, err := section.NewKey(setting[0], setting[1])
+				if err != nil {
+					return err
+				}
+			}
+		}
 	}
 
 	_, err := cfg.WriteTo(file)
@@ -191,6 +281,32 @@ func rootConfigsEqual(left, right *RootConfig) bool {
 		if leftHost.Token != rightHost.Token {
 			return false
 		}
+		if leftHost.Retries != rightHost.Retries {
+			return false
+		}
+		if leftHost.RetryStatusCodes != rightHost.RetryStatusCodes {
+			return false
+		}
+		if leftHost.CredentialHelper != rightHost.CredentialHelper {
+			return false
+		}
+		if leftHost.RequestTimeout != rightHost.RequestTimeout ||
+			leftHost.Proxy != rightHost.Proxy ||
+			leftHost.NoProxy != rightHost.NoProxy ||
+			leftHost.CACert != rightHost.CACert ||
+			leftHost.ClientCert != rightHost.ClientCert ||
+			leftHost.ClientKey != rightHost.ClientKey {
+			return false
+		}
+		if len(leftHost.Headers) != len(rightHost.Headers) {
+			return false
+		}
+		for name, value := range leftHost.Headers {
+			if rightValue, exists := rightHost.Headers[name]; !exists ||
+				rightValue != value {
+				return false
+			}
+		}
 	}
 	return true
 }
@@ -45,6 +45,21 @@ func TestSaveAndLoadRootConfig(t *testing.T) {
 				Password:     "My Password",
 				RestHostname: "My RestHostname",
 				Token:        "My Token",
+
+				Retries:          "5",
+				RetryStatusCodes: "502,503",
+				CredentialHelper: "pass-helper --store tx",
+
+				RequestTimeout: "30s",
+				Proxy:          "http://proxy.example.com:3128",
+				NoProxy:        "localhost,.internal",
+				CACert:         "/etc/ssl/gateway.pem",
+				ClientCert:     "/home/me/tx.crt",
+				ClientKey:      "/home/me/tx.key",
+				Headers: map[string]string{
+					"X-Gateway-Key": "secret",
+					"X-Team":        "l10n",
+				},
 			},
 		},
 	}
@@ -0,0 +1,239 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Directories that never contain configurations that should be used
+var defaultWorkspaceIgnore = []string{".git", "node_modules", "vendor"}
+
+// File in the root of a workspace with more directories to ignore, one
+// pattern per line
+const workspaceIgnoreFile = ".txignore"
+
+/*
+FindLocalPaths
+Return the paths of all '.tx/config' files under 'root', skipping the
+directories that match the default ignore list, the patterns of a '.txignore'
+file in 'root' and 'ignore'. Patterns are matched (with path.Match) against
+both the name of a directory and its path relative to 'root', with '/' as the
+separator.
+*/
+func FindLocalPaths(root string, ignore []string) ([]string, error) {
+	patterns := append([]string{}, defaultWorkspaceIgnore...)
+	filePatterns, err := readWorkspaceIgnoreFile(
+		filepath.Join(root, workspaceIgnoreFile),
+	)
+	if err != nil {
+		return nil, err
+	}
+	patterns = append(patterns, filePatterns...)
+	for _, pattern := range ignore {
+		pattern = strings.Trim(strings.TrimSpace(pattern), "/")
+		if pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+	}
+	for _, pattern := range patterns {
+		_, err := path.Match(pattern, "")
+		if err != nil {
+			return nil, fmt.Errorf("invalid ignore pattern '%s': %w", pattern, err)
+		}
+	}
+
+	var result []string
+	err = filepath.WalkDir(root, func(
+		current string, entry fs.DirEntry, err error,
+	) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() {
+			return nil
+		}
+		relative, err := filepath.Rel(root, current)
+		if err != nil {
+			return err
+		}
+		relative = filepath.ToSlash(relative)
+		if relative != "." {
+			for _, pattern := range patterns {
+				nameMatched, _ := path.Match(pattern, entry.Name())
+				pathMatched, _ := path.Match(pattern, relative)
+				if nameMatched || pathMatched {
+					return filepath.SkipDir
+				}
+			}
+		}
+		if entry.Name() == ".tx" {
+			configPath := filepath.Join(current, "config")
+			info, err := os.Stat(configPath)
+			if err == nil && !info.IsDir() {
+				result = append(result, configPath)
+			}
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func readWorkspaceIgnoreFile(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var result []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.Trim(strings.TrimSpace(scanner.Text()), "/")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		result = append(result, line)
+	}
+	return result, scanner.Err()
+}
+
+/*
+LoadWorkspace
+Load every '.tx/config' under 'root' (see 'FindLocalPaths') and combine their
+resources into one configuration, so that a single command can work on all of
+them. The paths of each resource are rewritten to be relative to the current
+directory instead of the root of its own configuration and the language
+mappings of each '[main]' section are moved to its resources. All
+configurations need to use the same host and a resource may only be configured
+once.
+
+The combined configuration has no path; it is not meant to be saved. Each
+resource remembers the configuration file it came from in 'ConfigPath' and the
+paths of all the configuration files that were found are returned along with
+the combined configuration.
+*/
+func LoadWorkspace(
+	rootPath, root string, ignore []string,
+) (Config, []string, error) {
+	var rootConfig *RootConfig
+	var err error
+	if rootPath == "" {
+		rootConfig, err = loadRootConfig()
+	} else {
+		rootConfig, err = loadRootConfigFromPath(rootPath)
+	}
+	if err != nil {
+		return Config{}, nil, err
+	}
+
+	paths, err := FindLocalPaths(root, ignore)
+	if err != nil {
+		return Config{}, nil, err
+	}
+	if len(paths) == 0 {
+		return Config{}, nil, fmt.Errorf(
+			"could not find any '.tx/config' file under '%s'", root,
+		)
+	}
+
+	curDir, err := os.Getwd()
+	if err != nil {
+		return Config{}, nil, err
+	}
+
+	combined := &LocalConfig{LanguageMappings: make(map[string]string)}
+	hostPath := ""
+	resourcePaths := make(map[string]string)
+	for _, localPath := range paths {
+		localCfg, err := loadLocalConfigFromPath(localPath)
+		if err != nil {
+			return Config{}, nil, fmt.Errorf("%s: %w", localPath, err)
+		}
+
+		if hostPath == "" {
+			combined.Host = localCfg.Host
+			hostPath = localPath
+		} else if localCfg.Host != combined.Host {
+			return Config{}, nil, fmt.Errorf(
+				"'%s' uses host '%s' but '%s' uses '%s'; all configurations "+
+					"of a workspace need to use the same host",
+				localPath, localCfg.Host, hostPath, combined.Host,
+			)
+		}
+
+		configRoot := filepath.Dir(filepath.Dir(localPath))
+		if !filepath.IsAbs(configRoot) {
+			configRoot = filepath.Join(curDir, configRoot)
+		}
+		prefix, err := filepath.Rel(curDir, configRoot)
+		if err != nil {
+			return Config{}, nil, err
+		}
+
+		for _, resource := range localCfg.Resources {
+			id := fmt.Sprintf("%s.%s", resource.ProjectSlug, resource.ResourceSlug)
+			if otherPath, exists := resourcePaths[id]; exists {
+				return Config{}, nil, fmt.Errorf(
+					"resource '%s' is configured in both '%s' and '%s'",
+					id, otherPath, localPath,
+				)
+			}
+			resourcePaths[id] = localPath
+			resource.ConfigPath = localPath
+
+			resource.FileFilter = joinWorkspacePath(prefix, resource.FileFilter)
+			resource.SourceFile = joinWorkspacePath(prefix, resource.SourceFile)
+			overrides := make(map[string]string)
+			for languageCode, override := range resource.Overrides {
+				overrides[languageCode] = joinWorkspacePath(prefix, override)
+			}
+			resource.Overrides = overrides
+			resource.LanguageMappings = mergeLanguageMappings(
+				localCfg.LanguageMappings, resource.LanguageMappings,
+			)
+			combined.Resources = append(combined.Resources, resource)
+		}
+	}
+
+	return Config{Root: rootConfig, Local: combined}, paths, nil
+}
+
+func joinWorkspacePath(prefix, value string) string {
+	if value == "" || prefix == "." || filepath.IsAbs(value) {
+		return value
+	}
+	return filepath.Join(prefix, value)
+}
+
+/*
+Combine the language mappings of a '[main]' section with the ones of one of
+its resources. The resource mappings win, both for the remote codes they map
+and for the local codes they map to.
+*/
+func mergeLanguageMappings(main, resource map[string]string) map[string]string {
+	result := make(map[string]string)
+	localCodes := make(map[string]bool)
+	for remoteCode, localCode := range resource {
+		result[remoteCode] = localCode
+		localCodes[localCode] = true
+	}
+	for remoteCode, localCode := range main {
+		if _, exists := result[remoteCode]; exists || localCodes[localCode] {
+			continue
+		}
+		result[remoteCode] = localCode
+	}
+	return result
+}
@@ -0,0 +1,163 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeWorkspaceFile(t *testing.T, path, content string) {
+	t.Helper()
+	err := os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(path, []byte(content), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func setUpWorkspace(t *testing.T) {
+	t.Helper()
+	curDir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(curDir) })
+	err = os.Chdir(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeWorkspaceFile(t, ".transifexrc", "")
+	writeWorkspaceFile(t, filepath.Join("web", ".tx", "config"), `[main]
+host = https://app.transifex.com
+lang_map = pt_BR: pt-br, de: de-de
+
+[o:org:p:web:r:ui]
+file_filter = locale/<lang>.po
+source_file = locale/en.po
+trans.el = greek.po
+lang_map = de: de
+`)
+	writeWorkspaceFile(
+		t, filepath.Join("packages", "mobile", ".tx", "config"), `[main]
+host = https://app.transifex.com
+
+[o:org:p:mobile:r:strings]
+file_filter = <lang>.json
+source_file = en.json
+`)
+	writeWorkspaceFile(
+		t, filepath.Join("node_modules", "lib", ".tx", "config"), "[main]\n",
+	)
+	writeWorkspaceFile(
+		t, filepath.Join("legacy", "old", ".tx", "config"), "[main]\n",
+	)
+	writeWorkspaceFile(t, ".txignore", "# Not maintained\nlegacy/old/\n")
+}
+
+func TestFindLocalPaths(t *testing.T) {
+	setUpWorkspace(t)
+
+	paths, err := FindLocalPaths(".", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		filepath.Join("packages", "mobile", ".tx", "config"),
+		filepath.Join("web", ".tx", "config"),
+	}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("Got %v, expected %v", paths, expected)
+	}
+
+	paths, err = FindLocalPaths(".", []string{"pack*"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(paths, expected[1:]) {
+		t.Errorf("Got %v, expected %v", paths, expected[1:])
+	}
+
+	_, err = FindLocalPaths(".", []string{"[a"})
+	if err == nil {
+		t.Error("Did not get error with invalid ignore pattern")
+	}
+}
+
+func TestLoadWorkspace(t *testing.T) {
+	setUpWorkspace(t)
+
+	cfg, paths, err := LoadWorkspace(".transifexrc", ".", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 2 || cfg.Local.Path != "" {
+		t.Fatalf("Got wrong workspace %v, %+v", paths, cfg.Local)
+	}
+	if cfg.Local.Host != "https://app.transifex.com" {
+		t.Errorf("Got wrong host '%s'", cfg.Local.Host)
+	}
+
+	mobile := cfg.FindResource("mobile.strings")
+	if mobile == nil {
+		t.Fatal("Resource of 'packages/mobile' was not loaded")
+	}
+	if mobile.SourceFile != filepath.Join("packages", "mobile", "en.json") ||
+		mobile.FileFilter != filepath.Join("packages", "mobile", "<lang>.json") {
+		t.Errorf("Paths were not rewritten: %+v", mobile)
+	}
+	if mobile.ConfigPath != filepath.Join("packages", "mobile", ".tx", "config") {
+		t.Errorf("Got wrong configuration path '%s'", mobile.ConfigPath)
+	}
+
+	web := cfg.FindResource("web.ui")
+	if web == nil {
+		t.Fatal("Resource of 'web' was not loaded")
+	}
+	if web.Overrides["el"] != filepath.Join("web", "greek.po") {
+		t.Errorf("Override was not rewritten: %+v", web.Overrides)
+	}
+	expectedMappings := map[string]string{"pt_BR": "pt-br", "de": "de"}
+	if !reflect.DeepEqual(web.LanguageMappings, expectedMappings) {
+		t.Errorf(
+			"Got language mappings %v, expected %v",
+			web.LanguageMappings, expectedMappings,
+		)
+	}
+}
+
+func TestLoadWorkspaceConflicts(t *testing.T) {
+	setUpWorkspace(t)
+	writeWorkspaceFile(t, filepath.Join("other", ".tx", "config"), `[main]
+host = https://app.transifex.com
+
+[o:org:p:web:r:ui]
+file_filter = <lang>.po
+`)
+	_, _, err := LoadWorkspace(".transifexrc", ".", nil)
+	if err == nil || !strings.Contains(err.Error(), "configured in both") {
+		t.Errorf("Did not get error about duplicate resource: %v", err)
+	}
+
+	writeWorkspaceFile(t, filepath.Join("other", ".tx", "config"), `[main]
+host = https://example.com
+`)
+	_, _, err = LoadWorkspace(".transifexrc", ".", nil)
+	if err == nil || !strings.Contains(err.Error(), "same host") {
+		t.Errorf("Did not get error about different hosts: %v", err)
+	}
+
+	err = os.Mkdir("empty", os.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = LoadWorkspace(".transifexrc", "empty", nil)
+	if err == nil || !strings.Contains(err.Error(), "could not find") {
+		t.Errorf("Did not get error without any configuration: %v", err)
+	}
+}
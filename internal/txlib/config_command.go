@@ -0,0 +1,455 @@
+package txlib
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/transifex/cli/internal/txlib/config"
+)
+
+/*
+ConfigCommandArguments
+Without a 'ResourceId', the commands operate on the '[main]' section of the
+local configuration. 'ResourceId' is in the '<project_slug>.<resource_slug>'
+format; 'set' and 'unset' also accept '*' wildcards to change many resources
+at once.
+*/
+type ConfigCommandArguments struct {
+	ResourceId string
+	Key        string
+	Value      string
+}
+
+/*
+Print the value of a key. Fails if the key is not set so that scripts can tell
+an empty value apart from a missing one.
+*/
+func ConfigGetCommand(cfg *config.Config, args ConfigCommandArguments) error {
+	if cfg.Local == nil {
+		return errors.New("local configuration file does not exist")
+	}
+	var value string
+	var exists bool
+	var err error
+	if args.ResourceId == "" {
+		value, exists, err = getMainConfigValue(cfg.Local, args.Key)
+	} else {
+		resource := cfg.FindResource(args.ResourceId)
+		if resource == nil {
+			return fmt.Errorf(
+				"could not find resource '%s' in local configuration",
+				args.ResourceId,
+			)
+		}
+		value, exists, err = getResourceConfigValue(resource, args.Key)
+	}
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("'%s' is not set", args.Key)
+	}
+	fmt.Println(value)
+	return nil
+}
+
+/*
+Validate and set the value of a key and save the local configuration
+*/
+func ConfigSetCommand(cfg *config.Config, args ConfigCommandArguments) error {
+	return changeConfig(cfg, args, func(resource *config.Resource) error {
+		if resource == nil {
+			return setMainConfigValue(cfg.Local, args.Key, args.Value)
+		}
+		return setResourceConfigValue(resource, args.Key, args.Value)
+	})
+}
+
+/*
+Remove a key (or reset it to its default value) and save the local
+configuration
+*/
+func ConfigUnsetCommand(cfg *config.Config, args ConfigCommandArguments) error {
+	return changeConfig(cfg, args, func(resource *config.Resource) error {
+		if resource == nil {
+			return unsetMainConfigValue(cfg.Local, args.Key)
+		}
+		return unsetResourceConfigValue(resource, args.Key)
+	})
+}
+
+/*
+Print all keys that are set, as '<key> = <value>' lines. Without a resource,
+the '[main]' section and every resource section are printed.
+*/
+func ConfigListCommand(cfg *config.Config, args ConfigCommandArguments) error {
+	if cfg.Local == nil {
+		return errors.New("local configuration file does not exist")
+	}
+	if args.ResourceId != "" {
+		resource := cfg.FindResource(args.ResourceId)
+		if resource == nil {
+			return fmt.Errorf(
+				"could not find resource '%s' in local configuration",
+				args.ResourceId,
+			)
+		}
+		printResourceConfig(resource)
+		return nil
+	}
+
+	fmt.Println("[main]")
+	for _, key := range config.MainKeys {
+		value, exists, _ := getMainConfigValue(cfg.Local, key)
+		if exists {
+			fmt.Printf("%s = %s\n", key, value)
+		}
+	}
+	for i := range cfg.Local.Resources {
+		resource := &cfg.Local.Resources[i]
+		fmt.Printf("\n[%s]\n", resource.Name())
+		printResourceConfig(resource)
+	}
+	return nil
+}
+
+func printResourceConfig(resource *config.Resource) {
+	for _, key := range config.ResourceKeys {
+		value, exists, _ := getResourceConfigValue(resource, key)
+		if exists {
+			fmt.Printf("%s = %s\n", key, value)
+		}
+	}
+	var languageCodes []string
+	for languageCode := range resource.Overrides {
+		languageCodes = append(languageCodes, languageCode)
+	}
+	sort.Strings(languageCodes)
+	for _, languageCode := range languageCodes {
+		fmt.Printf(
+			"trans.%s = %s\n", languageCode, resource.Overrides[languageCode],
+		)
+	}
+}
+
+/*
+Apply 'change' to the '[main]' section (a nil resource) or to every resource
+that matches 'args.ResourceId' and save the local configuration if all changes
+succeeded
+*/
+func changeConfig(
+	cfg *config.Config,
+	args ConfigCommandArguments,
+	change func(*config.Resource) error,
+) error {
+	if cfg.Local == nil {
+		return errors.New("local configuration file does not exist")
+	}
+	if args.ResourceId == "" {
+		err := change(nil)
+		if err != nil {
+			return err
+		}
+	} else {
+		cfgResources, err := figureOutResources([]string{args.ResourceId}, cfg)
+		if err != nil {
+			return err
+		}
+		for _, cfgResource := range cfgResources {
+			err := change(cfgResource)
+			if err != nil {
+				return fmt.Errorf("%s.%s: %w",
+					cfgResource.ProjectSlug, cfgResource.ResourceSlug, err)
+			}
+		}
+	}
+	return cfg.Local.Save()
+}
+
+/*
+Split keys like 'lang_map.pt_BR' and 'trans.pt_BR' into the option and the
+language code
+*/
+func splitLanguageKey(key string) (string, string) {
+	for _, prefix := range []string{"lang_map.", "trans."} {
+		if strings.HasPrefix(key, prefix) && len(key) > len(prefix) {
+			return strings.TrimSuffix(prefix, "."), key[len(prefix):]
+		}
+	}
+	return key, ""
+}
+
+func unknownConfigKeyError(key string, resource bool) error {
+	if resource {
+		return fmt.Errorf(
+			"unknown key '%s'; valid keys are %s, lang_map.<lang> and "+
+				"trans.<lang>",
+			key, strings.Join(config.ResourceKeys, ", "),
+		)
+	}
+	return fmt.Errorf(
+		"unknown key '%s' in the main section; valid keys are %s and "+
+			"lang_map.<lang> (use --resource for resource keys)",
+		key, strings.Join(config.MainKeys, ", "),
+	)
+}
+
+func getMainConfigValue(
+	localCfg *config.LocalConfig, key string,
+) (string, bool, error) {
+	option, languageCode := splitLanguageKey(key)
+	switch {
+	case key == "host":
+		return localCfg.Host, localCfg.Host != "", nil
+	case key == "lang_map":
+		return config.FormatLanguageMappings(localCfg.LanguageMappings),
+			len(localCfg.LanguageMappings) > 0,
+			nil
+	case option == "lang_map" && languageCode != "":
+		value, exists := localCfg.LanguageMappings[languageCode]
+		return value, exists, nil
+	}
+	return "", false, unknownConfigKeyError(key, false)
+}
+
+func setMainConfigValue(
+	localCfg *config.LocalConfig, key, value string,
+) error {
+	option, languageCode := splitLanguageKey(key)
+	switch {
+	case key == "host":
+		if value == "" {
+			return errors.New("host cannot be empty")
+		}
+		localCfg.Host = value
+	case key == "lang_map":
+		mappings, err := config.ParseLanguageMappings(value)
+		if err != nil {
+			return err
+		}
+		localCfg.LanguageMappings = mappings
+	case option == "lang_map" && languageCode != "":
+		if value == "" {
+			return errors.New("language mapping cannot be empty")
+		}
+		if localCfg.LanguageMappings == nil {
+			localCfg.LanguageMappings = make(map[string]string)
+		}
+		localCfg.LanguageMappings[languageCode] = value
+	default:
+		return unknownConfigKeyError(key, false)
+	}
+	return nil
+}
+
+func unsetMainConfigValue(localCfg *config.LocalConfig, key string) error {
+	option, languageCode := splitLanguageKey(key)
+	switch {
+	case key == "host":
+		return errors.New("host is required and cannot be unset")
+	case key == "lang_map":
+		localCfg.LanguageMappings = make(map[string]string)
+	case option == "lang_map" && languageCode != "":
+		delete(localCfg.LanguageMappings, languageCode)
+	default:
+		return unknownConfigKeyError(key, false)
+	}
+	return nil
+}
+
+func getResourceConfigValue(
+	resource *config.Resource, key string,
+) (string, bool, error) {
+	option, languageCode := splitLanguageKey(key)
+	switch {
+	case key == "file_filter":
+		return resource.FileFilter, resource.FileFilter != "", nil
+	case key == "source_file":
+		return resource.SourceFile, resource.SourceFile != "", nil
+	case key == "source_lang":
+		return resource.SourceLanguage, resource.SourceLanguage != "", nil
+	case key == "type":
+		return resource.Type, resource.Type != "", nil
+	case key == "minimum_perc":
+		return strconv.Itoa(resource.MinimumPercentage),
+			resource.MinimumPercentage != -1,
+			nil
+	case key == "resource_name":
+		return resource.ResourceName, resource.ResourceName != "", nil
+	case key == "replace_edited_strings":
+		return strconv.FormatBool(resource.ReplaceEditedStrings), true, nil
+	case key == "keep_translations":
+		return strconv.FormatBool(resource.KeepTranslations), true, nil
+	case key == "max_deletions":
+		if resource.MaxDeletions == nil {
+			return "", false, nil
+		}
+		return strconv.Itoa(*resource.MaxDeletions), true, nil
+	case key == "max_deletion_percent":
+		if resource.MaxDeletionPercent == nil {
+			return "", false, nil
+		}
+		return strconv.Itoa(*resource.MaxDeletionPercent), true, nil
+	case key == "tags":
+		return strings.Join(resource.Tags, ", "), len(resource.Tags) > 0, nil
+	case key == "lang_map":
+		return config.FormatLanguageMappings(resource.LanguageMappings),
+			len(resource.LanguageMappings) > 0,
+			nil
+	case option == "lang_map" && languageCode != "":
+		value, exists := resource.LanguageMappings[languageCode]
+		return value, exists, nil
+	case option == "trans" && languageCode != "":
+		value, exists := resource.Overrides[languageCode]
+		return value, exists, nil
+	}
+	return "", false, unknownConfigKeyError(key, true)
+}
+
+func setResourceConfigValue(
+	resource *config.Resource, key, value string,
+) error {
+	parseInt := func(min, max int) (int, error) {
+		number, err := strconv.Atoi(value)
+		if err != nil || number < min || number > max {
+			return 0, fmt.Errorf(
+				"%s needs to be a number between %d and %d, got '%s'",
+				key, min, max, value,
+			)
+		}
+		return number, nil
+	}
+	parseBool := func() (bool, error) {
+		result, err := strconv.ParseBool(value)
+		if err != nil {
+			return false, fmt.Errorf(
+				"%s needs to be 'true' or 'false', got '%s'", key, value,
+			)
+		}
+		return result, nil
+	}
+
+	option, languageCode := splitLanguageKey(key)
+	switch {
+	case key == "file_filter":
+		err := validateFileFilter(value)
+		if err != nil {
+			return err
+		}
+		resource.FileFilter = value
+	case key == "source_file":
+		err := validateSourceFile(value)
+		if err != nil {
+			return err
+		}
+		resource.SourceFile = value
+	case key == "source_lang":
+		resource.SourceLanguage = value
+	case key == "type":
+		if value == "" {
+			return errors.New("type cannot be empty")
+		}
+		resource.Type = value
+	case key == "minimum_perc":
+		number, err := parseInt(0, 100)
+		if err != nil {
+			return err
+		}
+		resource.MinimumPercentage = number
+	case key == "resource_name":
+		resource.ResourceName = value
+	case key == "replace_edited_strings":
+		result, err := parseBool()
+		if err != nil {
+			return err
+		}
+		resource.ReplaceEditedStrings = result
+	case key == "keep_translations":
+		result, err := parseBool()
+		if err != nil {
+			return err
+		}
+		resource.KeepTranslations = result
+	case key == "max_deletions":
+		number, err := strconv.Atoi(value)
+		if err != nil || number < 0 {
+			return fmt.Errorf(
+				"max_deletions needs to be a non-negative number, got '%s'",
+				value,
+			)
+		}
+		resource.MaxDeletions = &number
+	case key == "max_deletion_percent":
+		number, err := parseInt(0, 100)
+		if err != nil {
+			return err
+		}
+		resource.MaxDeletionPercent = &number
+	case key == "tags":
+		resource.Tags = config.ParseTags(value)
+	case key == "lang_map":
+		mappings, err := config.ParseLanguageMappings(value)
+		if err != nil {
+			return err
+		}
+		resource.LanguageMappings = mappings
+	case option == "lang_map" && languageCode != "":
+		if value == "" {
+			return errors.New("language mapping cannot be empty")
+		}
+		if resource.LanguageMappings == nil {
+			resource.LanguageMappings = make(map[string]string)
+		}
+		resource.LanguageMappings[languageCode] = value
+	case option == "trans" && languageCode != "":
+		err := validateFileFilter(value)
+		if err != nil {
+			return err
+		}
+		if resource.Overrides == nil {
+			resource.Overrides = make(map[string]string)
+		}
+		resource.Overrides[languageCode] = value
+	default:
+		return unknownConfigKeyError(key, true)
+	}
+	return nil
+}
+
+func unsetResourceConfigValue(resource *config.Resource, key string) error {
+	option, languageCode := splitLanguageKey(key)
+	switch {
+	case key == "file_filter", key == "source_file":
+		return fmt.Errorf("%s is required and cannot be unset", key)
+	case key == "source_lang":
+		resource.SourceLanguage = ""
+	case key == "type":
+		resource.Type = ""
+	case key == "minimum_perc":
+		resource.MinimumPercentage = -1
+	case key == "resource_name":
+		resource.ResourceName = ""
+	case key == "replace_edited_strings":
+		resource.ReplaceEditedStrings = false
+	case key == "keep_translations":
+		resource.KeepTranslations = false
+	case key == "max_deletions":
+		resource.MaxDeletions = nil
+	case key == "max_deletion_percent":
+		resource.MaxDeletionPercent = nil
+	case key == "tags":
+		resource.Tags = nil
+	case key == "lang_map":
+		resource.LanguageMappings = make(map[string]string)
+	case option == "lang_map" && languageCode != "":
+		delete(resource.LanguageMappings, languageCode)
+	case option == "trans" && languageCode != "":
+		delete(resource.Overrides, languageCode)
+	default:
+		return unknownConfigKeyError(key, true)
+	}
+	return nil
+}
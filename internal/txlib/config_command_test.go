@@ -0,0 +1,184 @@
+package txlib
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/transifex/cli/internal/txlib/config"
+	"github.com/transifex/cli/pkg/assert"
+)
+
+const configCommandTestConfig = `[main]
+host = https://app.transifex.com
+
+[o:orgslug:p:projslug:r:resslug]
+file_filter = aaa-<lang>.json
+source_file = aaa.json
+type = KEYVALUEJSON
+`
+
+func loadConfigCommandTestConfig(t *testing.T) config.Config {
+	t.Helper()
+	cfg, err := config.LoadFromPaths("", filepath.Join(".tx", "config"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+func TestConfigSetAndUnset(t *testing.T) {
+	afterTest := beforeTest(t, nil, nil)
+	defer afterTest()
+	err := os.MkdirAll(".tx", os.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(
+		filepath.Join(".tx", "config"), []byte(configCommandTestConfig), 0644,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, args := range []ConfigCommandArguments{
+		{ResourceId: "projslug.resslug", Key: "minimum_perc", Value: "50"},
+		{ResourceId: "projslug.*", Key: "keep_translations", Value: "true"},
+		{ResourceId: "projslug.resslug", Key: "lang_map.pt_BR", Value: "pt-br"},
+		{ResourceId: "projslug.resslug", Key: "trans.el", Value: "greek.json"},
+		{Key: "lang_map", Value: "de: de-de, fr: fr-fr"},
+	} {
+		cfg := loadConfigCommandTestConfig(t)
+		err = ConfigSetCommand(&cfg, args)
+		if err != nil {
+			t.Fatalf("Could not set '%s': %s", args.Key, err)
+		}
+	}
+
+	cfg := loadConfigCommandTestConfig(t)
+	resource := cfg.FindResource("projslug.resslug")
+	assert.Equal(t, resource.MinimumPercentage, 50)
+	assert.Equal(t, resource.KeepTranslations, true)
+	assert.Equal(t, resource.LanguageMappings["pt_BR"], "pt-br")
+	assert.Equal(t, resource.Overrides["el"], "greek.json")
+	assert.Equal(t, cfg.Local.LanguageMappings["de"], "de-de")
+	assert.Equal(t, cfg.Local.LanguageMappings["fr"], "fr-fr")
+
+	err = ConfigUnsetCommand(&cfg, ConfigCommandArguments{
+		ResourceId: "projslug.resslug", Key: "minimum_perc",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ConfigUnsetCommand(&cfg, ConfigCommandArguments{
+		Key: "lang_map.de",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg = loadConfigCommandTestConfig(t)
+	assert.Equal(t, cfg.FindResource("projslug.resslug").MinimumPercentage, -1)
+	_, exists := cfg.Local.LanguageMappings["de"]
+	assert.True(t, !exists)
+}
+
+func TestConfigSetValidates(t *testing.T) {
+	afterTest := beforeTest(t, nil, nil)
+	defer afterTest()
+	err := os.MkdirAll(".tx", os.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(
+		filepath.Join(".tx", "config"), []byte(configCommandTestConfig), 0644,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, args := range []ConfigCommandArguments{
+		{ResourceId: "projslug.resslug", Key: "source_file", Value: "missing.json"},
+		{ResourceId: "projslug.resslug", Key: "file_filter", Value: "locale/<lang>"},
+		{ResourceId: "projslug.resslug", Key: "minimum_perc", Value: "150"},
+		{ResourceId: "projslug.resslug", Key: "keep_translations", Value: "maybe"},
+		{ResourceId: "projslug.resslug", Key: "lang_map", Value: "pt_BR"},
+		{ResourceId: "projslug.resslug", Key: "unknown", Value: "value"},
+		{ResourceId: "projslug.other", Key: "type", Value: "PO"},
+		{Key: "file_filter", Value: "aaa-<lang>.json"},
+	} {
+		cfg := loadConfigCommandTestConfig(t)
+		err = ConfigSetCommand(&cfg, args)
+		if err == nil {
+			t.Errorf("Expected an error when setting '%s' to '%s'",
+				args.Key, args.Value)
+		}
+	}
+
+	cfg := loadConfigCommandTestConfig(t)
+	err = ConfigUnsetCommand(&cfg, ConfigCommandArguments{
+		ResourceId: "projslug.resslug", Key: "source_file",
+	})
+	assert.True(t, err != nil)
+
+	data, err := os.ReadFile(filepath.Join(".tx", "config"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, string(data), configCommandTestConfig)
+}
+
+func TestConfigGetAndList(t *testing.T) {
+	afterTest := beforeTest(t, nil, nil)
+	defer afterTest()
+	err := os.MkdirAll(".tx", os.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(
+		filepath.Join(".tx", "config"), []byte(configCommandTestConfig), 0644,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := loadConfigCommandTestConfig(t)
+
+	rescueStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	getErr := ConfigGetCommand(&cfg, ConfigCommandArguments{
+		ResourceId: "projslug.resslug", Key: "type",
+	})
+	missingErr := ConfigGetCommand(&cfg, ConfigCommandArguments{
+		ResourceId: "projslug.resslug", Key: "max_deletions",
+	})
+	listErr := ConfigListCommand(&cfg, ConfigCommandArguments{})
+
+	w.Close()
+	out, _ := io.ReadAll(r)
+	os.Stdout = rescueStdout
+
+	assert.True(t, getErr == nil)
+	assert.True(t, missingErr != nil)
+	assert.True(t, listErr == nil)
+	lines := strings.Split(string(out), "\n")
+	assert.Equal(t, lines[0], "KEYVALUEJSON")
+	assert.Equal(t, lines[1], "[main]")
+	assert.Equal(t, lines[2], "host = https://app.transifex.com")
+	assert.True(t, strings.Contains(
+		string(out),
+		"[o:orgslug:p:projslug:r:resslug]\nfile_filter = aaa-<lang>.json\n",
+	))
+}
+
+func TestConfigKnowsEveryResourceKey(t *testing.T) {
+	for _, key := range config.ResourceKeys {
+		_, _, err := getResourceConfigValue(&config.Resource{}, key)
+		if err != nil {
+			t.Errorf("'%s' is a resource key but 'tx config get' fails: %s",
+				key, err)
+		}
+	}
+}
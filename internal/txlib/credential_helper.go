@@ -0,0 +1,152 @@
+package txlib
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/transifex/cli/internal/txlib/config"
+)
+
+/*
+CredentialHelper
+An external command that stores API tokens on behalf of the client, set with
+'credential_helper' for a host in the root configuration. Like git's
+credential helpers, it is run as '<command> get', '<command> store' or
+'<command> erase' (through the shell, so it can have arguments) and it is
+given 'key=value' lines in its standard input, terminated by an empty line:
+
+	host=https://app.transifex.com
+	rest_hostname=https://rest.api.transifex.com
+	token=...
+
+('token' is only sent to 'store'). For 'get' it should print a 'token=...'
+line to its standard output; printing nothing means it doesn't have a token
+for the host. Its standard error is passed through, so that it can ask for a
+passphrase.
+*/
+type CredentialHelper struct {
+	Command string
+}
+
+func (helper *CredentialHelper) Get(host *config.Host) (string, error) {
+	output, err := helper.run("get", host, "")
+	if err != nil {
+		return "", err
+	}
+	return output["token"], nil
+}
+
+func (helper *CredentialHelper) Store(host *config.Host, token string) error {
+	_, err := helper.run("store", host, token)
+	return err
+}
+
+func (helper *CredentialHelper) Erase(host *config.Host) error {
+	_, err := helper.run("erase", host, "")
+	return err
+}
+
+func (helper *CredentialHelper) run(
+	action string, host *config.Host, token string,
+) (map[string]string, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", helper.Command+" "+action)
+	} else {
+		// '"$@"' appends the action to the command without quoting issues,
+		// the same way git does it
+		cmd = exec.Command(
+			"sh", "-c", helper.Command+` "$@"`, helper.Command, action,
+		)
+	}
+
+	var input bytes.Buffer
+	fmt.Fprintf(&input, "host=%s\n", host.Name)
+	if host.RestHostname != "" {
+		fmt.Fprintf(&input, "rest_hostname=%s\n", host.RestHostname)
+	}
+	if token != "" {
+		fmt.Fprintf(&input, "token=%s\n", token)
+	}
+	input.WriteString("\n")
+	cmd.Stdin = &input
+	cmd.Stderr = os.Stderr
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+
+	err := cmd.Run()
+	if err != nil {
+		return nil, fmt.Errorf(
+			"credential helper '%s %s' failed: %w", helper.Command, action, err,
+		)
+	}
+
+	result := make(map[string]string)
+	scanner := bufio.NewScanner(&stdout)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			break
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf(
+				"credential helper '%s %s' printed an invalid line, expected "+
+					"'key=value'",
+				helper.Command, action,
+			)
+		}
+		result[parts[0]] = parts[1]
+	}
+	return result, scanner.Err()
+}
+
+/*
+ReadTokenFile
+Read an API token from a file (eg one mounted from a secret store), ignoring
+surrounding whitespace
+*/
+func ReadTokenFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("token file '%s' is empty", path)
+	}
+	warnIfReadableByOthers(path)
+	return token, nil
+}
+
+var warnedAbout sync.Map
+
+/*
+Print a warning (once per file) if a file with API tokens can be read by
+users other than its owner. Permissions like these don't mean anything on
+Windows, so nothing is checked there.
+*/
+func warnIfReadableByOthers(path string) {
+	if runtime.GOOS == "windows" {
+		return
+	}
+	stat, err := os.Stat(path)
+	if err != nil || stat.Mode().Perm()&0077 == 0 {
+		return
+	}
+	if _, warned := warnedAbout.LoadOrStore(path, true); warned {
+		return
+	}
+	fmt.Fprintf(
+		os.Stderr,
+		"Warning: '%s' contains API tokens and can be read by other users; "+
+			"run 'chmod 600 %s' or use a credential helper\n",
+		path, path,
+	)
+}
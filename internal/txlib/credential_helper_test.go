@@ -0,0 +1,151 @@
+package txlib
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/transifex/cli/internal/txlib/config"
+)
+
+// A credential helper that keeps the token in a file next to it
+const testCredentialHelper = `#!/bin/sh
+store="$(dirname "$0")/store"
+case "$1" in
+get)
+	if [ -f "$store" ]; then
+		echo "token=$(cat "$store")"
+	fi
+	;;
+store)
+	while read -r line; do
+		case "$line" in
+		token=*) echo "${line#token=}" > "$store" ;;
+		esac
+	done
+	;;
+erase)
+	rm -f "$store"
+	;;
+esac
+`
+
+func getTestCredentialHelper(t *testing.T) *CredentialHelper {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the test credential helper is a shell script")
+	}
+	path := filepath.Join(t.TempDir(), "helper")
+	err := os.WriteFile(path, []byte(testCredentialHelper), 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &CredentialHelper{Command: path}
+}
+
+func TestCredentialHelper(t *testing.T) {
+	helper := getTestCredentialHelper(t)
+	host := &config.Host{
+		Name:         "https://app.transifex.com",
+		RestHostname: "https://rest.api.transifex.com",
+	}
+
+	token, err := helper.Get(host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "" {
+		t.Errorf("Got token '%s' from empty credential helper", token)
+	}
+
+	err = helper.Store(host, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err = helper.Get(host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "secret" {
+		t.Errorf("Got token '%s', expected 'secret'", token)
+	}
+
+	err = helper.Erase(host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err = helper.Get(host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "" {
+		t.Errorf("Token '%s' was not erased", token)
+	}
+
+	failing := CredentialHelper{Command: "exit 1"}
+	_, err = failing.Get(host)
+	if err == nil {
+		t.Error("Did not get error from failing credential helper")
+	}
+}
+
+func TestGetHostAndTokenFromCredentialHelper(t *testing.T) {
+	helper := getTestCredentialHelper(t)
+	cfg := config.Config{
+		Root: &config.RootConfig{
+			Path: filepath.Join(t.TempDir(), ".transifexrc"),
+			Hosts: []config.Host{{
+				Name:             "https://app.transifex.com",
+				RestHostname:     "https://rest.api.transifex.com",
+				CredentialHelper: helper.Command,
+			}},
+		},
+		Local: &config.LocalConfig{Host: "https://app.transifex.com"},
+	}
+	err := helper.Store(&cfg.Root.Hosts[0], "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hostname, token, err := GetHostAndToken(&cfg, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hostname != "https://rest.api.transifex.com" || token != "secret" {
+		t.Errorf("Got wrong hostname/token '%s', '%s'", hostname, token)
+	}
+
+	// Tokens from the command line take precedence
+	_, token, err = GetHostAndToken(&cfg, "", "other")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "other" {
+		t.Errorf("Got token '%s', expected 'other'", token)
+	}
+}
+
+func TestReadTokenFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	err := os.WriteFile(path, []byte("  secret\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := ReadTokenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "secret" {
+		t.Errorf("Got token '%s', expected 'secret'", token)
+	}
+
+	err = os.WriteFile(path, []byte("\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ReadTokenFile(path)
+	if err == nil {
+		t.Error("Did not get error for empty token file")
+	}
+}
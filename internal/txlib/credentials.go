@@ -68,51 +68,51 @@ The logic for retrieving the final hostname and token is:
    program will ask the user to provide a token. After the token is provided,
    it will be saved in the root configuration using the appropriate section key
    and hostname that were already retrieved.
+
+   If the host has a 'credential_helper' (see 'CredentialHelper'), the token is
+   retrieved from it instead. If the helper doesn't have one, the user is asked
+   for it and it is given to the helper to store; it is never saved in the
+   root configuration.
 */
 func GetHostAndToken(
 	cfg *config.Config, hostname, token string,
 ) (string, string, error) {
-	var restHostname string
-	var selectedHost *config.Host
-	if hostname != "" {
-		// User provided hostname, see if there is a host in the root
-		// configuration that matches
-		host := cfg.FindHost(hostname)
-		if host != nil {
-			// Found
-			selectedHost = host
-			restHostname = host.RestHostname
-		} else {
-			restHostname = hostname
-		}
-	} else {
-		// User did not provide hostname, Lets see if we can find one based on
-		// the active host
-		activeHost := cfg.GetActiveHost()
-		if activeHost != nil {
-			selectedHost = activeHost
-			hostname = activeHost.Name
-			restHostname = activeHost.RestHostname
-		} else {
-			// Fall back to defaults
-			hostname = "https://app.transifex.com"
-			restHostname = "https://rest.api.transifex.com"
-		}
-	}
+	resolved := resolveHost(cfg, hostname)
+	hostname = resolved.name
+	restHostname := resolved.restHostname
+	selectedHost := resolved.host
 
 	if token == "" {
 		// User did not provide token
-		if selectedHost != nil {
+		if selectedHost != nil && selectedHost.CredentialHelper != "" {
+			// The token is kept by an external command instead of in the
+			// root configuration
+			helper := CredentialHelper{Command: selectedHost.CredentialHelper}
+			var err error
+			token, err = helper.Get(selectedHost)
+			if err != nil {
+				return "", "", err
+			}
+			if token == "" {
+				token, err = promptForToken("with the credential helper")
+				if err != nil {
+					return "", "", err
+				}
+				err = helper.Store(selectedHost, token)
+				if err != nil {
+					return "", "", err
+				}
+			}
+		} else if selectedHost != nil {
 			// If a host was found in the root configuration during the search
 			// for the hostname
 			token = selectedHost.Token
+			if token != "" && cfg.Root != nil {
+				warnIfReadableByOthers(cfg.Root.Path)
+			}
 		} else {
-			fmt.Println("API token not found. Please provide it and it will " +
-				"be saved in '~/.transifexrc'.")
-			fmt.Println("If you don't have an API token, you can generate " +
-				"one in https://app.transifex.com/user/settings/api/")
-			fmt.Print("> ")
-			_, err := fmt.Scanln(&token)
+			var err error
+			token, err = promptForToken("in '~/.transifexrc'")
 			if err != nil {
 				return "", "", err
 			}
@@ -145,3 +145,22 @@ func GetHostAndToken(
 	}
 	return restHostname, token, nil
 }
+
+func promptForToken(where string) (string, error) {
+	err := checkInteractive(
+		"an API token",
+		"provide it with --token, TX_TOKEN or --token-file, or save it with "+
+			"'tx auth login' first",
+	)
+	if err != nil {
+		return "", err
+	}
+	fmt.Printf("API token not found. Please provide it and it will be saved "+
+		"%s.\n", where)
+	fmt.Println("If you don't have an API token, you can generate " +
+		"one in https://app.transifex.com/user/settings/api/")
+	fmt.Print("> ")
+	var token string
+	_, err = fmt.Scanln(&token)
+	return token, err
+}
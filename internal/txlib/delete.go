@@ -2,7 +2,6 @@ package txlib
 
 import (
 	"fmt"
-	"strings"
 
 	"github.com/fatih/color"
 	"github.com/gosimple/slug"
@@ -12,10 +11,13 @@ import (
 )
 
 type DeleteCommandArguments struct {
-	ResourceIds []string
-	Force       bool
-	Skip        bool
-	Branch      string
+	ResourceIds        []string
+	ExcludeResourceIds []string
+	Tags               []string
+	ExcludeTags        []string
+	Force              bool
+	Skip               bool
+	Branch             string
 }
 
 func DeleteCommand(
@@ -35,50 +37,42 @@ func DeleteCommand(
 	}
 	fmt.Printf("# Initiating Delete\n\n")
 
-	for _, resourceId := range arguments.ResourceIds {
-
-		// Split resource id to check if it is a bulk delete
-		parts := strings.Split(resourceId, ".")
-		if len(parts) != 2 {
+	selector, err := newResourceSelector(
+		arguments.ResourceIds, arguments.ExcludeResourceIds,
+		arguments.Tags, arguments.ExcludeTags,
+	)
+	if err != nil {
+		return err
+	}
+	// Deleting needs resources to be selected explicitly; an empty or
+	// exclusion-only selection does not mean 'everything'
+	if len(selector.include) > 0 || len(selector.tags) > 0 {
+		for _, tag := range selector.unknownTags(cfg) {
 			if !arguments.Skip {
 				return fmt.Errorf(
-					"wrong resource id for %s. Aborting",
-					resourceId,
-				)
-			} else {
-				fmt.Printf(
-					"Wrong resource id for %s. Aborting",
-					resourceId,
+					"no resource in local configuration has the tag '%s'. "+
+						"Aborting",
+					tag,
 				)
 			}
+			fmt.Printf(
+				"no resource in local configuration has the tag '%s'.\n", tag,
+			)
 		}
-
-		projectSlug := parts[0]
-		resourceSlug := parts[1]
-
-		// Find Resources for delete in config
-		if resourceSlug != "*" {
-			cfgResource := cfg.FindResource(resourceId)
-			if cfgResource == nil {
-				if !arguments.Skip {
-					return fmt.Errorf(
-						"could not find resource '%s' in local configuration. Aborting",
-						resourceId,
-					)
-				} else {
-					fmt.Printf(
-						"could not find resource '%s' in local configuration.",
-						resourceId,
-					)
-					break
-				}
+		var unmatched []string
+		cfgResources, unmatched = selector.apply(cfg)
+		for _, resourceId := range unmatched {
+			if !arguments.Skip {
+				return fmt.Errorf(
+					"could not find resource '%s' in local configuration. Aborting",
+					resourceId,
+				)
 			}
-			cfgResources = append(cfgResources, cfgResource)
-		} else {
-			batchedResources := cfg.FindResourcesByProject(projectSlug)
-			cfgResources = append(cfgResources, batchedResources...)
+			fmt.Printf(
+				"could not find resource '%s' in local configuration.\n",
+				resourceId,
+			)
 		}
-
 	}
 	// If there are no resources found stop
 	if len(cfgResources) == 0 {
@@ -108,7 +102,7 @@ func DeleteCommand(
 		}
 	}
 
-	err := cfg.Save()
+	err = cfg.Save()
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,92 @@
+package txlib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/transifex/cli/internal/txlib/config"
+	"github.com/transifex/cli/pkg/fakeapi"
+)
+
+type DevServerCommandArguments struct {
+	Address string
+	// If set, clients need to use this API token
+	Token          string
+	SourceLanguage string
+	Languages      []string
+	// Extra projects to create, by ID (eg 'o:org:p:project')
+	Projects      []string
+	ThrottleEvery int
+	Quiet         bool
+}
+
+/*
+Run a fake Transifex API server (see the 'fakeapi' package) until the context
+is cancelled. The projects of the resources in the local configuration are
+created up front, so that the client can push to it right away.
+*/
+func DevServerCommand(
+	ctx context.Context, cfg *config.Config, args DevServerCommandArguments,
+) error {
+	server := fakeapi.New()
+	server.Token = args.Token
+	server.ThrottleEvery = args.ThrottleEvery
+	if !args.Quiet {
+		server.Log = os.Stdout
+	}
+
+	projectIds := append([]string{}, args.Projects...)
+	if cfg.Local != nil {
+		for _, cfgResource := range cfg.Local.Resources {
+			projectIds = append(projectIds, fmt.Sprintf(
+				"o:%s:p:%s",
+				cfgResource.OrganizationSlug,
+				cfgResource.ProjectSlug,
+			))
+		}
+	}
+	for _, projectId := range projectIds {
+		parts := strings.Split(projectId, ":")
+		if len(parts) != 4 || parts[0] != "o" || parts[2] != "p" ||
+			parts[1] == "" || parts[3] == "" {
+			return fmt.Errorf(
+				"invalid project ID '%s', expected 'o:<organization>:p:<project>'",
+				projectId,
+			)
+		}
+		server.AddProject(
+			parts[1], parts[3], args.SourceLanguage, args.Languages...,
+		)
+	}
+
+	listener, err := net.Listen("tcp", args.Address)
+	if err != nil {
+		return err
+	}
+	httpServer := &http.Server{Handler: server}
+	go func() {
+		<-ctx.Done()
+		httpServer.Close()
+	}()
+
+	url := fmt.Sprintf("http://%s", listener.Addr())
+	fmt.Printf("Fake Transifex API listening on %s\n", url)
+	token := args.Token
+	if token == "" {
+		token = "anything"
+	}
+	fmt.Printf(
+		"Use it with: tx --hostname %s --token %s <command>\n\n", url, token,
+	)
+
+	err = httpServer.Serve(listener)
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
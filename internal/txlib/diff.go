@@ -0,0 +1,276 @@
+package txlib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/transifex/cli/internal/txlib/config"
+	"github.com/transifex/cli/pkg/jsonapi"
+	"github.com/transifex/cli/pkg/txapi"
+	"github.com/transifex/cli/pkg/worker_pool"
+)
+
+type DiffCommandArguments struct {
+	ResourceIds        []string
+	ExcludeResourceIds []string
+	Tags               []string
+	ExcludeTags        []string
+	Branch             string
+	Workers            int
+	Silent             bool
+	Summary            bool
+	Timeout            time.Duration
+}
+
+/*
+ResourceDiff
+The result of comparing the local source file of a resource with the source
+strings that are currently on Transifex
+*/
+type ResourceDiff struct {
+	Resource   string
+	SourceFile string
+	// The resource does not exist on Transifex yet, so all local strings will
+	// be created
+	IsNew bool
+	Diff  StringsDiff
+	Error string
+}
+
+func DiffCommand(
+	ctx context.Context,
+	cfg *config.Config,
+	api jsonapi.Connection,
+	args DiffCommandArguments,
+) error {
+	args.Branch = figureOutBranch(args.Branch)
+	cfgResources, err := selectResources(
+		args.ResourceIds, args.ExcludeResourceIds,
+		args.Tags, args.ExcludeTags, cfg,
+	)
+	if err != nil {
+		return err
+	}
+	applyBranchToResources(cfgResources, args.Branch)
+
+	if !args.Silent {
+		fmt.Print("# Fetching remote source strings\n\n")
+	}
+
+	var diffs []*ResourceDiff
+	var mutex sync.Mutex
+	pool := worker_pool.New(ctx, args.Workers, len(cfgResources), args.Silent)
+	for _, cfgResource := range cfgResources {
+		pool.Add(&ResourceDiffTask{
+			cfgResource,
+			&api,
+			args,
+			func(diff *ResourceDiff) {
+				mutex.Lock()
+				defer mutex.Unlock()
+				diffs = append(diffs, diff)
+			},
+		})
+	}
+	pool.Start()
+	<-pool.Wait()
+	err = checkPool(pool, nil)
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].Resource < diffs[j].Resource
+	})
+	failed := 0
+	for _, diff := range diffs {
+		printResourceDiff(diff, args.Summary)
+		if diff.Error != "" {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("could not compare %d resource(s)", failed)
+	}
+	return nil
+}
+
+type ResourceDiffTask struct {
+	cfgResource *config.Resource
+	api         *jsonapi.Connection
+	args        DiffCommandArguments
+	done        func(*ResourceDiff)
+}
+
+func (task *ResourceDiffTask) Run(
+	ctx context.Context, send func(string), abort func(),
+) {
+	cfgResource := task.cfgResource
+	args := task.args
+
+	sendMessage := func(body string, force bool) {
+		if args.Silent && !force {
+			return
+		}
+		send(fmt.Sprintf(
+			"%s.%s - %s",
+			cfgResource.ProjectSlug,
+			cfgResource.ResourceSlug,
+			body,
+		))
+	}
+
+	result := &ResourceDiff{
+		Resource: fmt.Sprintf(
+			"%s.%s", cfgResource.ProjectSlug, cfgResource.ResourceSlug,
+		),
+		SourceFile: cfgResource.SourceFile,
+	}
+	defer task.done(result)
+	fail := func(err string) {
+		sendMessage(err, true)
+		result.Error = err
+	}
+
+	parse, err := getSourceStringsParser(cfgResource.Type, cfgResource.SourceFile)
+	if err != nil {
+		fail(err.Error())
+		return
+	}
+	data, err := os.ReadFile(cfgResource.SourceFile)
+	if err != nil {
+		fail(err.Error())
+		return
+	}
+	local, err := parse(data)
+	if err != nil {
+		fail(fmt.Sprintf("Could not parse '%s': %s", cfgResource.SourceFile, err))
+		return
+	}
+
+	jobCtx, jobApi, cancel := withJobTimeout(ctx, task.api, args.Timeout)
+	defer cancel()
+
+	sendMessage("Getting info", false)
+	resource, err := txapi.GetResourceById(jobApi, cfgResource.GetAPv3Id())
+	if err != nil {
+		fail(fmt.Sprintf("Error while fetching resource: %s", err))
+		return
+	}
+	if resource == nil {
+		result.IsNew = true
+		result.Diff = diffStrings(map[string]string{}, local)
+		sendMessage("Done", false)
+		return
+	}
+
+	remote, err := getRemoteSourceStrings(
+		jobCtx,
+		jobApi,
+		resource,
+		parse,
+		args.Timeout,
+		func(msg string) { sendMessage(msg, false) },
+	)
+	if err != nil {
+		fail(err.Error())
+		return
+	}
+
+	result.Diff = diffStrings(remote, local)
+	sendMessage("Done", false)
+}
+
+/*
+Download the source strings of 'resource' that are currently on Transifex and
+parse them with 'parse'
+*/
+func getRemoteSourceStrings(
+	ctx context.Context,
+	api *jsonapi.Connection,
+	resource *jsonapi.Resource,
+	parse func([]byte) (map[string]string, error),
+	timeout time.Duration,
+	send func(string),
+) (map[string]string, error) {
+	var download *jsonapi.Resource
+	err := handleThrottling(
+		ctx,
+		func() error {
+			var err error
+			download, err = txapi.CreateResourceStringsAsyncDownload(
+				api, resource, "text", "default", false,
+			)
+			return err
+		},
+		"Creating download job",
+		send,
+	)
+	if err != nil {
+		return nil, errors.New(jobErrorMessage(err, timeout))
+	}
+
+	var data []byte
+	err = handleThrottling(
+		ctx,
+		func() error {
+			var err error
+			data, err = txapi.GetResourceStringsDownloadContent(ctx, download)
+			return err
+		},
+		"Downloading remote source file",
+		send,
+	)
+	if err != nil {
+		return nil, errors.New(jobErrorMessage(err, timeout))
+	}
+	remote, err := parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("Could not parse remote source file: %s", err)
+	}
+	return remote, nil
+}
+
+func printResourceDiff(diff *ResourceDiff, summary bool) {
+	red := color.New(color.FgRed).SprintFunc()
+	green := color.New(color.FgGreen).SprintFunc()
+	yellow := color.New(color.FgYellow).SprintFunc()
+	cyan := color.New(color.FgCyan).SprintFunc()
+
+	fmt.Printf("\n%s (%s)\n", cyan(diff.Resource), diff.SourceFile)
+	if diff.Error != "" {
+		fmt.Printf("  %s\n", red(diff.Error))
+		return
+	}
+	if diff.IsNew {
+		fmt.Println("  Resource does not exist on Transifex yet")
+	}
+	if !summary {
+		for _, key := range diff.Diff.Created {
+			fmt.Printf("  %s\n", green("+ "+key))
+		}
+		for _, key := range diff.Diff.Updated {
+			fmt.Printf("  %s\n", yellow("~ "+key))
+		}
+		for _, key := range diff.Diff.Deleted {
+			fmt.Printf("  %s\n", red("- "+key))
+		}
+	}
+	parts := []string{
+		fmt.Sprintf("%d to be created", len(diff.Diff.Created)),
+		fmt.Sprintf("%d to be updated", len(diff.Diff.Updated)),
+		fmt.Sprintf("%d to be deleted", len(diff.Diff.Deleted)),
+		fmt.Sprintf("%d unchanged", diff.Diff.Unchanged),
+	}
+	if len(diff.Diff.Deleted) > 0 {
+		parts[2] = red(parts[2])
+	}
+	fmt.Printf("  Strings: %s\n", strings.Join(parts, ", "))
+}
@@ -0,0 +1,198 @@
+package txlib
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/transifex/cli/pkg/assert"
+	"github.com/transifex/cli/pkg/jsonapi"
+)
+
+func TestDiffCommand(t *testing.T) {
+	afterTest := beforeTest(t, nil, nil)
+	defer afterTest()
+
+	err := os.WriteFile(
+		"aaa.json", []byte(`{"hello": "world", "new": "string"}`), 0644,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts := getNewTestServer(`{"hello": "there", "old": "string"}`)
+	defer ts.Close()
+
+	mockData := jsonapi.MockData{
+		resourceUrl:        getResourceEndpoint(),
+		sourceDownloadsUrl: getSourceDownloadsEndpoint(),
+		sourceDownloadUrl:  getDownloadEndpoint(ts.URL),
+	}
+	api := jsonapi.GetTestConnection(mockData)
+
+	cfg := getStandardConfig()
+	cfg.Local.Resources[0].Type = "KEYVALUEJSON"
+	err = DiffCommand(context.Background(), cfg, api, DiffCommandArguments{
+		Branch: "-1", Workers: 1,
+	})
+	if err != nil {
+		t.Error(err)
+	}
+	testSimpleGet(t, mockData, resourceUrl)
+	testSimpleGet(t, mockData, sourceDownloadUrl)
+}
+
+func TestDiffCommandUnsupportedType(t *testing.T) {
+	afterTest := beforeTest(t, nil, nil)
+	defer afterTest()
+
+	api := jsonapi.GetTestConnection(jsonapi.MockData{})
+
+	cfg := getStandardConfig()
+	cfg.Local.Resources[0].Type = "DOCX"
+	cfg.Local.Resources[0].SourceFile = "aaa.docx"
+	err := DiffCommand(context.Background(), cfg, api, DiffCommandArguments{
+		Branch: "-1", Workers: 1,
+	})
+	assert.True(t, err != nil)
+}
+
+func TestDiffStrings(t *testing.T) {
+	diff := diffStrings(
+		map[string]string{"a": "1", "b": "2", "c": "3"},
+		map[string]string{"a": "1", "b": "changed", "d": "4"},
+	)
+	assert.Equal(t, strings.Join(diff.Created, ","), "d")
+	assert.Equal(t, strings.Join(diff.Updated, ","), "b")
+	assert.Equal(t, strings.Join(diff.Deleted, ","), "c")
+	assert.Equal(t, diff.Unchanged, 1)
+}
+
+func TestParsePoStrings(t *testing.T) {
+	result, err := parsePoStrings([]byte(`# Header
+msgid ""
+msgstr ""
+"Language: en\n"
+
+#: main.c:1
+msgid "Hello"
+msgstr ""
+
+msgctxt "menu"
+msgid "Open"
+msgstr ""
+
+msgid "One file"
+msgid_plural ""
+"%d files"
+msgstr[0] ""
+msgstr[1] ""
+
+#~ msgid "Obsolete"
+#~ msgstr ""
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, len(result), 3)
+	assert.Equal(t, result["Hello"], "")
+	_, exists := result["menu | Open"]
+	assert.True(t, exists)
+	assert.Equal(t, result["One file"], "%d files")
+}
+
+func TestParseJsonAndYamlStrings(t *testing.T) {
+	result, err := parseJsonStrings(
+		[]byte(`{"a": {"b": "nested"}, "list": ["x", "y"], "c": "flat"}`),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, len(result), 4)
+	assert.Equal(t, result["a.b"], "nested")
+	assert.Equal(t, result["list[1]"], "y")
+
+	result, err = parseYamlStrings([]byte("en:\n  a:\n    b: nested\n  c: flat\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, len(result), 2)
+	assert.Equal(t, result["en.a.b"], "nested")
+	assert.Equal(t, result["en.c"], "flat")
+}
+
+func TestParseStructuredJsonStrings(t *testing.T) {
+	parser, err := getSourceStringsParser("STRUCTURED_JSON", "en.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := parser([]byte(`{
+		"menu": {
+			"open": {
+				"string": "Open",
+				"context": "menu",
+				"developer_comment": "The File menu"
+			}
+		},
+		"close": {"string": "Close"}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, len(result), 2)
+	assert.Equal(t, result["menu.open"], "Open")
+	assert.Equal(t, result["close"], "Close")
+}
+
+func TestParseAndroidStrings(t *testing.T) {
+	result, err := parseAndroidStrings([]byte(`<?xml version="1.0" encoding="utf-8"?>
+<resources>
+    <string name="app_name">My <b>App</b></string>
+    <string name="internal" translatable="false">skip</string>
+    <string-array name="planets">
+        <item>Mercury</item>
+        <item>Venus</item>
+    </string-array>
+    <plurals name="songs">
+        <item quantity="one">%d song</item>
+        <item quantity="other">%d songs</item>
+    </plurals>
+</resources>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, len(result), 5)
+	assert.Equal(t, result["app_name"], "My <b>App</b>")
+	assert.Equal(t, result["planets[1]"], "Venus")
+	assert.Equal(t, result["songs[other]"], "%d songs")
+}
+
+func TestParseAppleStrings(t *testing.T) {
+	text := `/* Comment */
+"hello" = "Hello \"world\"";
+// Another comment
+bare_key = "value";
+`
+	result, err := parseAppleStrings([]byte(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, len(result), 2)
+	assert.Equal(t, result["hello"], `Hello "world"`)
+	assert.Equal(t, result["bare_key"], "value")
+
+	// UTF-16 with a byte order mark
+	encoded := []byte{0xff, 0xfe}
+	for _, char := range `"a" = "b";` {
+		encoded = append(encoded, byte(char), 0)
+	}
+	result, err = parseAppleStrings(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, result["a"], "b")
+
+	_, err = parseAppleStrings([]byte(`"a" = "b"`))
+	assert.True(t, err != nil)
+}
@@ -0,0 +1,195 @@
+package txlib
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/transifex/cli/internal/txlib/config"
+	"github.com/transifex/cli/pkg/assert"
+	"github.com/transifex/cli/pkg/fakeapi"
+	"github.com/transifex/cli/pkg/jsonapi"
+)
+
+func TestPushAndPullWithFakeServer(t *testing.T) {
+	afterTest := beforeTest(t, nil, nil)
+	defer afterTest()
+
+	server := fakeapi.New()
+	server.AddProject("orgslug", "projslug", "en", "el")
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+	api := jsonapi.Connection{Host: ts.URL, Token: "token"}
+
+	err := os.WriteFile("aaa.json", []byte(`{"hello": "Hello"}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := getStandardConfig()
+	cfg.Local.Resources[0].Type = "KEYVALUEJSON"
+
+	err = PushCommand(context.Background(), cfg, api, PushCommandArguments{
+		Source: true, Branch: "-1", Workers: 1, Silent: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	source, _ := server.SourceFile(resourceId)
+	if string(source) != `{"hello": "Hello"}` {
+		t.Errorf("Pushed wrong source file '%s'", source)
+	}
+
+	err = server.SetTranslationFile(resourceId, "el", []byte(`{"hello": "Γεια"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = PullCommand(context.Background(), cfg, &api, &PullCommandArguments{
+		FileType:          "default",
+		Mode:              "default",
+		ContentEncoding:   "text",
+		Translations:      true,
+		All:               true,
+		Branch:            "-1",
+		MinimumPercentage: -1,
+		Workers:           1,
+		Silent:            true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertFileContent(t, "aaa-el.json", `{"hello": "Γεια"}`)
+}
+
+func TestRecursivePushAndPullUsePackageStates(t *testing.T) {
+	afterTest := beforeTest(t, nil, nil)
+	defer afterTest()
+
+	server := fakeapi.New()
+	server.AddProject("orgslug", "projslug", "en", "el")
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+	api := jsonapi.Connection{Host: ts.URL, Token: "token"}
+
+	packages := []string{"web", filepath.Join("packages", "mobile")}
+	for _, dir := range packages {
+		err := os.MkdirAll(filepath.Join(dir, ".tx"), os.ModePerm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(filepath.Join(dir, ".tx", "config"), []byte(fmt.Sprintf(
+			"[main]\nhost = https://app.transifex.com\n\n"+
+				"[o:orgslug:p:projslug:r:%s]\nfile_filter = <lang>.json\n"+
+				"source_file = en.json\nsource_lang = en\ntype = KEYVALUEJSON\n",
+			filepath.Base(dir),
+		)), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(
+			filepath.Join(dir, "en.json"), []byte(`{"hello": "Hello"}`), 0644,
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := os.WriteFile(".transifexrc", nil, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, _, err := config.LoadWorkspace(".transifexrc", ".", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pushArgs := PushCommandArguments{
+		Source: true, Branch: "-1", Workers: 1, Silent: true,
+		ReportPath: "report.json",
+	}
+	err = PushCommand(context.Background(), &cfg, api, pushArgs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each package has its own state, with paths relative to the package, as
+	// if the push had been run from the package itself
+	getPackageState := func(dir string) *State {
+		t.Helper()
+		state, err := LoadState(&config.Config{
+			Local: &config.LocalConfig{Path: filepath.Join(dir, ".tx", "config")},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return state
+	}
+	for _, dir := range packages {
+		id := "o:orgslug:p:projslug:r:" + filepath.Base(dir)
+		resourceState := getPackageState(dir).Resources[id]
+		if resourceState == nil || resourceState.Source == nil {
+			t.Fatalf("Source file of '%s' was not recorded in its state", dir)
+		}
+		assert.Equal(t, resourceState.Source.Path, "en.json")
+	}
+
+	// The package states are read, so nothing is pushed again
+	err = PushCommand(context.Background(), &cfg, api, pushArgs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile("report.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report Report
+	err = json.Unmarshal(data, &report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, len(report.Outcomes), 2)
+	for _, outcome := range report.Outcomes {
+		assert.Equal(t, outcome.Status, OutcomeSkipped)
+		assert.Equal(
+			t, outcome.Reason, "file has not changed since last push or pull",
+		)
+	}
+
+	for _, dir := range packages {
+		err = server.SetTranslationFile(
+			"o:orgslug:p:projslug:r:"+filepath.Base(dir), "el",
+			[]byte(`{"hello": "Γεια"}`),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = PullCommand(context.Background(), &cfg, &api, &PullCommandArguments{
+		FileType:          "default",
+		Mode:              "default",
+		ContentEncoding:   "text",
+		Translations:      true,
+		All:               true,
+		Branch:            "-1",
+		MinimumPercentage: -1,
+		Workers:           1,
+		Silent:            true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range packages {
+		id := "o:orgslug:p:projslug:r:" + filepath.Base(dir)
+		unchanged, _, err := getPackageState(dir).Check(
+			id, "el", filepath.Join(dir, "el.json"),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.True(t, unchanged, "Pulled file was not recorded in its state")
+	}
+	_, err = os.Stat(filepath.Join(".tx", "state.json"))
+	assert.True(t, os.IsNotExist(err))
+}
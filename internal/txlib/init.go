@@ -23,12 +23,19 @@ func InitCommand() error {
 	if _, err := os.Stat(configName); !os.IsNotExist(err) {
 		fmt.Println("It seems that this project is already initialized in " +
 			"this folder.")
+		err := checkInteractive(
+			"confirmation to overwrite it",
+			fmt.Sprintf("delete '%s' first to create a new one", configName),
+		)
+		if err != nil {
+			return err
+		}
 		prompt := promptui.Prompt{
 			Label:     "Do you want to delete it and reinit the project",
 			IsConfirm: true,
 		}
 
-		_, err := prompt.Run()
+		_, err = prompt.Run()
 
 		if err != nil {
 			fmt.Println("Init was cancelled!")
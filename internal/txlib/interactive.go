@@ -0,0 +1,53 @@
+package txlib
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+)
+
+/*
+Interactive
+Whether commands may stop and ask the user for input. When it is false,
+every code path that would prompt fails right away instead, with an error
+that says what to provide on the command line. The command-line app sets it
+with 'DetectInteractive'.
+*/
+var Interactive = true
+
+/*
+DetectInteractive
+Commands are not interactive if 'noInteractive' is set (the '--no-interactive'
+flag), if the 'CI' environment variable is set to a true value (which most CI
+services do) or if the standard input is not a terminal, since then nobody
+could answer a prompt.
+*/
+func DetectInteractive(noInteractive bool) bool {
+	if noInteractive {
+		return false
+	}
+	switch strings.ToLower(os.Getenv("CI")) {
+	case "true", "1", "yes":
+		return false
+	}
+	return isatty.IsTerminal(os.Stdin.Fd()) ||
+		isatty.IsCygwinTerminal(os.Stdin.Fd())
+}
+
+/*
+Return an error if the command would need to ask the user for 'what' but is
+not allowed to; 'instead' tells the user how to provide it without a prompt
+*/
+func checkInteractive(what, instead string) error {
+	if Interactive {
+		return nil
+	}
+	return fmt.Errorf(
+		"%s is needed, but the client is not running interactively "+
+			"(because of --no-interactive, CI=true or because the input is "+
+			"not a terminal); %s",
+		what, instead,
+	)
+}
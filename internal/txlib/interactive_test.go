@@ -0,0 +1,38 @@
+package txlib
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/transifex/cli/internal/txlib/config"
+)
+
+func TestDetectInteractive(t *testing.T) {
+	t.Setenv("CI", "true")
+	if DetectInteractive(false) {
+		t.Error("Interactive although CI=true")
+	}
+	t.Setenv("CI", "")
+	if DetectInteractive(true) {
+		t.Error("Interactive despite --no-interactive")
+	}
+}
+
+func TestNonInteractiveTokenPromptFails(t *testing.T) {
+	Interactive = false
+	defer func() { Interactive = true }()
+
+	cfg := config.Config{
+		Root: &config.RootConfig{
+			Path: filepath.Join(t.TempDir(), ".transifexrc"),
+		},
+	}
+	_, _, err := GetHostAndToken(&cfg, "", "")
+	if err == nil || !strings.Contains(err.Error(), "--token") {
+		t.Errorf("Did not fail with a helpful error: %v", err)
+	}
+	if len(cfg.Root.Hosts) != 0 {
+		t.Errorf("Saved a host without a token: %+v", cfg.Root.Hosts)
+	}
+}
@@ -0,0 +1,471 @@
+package txlib
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/transifex/cli/internal/txlib/config"
+)
+
+type LintCommandArguments struct {
+	// Also fail if there are only warnings
+	Strict bool
+}
+
+const (
+	LintError   = "error"
+	LintWarning = "warning"
+)
+
+/*
+LintIssue
+A problem with the local configuration. 'Line' points to the option the issue
+is about, or to the header of its section, and is 0 if it is not known.
+*/
+type LintIssue struct {
+	Severity string
+	Section  string
+	Line     int
+	Message  string
+}
+
+/*
+Check the local configuration against the working tree without contacting
+Transifex, print the issues that were found and return an error if any of
+them is an error (or a warning, if 'args.Strict' is set)
+*/
+func LintCommand(cfg *config.Config, args LintCommandArguments) error {
+	issues, err := lintConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	path := cfg.Local.Path
+	curDir, err := os.Getwd()
+	if err == nil {
+		relative, err := filepath.Rel(curDir, path)
+		if err == nil && !strings.HasPrefix(relative, "..") {
+			path = relative
+		}
+	}
+
+	red := color.New(color.FgRed).SprintFunc()
+	yellow := color.New(color.FgYellow).SprintFunc()
+	errorCount, warningCount := 0, 0
+	for _, issue := range issues {
+		location := path
+		if issue.Line > 0 {
+			location = fmt.Sprintf("%s:%d", location, issue.Line)
+		}
+		severity := yellow(issue.Severity)
+		if issue.Severity == LintError {
+			severity = red(issue.Severity)
+			errorCount++
+		} else {
+			warningCount++
+		}
+		fmt.Printf(
+			"%s: %s: [%s] %s\n", location, severity, issue.Section, issue.Message,
+		)
+	}
+	if len(issues) == 0 {
+		fmt.Printf("%s: no issues found\n", path)
+		return nil
+	}
+	fmt.Printf("\n%d error(s), %d warning(s)\n", errorCount, warningCount)
+	if errorCount > 0 || (args.Strict && warningCount > 0) {
+		return fmt.Errorf(
+			"found %d error(s) and %d warning(s) in the configuration",
+			errorCount, warningCount,
+		)
+	}
+	return nil
+}
+
+/*
+configFileIndex
+Where sections and options appear in the configuration file. The 'ini' package
+merges duplicate sections and options, so the file is scanned separately to
+find those and to report line numbers.
+*/
+type configFileIndex struct {
+	sections map[string][]int
+	options  map[string]map[string][]configOption
+}
+
+type configOption struct {
+	line  int
+	value string
+}
+
+func indexConfigFile(path string) (*configFileIndex, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	index := &configFileIndex{
+		sections: make(map[string][]int),
+		options:  make(map[string]map[string][]configOption),
+	}
+	section := ""
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") ||
+			strings.HasPrefix(text, ";") {
+			continue
+		}
+		if strings.HasPrefix(text, "[") && strings.HasSuffix(text, "]") {
+			section = strings.TrimSpace(text[1 : len(text)-1])
+			index.sections[section] = append(index.sections[section], line)
+			continue
+		}
+		position := strings.IndexAny(text, "=:")
+		if position == -1 {
+			continue
+		}
+		key := strings.TrimSpace(text[:position])
+		if index.options[section] == nil {
+			index.options[section] = make(map[string][]configOption)
+		}
+		index.options[section][key] = append(
+			index.options[section][key],
+			configOption{line, strings.TrimSpace(text[position+1:])},
+		)
+	}
+	return index, scanner.Err()
+}
+
+func (index *configFileIndex) sectionLine(section string) int {
+	lines := index.sections[section]
+	if len(lines) == 0 {
+		return 0
+	}
+	return lines[0]
+}
+
+/*
+The line of 'key' in 'section', or of the section itself if the key is not
+there
+*/
+func (index *configFileIndex) optionLine(section, key string) int {
+	options := index.options[section][key]
+	if len(options) == 0 {
+		return index.sectionLine(section)
+	}
+	return options[len(options)-1].line
+}
+
+func lintConfig(cfg *config.Config) ([]LintIssue, error) {
+	if cfg.Local == nil || cfg.Local.Path == "" {
+		return nil, fmt.Errorf("local configuration file does not exist")
+	}
+	index, err := indexConfigFile(cfg.Local.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []LintIssue
+	report := func(severity, section string, line int, message string) {
+		issues = append(issues, LintIssue{severity, section, line, message})
+	}
+
+	issues = append(issues, lintConfigFile(index)...)
+
+	for local, remote := range findLanguageMappingCollisions(
+		cfg.Local.LanguageMappings, nil,
+	) {
+		report(
+			LintError, "main", index.optionLine("main", "lang_map"),
+			fmt.Sprintf(
+				"lang_map maps both %s to '%s'",
+				strings.Join(remote, " and "), local,
+			),
+		)
+	}
+
+	// Which resources each local file belongs to, to find overlapping file
+	// filters
+	fileOwners := make(map[string][]string)
+	fileFilterOwners := make(map[string]string)
+	resourceSections := make(map[string]string)
+
+	for i := range cfg.Local.Resources {
+		resource := &cfg.Local.Resources[i]
+		section := resource.Name()
+		sectionLine := index.sectionLine(section)
+
+		slugs := fmt.Sprintf("%s.%s", resource.ProjectSlug, resource.ResourceSlug)
+		if other, exists := resourceSections[slugs]; exists {
+			report(
+				LintError, section, sectionLine,
+				fmt.Sprintf(
+					"resource '%s' is also configured in section [%s] "+
+						"(line %d)",
+					slugs, other, index.sectionLine(other),
+				),
+			)
+		} else {
+			resourceSections[slugs] = section
+		}
+		if resource.OrganizationSlug == "" {
+			report(
+				LintWarning, section, sectionLine,
+				"section uses the legacy format; run 'tx migrate'",
+			)
+		}
+
+		fileFilterLine := index.optionLine(section, "file_filter")
+		if resource.FileFilter == "" {
+			report(LintError, section, sectionLine, "file_filter is missing")
+		} else if !strings.Contains(resource.FileFilter, "<lang>") {
+			report(
+				LintError, section, fileFilterLine,
+				fmt.Sprintf(
+					"file_filter '%s' does not contain '<lang>'",
+					resource.FileFilter,
+				),
+			)
+		} else if err := validateFileFilter(resource.FileFilter); err != nil {
+			report(
+				LintError, section, fileFilterLine,
+				fmt.Sprintf("file_filter '%s': %s", resource.FileFilter, err),
+			)
+		} else {
+			normalised := filepath.Clean(normaliseFileFilter(resource.FileFilter))
+			if other, exists := fileFilterOwners[normalised]; exists {
+				report(
+					LintError, section, fileFilterLine,
+					fmt.Sprintf(
+						"file_filter '%s' is the same as the one of [%s]",
+						resource.FileFilter, other,
+					),
+				)
+			} else {
+				fileFilterOwners[normalised] = section
+			}
+			for _, path := range searchFileFilter(".", resource.FileFilter) {
+				path = filepath.Clean(path)
+				fileOwners[path] = appendUnique(fileOwners[path], section)
+			}
+		}
+
+		sourceFileLine := index.optionLine(section, "source_file")
+		if resource.SourceFile == "" {
+			report(LintError, section, sectionLine, "source_file is missing")
+		} else {
+			_, err := os.Stat(resource.SourceFile)
+			if os.IsNotExist(err) {
+				report(
+					LintError, section, sourceFileLine,
+					fmt.Sprintf(
+						"source_file '%s' does not exist", resource.SourceFile,
+					),
+				)
+			} else if err != nil {
+				report(LintError, section, sourceFileLine, err.Error())
+			} else {
+				path := filepath.Clean(resource.SourceFile)
+				fileOwners[path] = appendUnique(fileOwners[path], section)
+			}
+		}
+
+		if resource.Type == "" {
+			report(
+				LintWarning, section, sectionLine,
+				"type is missing; it is needed to create the resource on "+
+					"Transifex",
+			)
+		}
+
+		if values := index.options[section]["minimum_perc"]; len(values) > 0 {
+			value := values[len(values)-1]
+			number, err := strconv.Atoi(value.value)
+			if err != nil || number < 0 || number > 100 {
+				report(
+					LintWarning, section, value.line,
+					fmt.Sprintf(
+						"minimum_perc needs to be a number between 0 and "+
+							"100, got '%s'; it will be ignored",
+						value.value,
+					),
+				)
+			}
+		}
+
+		var languageCodes []string
+		for languageCode := range resource.Overrides {
+			languageCodes = append(languageCodes, languageCode)
+		}
+		sort.Strings(languageCodes)
+		for _, languageCode := range languageCodes {
+			path := resource.Overrides[languageCode]
+			key := "trans." + languageCode
+			_, err := os.Stat(path)
+			if os.IsNotExist(err) {
+				report(
+					LintWarning, section, index.optionLine(section, key),
+					fmt.Sprintf("%s points to '%s' which does not exist", key, path),
+				)
+			} else if err == nil {
+				path = filepath.Clean(path)
+				fileOwners[path] = appendUnique(fileOwners[path], section)
+			}
+		}
+
+		for local, remote := range findLanguageMappingCollisions(
+			cfg.Local.LanguageMappings, resource.LanguageMappings,
+		) {
+			report(
+				LintError, section, index.optionLine(section, "lang_map"),
+				fmt.Sprintf(
+					"lang_map (combined with the one in [main]) maps both %s "+
+						"to '%s'",
+					strings.Join(remote, " and "), local,
+				),
+			)
+		}
+	}
+
+	var paths []string
+	for path, owners := range fileOwners {
+		if len(owners) > 1 {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		owners := fileOwners[path]
+		for _, section := range owners[1:] {
+			report(
+				LintError, section, index.optionLine(section, "file_filter"),
+				fmt.Sprintf(
+					"'%s' is also matched by the configuration of [%s]",
+					path, owners[0],
+				),
+			)
+		}
+	}
+
+	sort.Slice(issues, func(i, j int) bool {
+		if issues[i].Line != issues[j].Line {
+			return issues[i].Line < issues[j].Line
+		}
+		return issues[i].Message < issues[j].Message
+	})
+	return issues, nil
+}
+
+/*
+Issues that can only be found by looking at the file itself: duplicate
+sections and options and unknown options
+*/
+func lintConfigFile(index *configFileIndex) []LintIssue {
+	var issues []LintIssue
+	for section, lines := range index.sections {
+		for _, line := range lines[1:] {
+			issues = append(issues, LintIssue{
+				LintError, section, line,
+				fmt.Sprintf(
+					"section is defined more than once (first on line %d); "+
+						"its options will be merged",
+					lines[0],
+				),
+			})
+		}
+	}
+	for section, options := range index.options {
+		for key, values := range options {
+			for _, value := range values[:len(values)-1] {
+				issues = append(issues, LintIssue{
+					LintWarning, section, value.line,
+					fmt.Sprintf(
+						"'%s' is set more than once; only the last value "+
+							"(line %d) is used",
+						key, values[len(values)-1].line,
+					),
+				})
+			}
+			known := stringSliceContains(config.ResourceKeys, key) ||
+				strings.HasPrefix(key, "trans.")
+			if section == "main" {
+				known = stringSliceContains(config.MainKeys, key)
+			}
+			if section == "" {
+				issues = append(issues, LintIssue{
+					LintWarning, section, values[0].line,
+					fmt.Sprintf("option '%s' is outside of any section", key),
+				})
+			} else if !known {
+				issues = append(issues, LintIssue{
+					LintWarning, section, values[0].line,
+					fmt.Sprintf("unknown option '%s'", key),
+				})
+			}
+		}
+	}
+	return issues
+}
+
+/*
+Language mappings go from remote to local codes; if two remote codes map to
+the same local code, the files of one of them will be ignored. Return the
+remote codes by the local code they collide on. If 'resourceMappings' is set,
+only collisions that involve them are returned, since the ones in
+'mainMappings' are reported separately.
+*/
+func findLanguageMappingCollisions(
+	mainMappings, resourceMappings map[string]string,
+) map[string][]string {
+	combined := make(map[string]string)
+	for remote, local := range mainMappings {
+		combined[remote] = local
+	}
+	for remote, local := range resourceMappings {
+		combined[remote] = local
+	}
+
+	byLocal := make(map[string][]string)
+	for remote, local := range combined {
+		byLocal[local] = append(byLocal[local], fmt.Sprintf("'%s'", remote))
+	}
+	result := make(map[string][]string)
+	for local, remote := range byLocal {
+		if len(remote) < 2 {
+			continue
+		}
+		if resourceMappings != nil {
+			involved := false
+			for resourceRemote, resourceLocal := range resourceMappings {
+				_, inMain := mainMappings[resourceRemote]
+				if resourceLocal == local &&
+					(!inMain || mainMappings[resourceRemote] != local) {
+					involved = true
+				}
+			}
+			if !involved {
+				continue
+			}
+		}
+		sort.Strings(remote)
+		result[local] = remote
+	}
+	return result
+}
+
+func appendUnique(slice []string, value string) []string {
+	for _, item := range slice {
+		if item == value {
+			return slice
+		}
+	}
+	return append(slice, value)
+}
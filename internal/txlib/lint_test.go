@@ -0,0 +1,152 @@
+package txlib
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/transifex/cli/internal/txlib/config"
+	"github.com/transifex/cli/pkg/assert"
+)
+
+func lintTestConfig(t *testing.T, content string) []LintIssue {
+	t.Helper()
+	err := os.MkdirAll(".tx", os.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(".tx", "config")
+	err = os.WriteFile(path, []byte(content), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.LoadFromPaths("", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issues, err := lintConfig(&cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return issues
+}
+
+func findLintIssue(issues []LintIssue, message string) *LintIssue {
+	for i := range issues {
+		if strings.Contains(issues[i].Message, message) {
+			return &issues[i]
+		}
+	}
+	return nil
+}
+
+func TestLintValidConfig(t *testing.T) {
+	afterTest := beforeTest(t, []string{"fr"}, nil)
+	defer afterTest()
+
+	issues := lintTestConfig(t, `[main]
+host = https://app.transifex.com
+lang_map = pt_BR: pt-br
+
+[o:orgslug:p:projslug:r:resslug]
+file_filter = aaa-<lang>.json
+source_file = aaa.json
+type = KEYVALUEJSON
+minimum_perc = 20
+trans.fr = aaa-fr.json
+`)
+	assert.Equal(t, len(issues), 0)
+}
+
+func TestLintConfigIssues(t *testing.T) {
+	afterTest := beforeTest(t, []string{"fr"}, nil)
+	defer afterTest()
+
+	issues := lintTestConfig(t, `[main]
+host = https://app.transifex.com
+lang_map = pt_BR: pt, pt_PT: pt
+
+[o:orgslug:p:projslug:r:resslug]
+file_filter = aaa-<lang>.json
+source_file = aaa.json
+type = KEYVALUEJSON
+trans.el = missing-el.json
+typo = value
+
+[o:orgslug:p:projslug:r:other]
+file_filter = aaa-<lang>.json
+source_file = missing.json
+type = KEYVALUEJSON
+
+[o:orgslug:p:projslug:r:nolang]
+file_filter = locale/aaa.json
+source_file = aaa.json
+
+[o:orgslug:p:projslug:r:resslug]
+minimum_perc = 50
+`)
+
+	expected := []struct {
+		message  string
+		severity string
+		line     int
+	}{
+		{"maps both 'pt_BR' and 'pt_PT' to 'pt'", LintError, 3},
+		{"trans.el points to 'missing-el.json'", LintWarning, 9},
+		{"unknown option 'typo'", LintWarning, 10},
+		{"the same as the one of [o:orgslug:p:projslug:r:other]", LintError, 6},
+		{"source_file 'missing.json' does not exist", LintError, 14},
+		{"does not contain '<lang>'", LintError, 18},
+		{"type is missing", LintWarning, 17},
+		{"section is defined more than once (first on line 5)", LintError, 21},
+	}
+	for _, item := range expected {
+		issue := findLintIssue(issues, item.message)
+		if issue == nil {
+			t.Errorf("Issue '%s' was not found in %+v", item.message, issues)
+			continue
+		}
+		assert.Equal(t, issue.Severity, item.severity)
+		assert.Equal(t, issue.Line, item.line)
+	}
+
+	// The source file of 'nolang' is also the source file of 'resslug'
+	issue := findLintIssue(issues, "'aaa.json' is also matched")
+	assert.True(t, issue != nil)
+}
+
+func TestLintCommandFailsOnErrors(t *testing.T) {
+	afterTest := beforeTest(t, nil, nil)
+	defer afterTest()
+
+	err := os.MkdirAll(".tx", os.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(".tx", "config")
+	err = os.WriteFile(path, []byte(`[main]
+host = https://app.transifex.com
+
+[o:orgslug:p:projslug:r:resslug]
+file_filter = aaa-<lang>.json
+source_file = aaa.json
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.LoadFromPaths("", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only a warning about the missing type
+	err = LintCommand(&cfg, LintCommandArguments{})
+	assert.True(t, err == nil)
+	err = LintCommand(&cfg, LintCommandArguments{Strict: true})
+	assert.True(t, err != nil)
+
+	cfg.Local.Resources[0].SourceFile = "missing.json"
+	err = LintCommand(&cfg, LintCommandArguments{})
+	assert.True(t, err != nil)
+}
@@ -1,11 +1,13 @@
 package txlib
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
 	"time"
 
+	"github.com/fatih/color"
 	"github.com/transifex/cli/internal/txlib/config"
 	"github.com/transifex/cli/pkg/jsonapi"
 	"github.com/transifex/cli/pkg/txapi"
@@ -13,37 +15,54 @@ import (
 )
 
 type MergeCommandArguments struct {
-	ResourceId         string
+	ResourceIds        []string
+	ExcludeResourceIds []string
 	Branch             string
 	ConflictResolution string
 	Force              bool
 	Skip               bool
 	Silent             bool
+	Timeout            time.Duration
 }
 
 func MergeCommand(
+	ctx context.Context,
 	cfg *config.Config,
 	api jsonapi.Connection,
 	args MergeCommandArguments,
 ) error {
 	args.Branch = figureOutBranch(args.Branch)
 
-	cfgResources, err := figureOutResources([]string{args.ResourceId}, cfg)
+	cfgResources, err := selectResources(
+		args.ResourceIds, args.ExcludeResourceIds, nil, nil, cfg,
+	)
 	if err != nil {
 		return err
 	}
+	if len(cfgResources) == 0 {
+		return errors.New("no resources were selected")
+	}
 
 	applyBranchToResources(cfgResources, args.Branch)
 
-	cfgResource := cfgResources[0]
-
-	err = mergeResource(&api, cfgResource, args)
-
-	return err
+	for _, cfgResource := range cfgResources {
+		err = mergeResource(ctx, &api, cfgResource, args)
+		if err == nil {
+			continue
+		}
+		if !args.Skip || errors.Is(err, ErrInterrupted) {
+			return err
+		}
+		color.Red("%s.%s - %s", cfgResource.ProjectSlug, cfgResource.ResourceSlug, err)
+	}
+	return nil
 }
 
 func mergeResource(
-	api *jsonapi.Connection, cfgResource *config.Resource, args MergeCommandArguments,
+	ctx context.Context,
+	api *jsonapi.Connection,
+	cfgResource *config.Resource,
+	args MergeCommandArguments,
 ) error {
 	isValidPolicy := isValidResolutionPolicy(args.ConflictResolution)
 	if !isValidPolicy {
@@ -79,15 +98,12 @@ func mergeResource(
 		return err
 	}
 
-	pool := worker_pool.New(1, 1, args.Silent)
+	pool := worker_pool.New(ctx, 1, 1, args.Silent)
 	pool.Add(&MergeResourcePollTask{merge, args})
 	pool.Start()
 	<-pool.Wait()
-	if pool.IsAborted {
-		return errors.New("Aborted")
-	}
 
-	return nil
+	return checkPool(pool, nil)
 }
 
 type MergeResourcePollTask struct {
@@ -95,7 +111,9 @@ type MergeResourcePollTask struct {
 	args  MergeCommandArguments
 }
 
-func (task *MergeResourcePollTask) Run(send func(string), abort func()) {
+func (task *MergeResourcePollTask) Run(
+	ctx context.Context, send func(string), abort func(),
+) {
 	merge := task.merge
 	args := task.args
 
@@ -109,9 +127,15 @@ func (task *MergeResourcePollTask) Run(send func(string), abort func()) {
 		))
 	}
 
+	jobCtx, jobApi, cancel := withJobTimeout(ctx, merge.API, args.Timeout)
+	defer cancel()
+	merge.API = jobApi
+
 	err := handleThrottling(
+		jobCtx,
 		func() error {
 			return txapi.PollResourceMerge(
+				jobCtx,
 				merge,
 				time.Second,
 			)
@@ -120,7 +144,7 @@ func (task *MergeResourcePollTask) Run(send func(string), abort func()) {
 		func(msg string) { sendMessage(msg, false) },
 	)
 	if err != nil {
-		sendMessage(err.Error(), true)
+		sendMessage(jobErrorMessage(err, args.Timeout), true)
 		if !args.Skip {
 			abort()
 		}
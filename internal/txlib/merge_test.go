@@ -1,6 +1,7 @@
 package txlib
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -15,18 +16,18 @@ const (
 func TestMergeSuccess(t *testing.T) {
 	mockData := getMockedDataForResourceMerge()
 	api := jsonapi.GetTestConnection(mockData)
-	commandArgs := MergeCommandArguments{"projslug.resslug", "the_branch", "USE_HEAD", false, false, false}
+	commandArgs := MergeCommandArguments{[]string{"projslug.resslug"}, nil, "the_branch", "USE_HEAD", false, false, false, 0}
 	resource := getStandardConfigMerge().FindResource("projslug.resslug")
-	err := mergeResource(&api, resource, commandArgs)
+	err := mergeResource(context.Background(), &api, resource, commandArgs)
 	assert.Nil(t, err)
 }
 
 func TestMergeInvalidPolicy(t *testing.T) {
 	mockData := getMockedDataForResourceMerge()
 	api := jsonapi.GetTestConnection(mockData)
-	commandArgs := MergeCommandArguments{"projslug.resslug", "the_branch", "INVALID_POLICY", false, false, false}
+	commandArgs := MergeCommandArguments{[]string{"projslug.resslug"}, nil, "the_branch", "INVALID_POLICY", false, false, false, 0}
 	resource := getStandardConfigMerge().FindResource("projslug.resslug")
-	err := mergeResource(&api, resource, commandArgs)
+	err := mergeResource(context.Background(), &api, resource, commandArgs)
 	assert.NotNil(t, err)
 
 }
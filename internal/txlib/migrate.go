@@ -65,6 +65,13 @@ func MigrateLegacyConfigFile(
 			activeHost.Token = activeHost.Password
 		} else {
 			// No token for some reason get a new one
+			err := checkInteractive(
+				"an API token",
+				"save it in '~/.transifexrc' with 'tx auth login' first",
+			)
+			if err != nil {
+				return "", err
+			}
 			if cfg.GetActiveHost() != nil {
 				fmt.Println("API token not found. Please provide it and it will " +
 					"be saved in '~/.transifexrc'.")
@@ -76,7 +83,7 @@ func MigrateLegacyConfigFile(
 				"one in https://app.transifex.com/user/settings/api/")
 			fmt.Print("> ")
 			var token string
-			_, err := fmt.Scanln(&token)
+			_, err = fmt.Scanln(&token)
 			if err != nil {
 				return "", err
 			}
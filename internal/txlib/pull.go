@@ -1,7 +1,7 @@
 package txlib
 
 import (
-	"errors"
+	"context"
 	"fmt"
 	"os"
 	"sort"
@@ -10,39 +10,80 @@ import (
 
 	"github.com/fatih/color"
 	"github.com/transifex/cli/internal/txlib/config"
+	"github.com/transifex/cli/pkg/atomicfile"
 	"github.com/transifex/cli/pkg/jsonapi"
 	"github.com/transifex/cli/pkg/txapi"
 	"github.com/transifex/cli/pkg/worker_pool"
 )
 
 type PullCommandArguments struct {
-	FileType          string
-	Mode              string
-	ContentEncoding   string
-	Force             bool
-	Skip              bool
-	Languages         []string
-	Source            bool
-	Translations      bool
-	All               bool
-	DisableOverwrite  bool
-	KeepNewFiles      bool
-	ResourceIds       []string
-	UseGitTimestamps  bool
-	Branch            string
-	MinimumPercentage int
-	Workers           int
-	Silent            bool
-	Pseudo            bool
+	FileType           string
+	Mode               string
+	ContentEncoding    string
+	Force              bool
+	Skip               bool
+	Languages          []string
+	Source             bool
+	Translations       bool
+	All                bool
+	DisableOverwrite   bool
+	KeepNewFiles       bool
+	ResourceIds        []string
+	ExcludeResourceIds []string
+	Tags               []string
+	ExcludeTags        []string
+	UseGitTimestamps   bool
+	Branch             string
+	MinimumPercentage  int
+	Workers            int
+	Silent             bool
+	Pseudo             bool
+	DryRun             bool
+	ReportPath         string
+	Timeout            time.Duration
+	Backup             bool
+	BackupDir          string
+	PreserveMode       bool
 }
 
 func PullCommand(
+	ctx context.Context,
 	cfg *config.Config,
 	api *jsonapi.Connection,
 	args *PullCommandArguments,
+) error {
+	report := NewReport("pull")
+	state, err := LoadState(cfg)
+	if err != nil {
+		return err
+	}
+	err = pullResources(ctx, cfg, api, args, report, state, nil)
+	err = saveState(state, err)
+	return finishReport(report, args.ReportPath, err)
+}
+
+func (args *PullCommandArguments) writeOptions() atomicfile.Options {
+	return atomicfile.Options{
+		PreserveMode: args.PreserveMode,
+		Backup:       args.Backup,
+		BackupDir:    args.BackupDir,
+	}
+}
+
+func pullResources(
+	ctx context.Context,
+	cfg *config.Config,
+	api *jsonapi.Connection,
+	args *PullCommandArguments,
+	report *Report,
+	state *State,
+	cache *resourceCache,
 ) error {
 	args.Branch = figureOutBranch(args.Branch)
-	cfgResources, err := figureOutResources(args.ResourceIds, cfg)
+	cfgResources, err := selectResources(
+		args.ResourceIds, args.ExcludeResourceIds,
+		args.Tags, args.ExcludeTags, cfg,
+	)
 	if err != nil {
 		return err
 	}
@@ -51,15 +92,21 @@ func PullCommand(
 		return cfgResources[i].GetAPv3Id() < cfgResources[j].GetAPv3Id()
 	})
 
+	if args.DryRun {
+		fmt.Print("# Dry run: no files will be downloaded\n\n")
+	}
 	if !args.Silent {
 		fmt.Print("# Getting info about resources\n\n")
 	}
 
 	filePullTaskChannel := make(chan *FilePullTask)
 	var filePullTasks []*FilePullTask
-	pool := worker_pool.New(args.Workers, len(cfgResources), args.Silent)
+	pool := worker_pool.New(ctx, args.Workers, len(cfgResources), args.Silent)
 	for _, cfgResource := range cfgResources {
-		pool.Add(&ResourcePullTask{cfgResource, api, args, filePullTaskChannel, cfg})
+		pool.Add(&ResourcePullTask{
+			cfgResource, api, args, filePullTaskChannel, cfg, report,
+			state.For(cfgResource), cache,
+		})
 	}
 	pool.Start()
 
@@ -73,8 +120,9 @@ func PullCommand(
 			exitfor = true
 		}
 	}
-	if pool.IsAborted {
-		return errors.New("Aborted")
+	err = checkPool(pool, report)
+	if err != nil {
+		return err
 	}
 	if args.Silent {
 		var names []string
@@ -102,17 +150,18 @@ func PullCommand(
 		if !args.Silent {
 			fmt.Print("\n# Pulling files\n\n")
 		}
-		pool = worker_pool.New(args.Workers, len(filePullTasks), args.Silent)
+		pool = worker_pool.New(ctx, args.Workers, len(filePullTasks), args.Silent)
 		for _, task := range filePullTasks {
 			pool.Add(task)
 		}
 		pool.Start()
 		<-pool.Wait()
 
-		if pool.IsAborted {
-			return errors.New("Aborted")
+		err = checkPool(pool, report)
+		if err != nil {
+			return err
 		}
-		if args.Silent {
+		if args.Silent && !args.DryRun {
 			var names []string
 			for _, filePullTask := range filePullTasks {
 				var languageCode string
@@ -140,9 +189,14 @@ type ResourcePullTask struct {
 	args                *PullCommandArguments
 	filePullTaskChannel chan *FilePullTask
 	cfg                 *config.Config
+	report              *Report
+	state               *State
+	cache               *resourceCache
 }
 
-func (task *ResourcePullTask) Run(send func(string), abort func()) {
+func (task *ResourcePullTask) Run(
+	ctx context.Context, send func(string), abort func(),
+) {
 	cfgResource := task.cfgResource
 	api := task.api
 	args := task.args
@@ -160,6 +214,16 @@ func (task *ResourcePullTask) Run(send func(string), abort func()) {
 			body,
 		))
 	}
+	resourceName := fmt.Sprintf(
+		"%s.%s", cfgResource.ProjectSlug, cfgResource.ResourceSlug,
+	)
+	fail := func(err string) {
+		sendMessage(err, true)
+		task.report.addFailure(ctx, task.outcome(), err)
+		if !args.Skip {
+			abort()
+		}
+	}
 	sendMessage("Getting info", false)
 
 	localToRemoteLanguageMappings := makeLocalToRemoteLanguageMappings(
@@ -171,49 +235,50 @@ func (task *ResourcePullTask) Run(send func(string), abort func()) {
 	)
 
 	var err error
-	resource, err := txapi.GetResourceById(api, cfgResource.GetAPv3Id())
-	if err != nil {
-		sendMessage(err.Error(), true)
-		if !args.Skip {
-			abort()
+	var resource, project *jsonapi.Resource
+	var stats map[string]*jsonapi.Resource
+	// 'tx sync' already got these while pushing
+	if cached := task.cache.get(cfgResource.GetAPv3Id()); cached != nil {
+		resource = cached.resource
+		project = cached.project
+		stats = cached.stats
+	} else {
+		resource, err = txapi.GetResourceById(api, cfgResource.GetAPv3Id())
+		if err != nil {
+			fail(err.Error())
+			return
+		}
+		if resource == nil {
+			sendMessage(
+				fmt.Sprintf("Resource %s does not exist", resourceName), true,
+			)
+			task.report.Add(TaskOutcome{
+				Resource: resourceName,
+				Status:   OutcomeSkipped,
+				Reason:   "Resource does not exist",
+			})
+			return
 		}
-		return
-	}
-	if resource == nil {
-		sendMessage(
-			fmt.Sprintf(
-				"Resource %s.%s does not exist",
-				cfgResource.ProjectSlug,
-				cfgResource.ResourceSlug,
-			),
-			true,
-		)
-		return
-	}
 
-	projectRelationship, err := resource.Fetch("project")
-	if err != nil {
-		sendMessage(err.Error(), true)
-		if !args.Skip {
-			abort()
+		projectRelationship, err := resource.Fetch("project")
+		if err != nil {
+			fail(err.Error())
+			return
 		}
-		return
+		project = projectRelationship.DataSingular
 	}
-	project := projectRelationship.DataSingular
 	sourceLanguage := project.Relationships["source_language"].DataSingular
 
-	var stats map[string]*jsonapi.Resource
-	if args.Source && !args.Translations {
-		stats, err = txapi.GetResourceStats(api, resource, sourceLanguage)
-	} else {
-		stats, err = txapi.GetResourceStats(api, resource, nil)
-	}
-	if err != nil {
-		sendMessage(err.Error(), true)
-		if !args.Skip {
-			abort()
+	if stats == nil {
+		if args.Source && !args.Translations {
+			stats, err = txapi.GetResourceStats(api, resource, sourceLanguage)
+		} else {
+			stats, err = txapi.GetResourceStats(api, resource, nil)
+		}
+		if err != nil {
+			fail(err.Error())
+			return
 		}
-		return
 	}
 
 	if args.Source {
@@ -226,6 +291,8 @@ func (task *ResourcePullTask) Run(send func(string), abort func()) {
 			stats[sourceLanguage.Id],
 			"",
 			remoteToLocalLanguageMappings,
+			task.report,
+			task.state,
 		}
 	}
 
@@ -238,10 +305,7 @@ func (task *ResourcePullTask) Run(send func(string), abort func()) {
 		// Local stuff
 		err = checkFileFilter(cfgResource.FileFilter)
 		if err != nil {
-			sendMessage(err.Error(), true)
-			if !args.Skip {
-				abort()
-			}
+			fail(err.Error())
 			return
 		}
 		fileFilter := setFileTypeExtensions(args.FileType, cfgResource.FileFilter)
@@ -325,6 +389,8 @@ func (task *ResourcePullTask) Run(send func(string), abort func()) {
 				info.stats,
 				info.filePath,
 				remoteToLocalLanguageMappings,
+				task.report,
+				task.state,
 			}
 		}
 	}
@@ -340,9 +406,13 @@ type FilePullTask struct {
 	stats                         *jsonapi.Resource
 	filePath                      string
 	remoteToLocalLanguageMappings map[string]string
+	report                        *Report
+	state                         *State
 }
 
-func (task *FilePullTask) Run(send func(string), abort func()) {
+func (task *FilePullTask) Run(
+	ctx context.Context, send func(string), abort func(),
+) {
 	cfgResource := task.cfgResource
 	languageCode := task.languageCode
 	args := task.args
@@ -372,18 +442,37 @@ func (task *FilePullTask) Run(send func(string), abort func()) {
 			body,
 		))
 	}
+	outcome := task.outcome()
+	fail := func(err string) {
+		sendMessage(err, true)
+		task.report.addFailure(ctx, outcome, err)
+		if !args.Skip {
+			abort()
+		}
+	}
+	skip := func(message string) {
+		sendMessage(message, args.DryRun)
+		outcome.Status = OutcomeSkipped
+		outcome.Reason = strings.TrimSuffix(message, ", skipping")
+		task.report.Add(outcome)
+	}
 	sendMessage("Pulling file", false)
 
+	jobCtx, jobApi, cancel := withJobTimeout(ctx, api, args.Timeout)
+	defer cancel()
+
 	if languageCode == "" {
 		sourceFile := setFileTypeExtensions(args.FileType, cfgResource.SourceFile)
+		outcome.Path = sourceFile
 
 		_, err := os.Stat(sourceFile)
 		if err == nil && args.DisableOverwrite {
 			if !args.KeepNewFiles {
-				sendMessage("Disable overwrite enabled, skipping", false)
+				skip("Disable overwrite enabled, skipping")
 				return
 			} else {
 				sourceFile = sourceFile + ".new"
+				outcome.Path = sourceFile
 			}
 		}
 
@@ -394,26 +483,31 @@ func (task *FilePullTask) Run(send func(string), abort func()) {
 				args.UseGitTimestamps,
 			)
 			if err != nil {
-				sendMessage(err.Error(), true)
-				if !args.Skip {
-					abort()
-				}
+				fail(err.Error())
 				return
 			}
 			if shouldSkip {
-				sendMessage("Local file is newer than remote, skipping", false)
+				skip("Local file is newer than remote, skipping")
 				return
 			}
 		}
 
+		if args.DryRun {
+			sendMessage(fmt.Sprintf("Would download to '%s'", sourceFile), true)
+			outcome.Status = OutcomePlanned
+			task.report.Add(outcome)
+			return
+		}
+
 		// Creating download job
 
 		var download *jsonapi.Resource
 		err = handleThrottling(
+			jobCtx,
 			func() error {
 				var err error
 				download, err = txapi.CreateResourceStringsAsyncDownload(
-					api,
+					jobApi,
 					resource,
 					args.ContentEncoding,
 					args.FileType,
@@ -425,35 +519,40 @@ func (task *FilePullTask) Run(send func(string), abort func()) {
 			func(msg string) { sendMessage(msg, false) },
 		)
 		if err != nil {
-			sendMessage(err.Error(), true)
-			if !args.Skip {
-				abort()
-			}
+			fail(jobErrorMessage(err, args.Timeout))
 			return
 		}
 
 		// Polling
 
 		err = handleThrottling(
+			jobCtx,
 			func() error {
-				return txapi.PollResourceStringsDownload(download, sourceFile)
+				return txapi.PollResourceStringsDownload(
+					jobCtx,
+					download,
+					sourceFile,
+					args.writeOptions(),
+					progressReporter(
+						"Downloading file",
+						func(msg string) { sendMessage(msg, false) },
+					),
+				)
 			},
 			"",
 			func(msg string) { sendMessage(msg, false) },
 		)
 		if err != nil {
-			sendMessage(err.Error(), true)
-			if !args.Skip {
-				abort()
-			}
+			fail(jobErrorMessage(err, args.Timeout))
 			return
 		}
 	} else {
 		if filePath != "" {
 			// Remote language file exists and so does local
+			outcome.Path = filePath
 			if args.DisableOverwrite {
 				if !args.KeepNewFiles {
-					sendMessage("Disable overwrite enabled, skipping", false)
+					skip("Disable overwrite enabled, skipping")
 					return
 				} else {
 					filePath = filePath + ".new"
@@ -469,7 +568,7 @@ func (task *FilePullTask) Run(send func(string), abort func()) {
 			if !args.All &&
 				(!stringSliceContains(args.Languages, remoteLanguageCode) &&
 					!stringSliceContains(args.Languages, localLanguageCode)) {
-				sendMessage("File was not found locally, skipping", false)
+				skip("File was not found locally, skipping")
 				return
 			}
 			pseudo_postfix := ""
@@ -484,6 +583,7 @@ func (task *FilePullTask) Run(send func(string), abort func()) {
 			)
 			filePath = setFileTypeExtensions(args.FileType, filePath)
 		}
+		outcome.Path = filePath
 		minimumPerc := args.MinimumPercentage
 		if minimumPerc == -1 {
 			if cfgResource.MinimumPercentage > -1 {
@@ -499,14 +599,18 @@ func (task *FilePullTask) Run(send func(string), abort func()) {
 			args.Force,
 		)
 		if err != nil {
-			sendMessage(err.Error(), true)
-			if !args.Skip {
-				abort()
-			}
+			fail(err.Error())
 			return
 		}
 		if shouldSkip {
-			sendMessage(feedbackMessage, false)
+			skip(feedbackMessage)
+			return
+		}
+
+		if args.DryRun {
+			sendMessage(fmt.Sprintf("Would download to '%s'", filePath), true)
+			outcome.Status = OutcomePlanned
+			task.report.Add(outcome)
 			return
 		}
 
@@ -514,11 +618,12 @@ func (task *FilePullTask) Run(send func(string), abort func()) {
 
 		var download *jsonapi.Resource
 		err = handleThrottling(
+			jobCtx,
 			func() error {
 				var err error
 				if args.Pseudo {
 					download, err = txapi.CreateResourceStringsAsyncDownload(
-						api,
+						jobApi,
 						resource,
 						args.ContentEncoding,
 						args.FileType,
@@ -526,7 +631,7 @@ func (task *FilePullTask) Run(send func(string), abort func()) {
 					)
 				} else {
 					download, err = txapi.CreateTranslationsAsyncDownload(
-						api,
+						jobApi,
 						resource,
 						languageCode,
 						args.ContentEncoding,
@@ -540,33 +645,65 @@ func (task *FilePullTask) Run(send func(string), abort func()) {
 			func(msg string) { sendMessage(msg, false) },
 		)
 		if err != nil {
-			sendMessage(err.Error(), true)
-			if !args.Skip {
-				abort()
-			}
+			fail(jobErrorMessage(err, args.Timeout))
 			return
 		}
 
 		// Polling
 
 		err = handleThrottling(
+			jobCtx,
 			func() error {
-				return txapi.PollTranslationDownload(download, filePath)
+				return txapi.PollTranslationDownload(
+					jobCtx,
+					download,
+					filePath,
+					args.writeOptions(),
+					progressReporter(
+						"Downloading file",
+						func(msg string) { sendMessage(msg, false) },
+					),
+				)
 			},
 			"",
 			func(msg string) { sendMessage(msg, false) },
 		)
 		if err != nil {
-			sendMessage(err.Error(), true)
-			if !args.Skip {
-				abort()
-			}
+			fail(jobErrorMessage(err, args.Timeout))
+			return
+		}
+	}
+	outcome.Status = OutcomePulled
+	task.report.Add(outcome)
+
+	// Files that were saved next to the local ones ('--keep-new-files') or
+	// that contain pseudo translations are not in sync with Transifex
+	if !args.Pseudo && !strings.HasSuffix(outcome.Path, ".new") {
+		err := task.state.Record(resource.Id, languageCode, outcome.Path, "")
+		if err != nil {
+			sendMessage(fmt.Sprintf("Could not record file state: %s", err), true)
 			return
 		}
 	}
 	sendMessage("Done", false)
 }
 
+func (task *ResourcePullTask) outcome() TaskOutcome {
+	return TaskOutcome{Resource: fmt.Sprintf(
+		"%s.%s", task.cfgResource.ProjectSlug, task.cfgResource.ResourceSlug,
+	)}
+}
+
+func (task *FilePullTask) outcome() TaskOutcome {
+	return TaskOutcome{
+		Resource: fmt.Sprintf(
+			"%s.%s", task.cfgResource.ProjectSlug, task.cfgResource.ResourceSlug,
+		),
+		Language: task.languageCode,
+		Path:     task.filePath,
+	}
+}
+
 func shouldSkipDownload(
 	path string, remoteStat *jsonapi.Resource, useGitTimestamps bool,
 	mode string, minimum_perc int, force bool,
@@ -1,11 +1,16 @@
 package txlib
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/transifex/cli/pkg/assert"
 	"github.com/transifex/cli/pkg/jsonapi"
@@ -40,7 +45,7 @@ func TestPullCommandResourceExists(t *testing.T) {
 		Workers:           1,
 	}
 
-	err := PullCommand(cfg, &api, &arguments)
+	err := PullCommand(context.Background(), cfg, &api, &arguments)
 	if err != nil {
 		t.Errorf("%s", err)
 	}
@@ -83,7 +88,7 @@ func TestPullCommandFileExists(t *testing.T) {
 		Workers:           1,
 	}
 
-	err := PullCommand(cfg, &api, &arguments)
+	err := PullCommand(context.Background(), cfg, &api, &arguments)
 	if err != nil {
 		t.Errorf("%s", err)
 	}
@@ -116,6 +121,7 @@ func TestPullCommandDownloadSource(t *testing.T) {
 
 	api := jsonapi.GetTestConnection(mockData)
 	err := PullCommand(
+		context.Background(),
 		cfg,
 		&api,
 		&PullCommandArguments{
@@ -176,7 +182,7 @@ func TestPullCommandSkipOnTranslatedMinPerc(t *testing.T) {
 		Workers:           1,
 	}
 
-	err := PullCommand(cfg, &api, &arguments)
+	err := PullCommand(context.Background(), cfg, &api, &arguments)
 	if err != nil {
 		t.Errorf("%s", err)
 	}
@@ -227,7 +233,7 @@ func TestPullCommandProceedOnEqualTranslatedMinPerc(t *testing.T) {
 		Workers:           1,
 	}
 
-	err := PullCommand(cfg, &api, &arguments)
+	err := PullCommand(context.Background(), cfg, &api, &arguments)
 	if err != nil {
 		t.Errorf("%s", err)
 	}
@@ -271,7 +277,7 @@ func TestPullCommandOverrides(t *testing.T) {
 		Workers:           1,
 	}
 
-	err := PullCommand(cfg, &api, &arguments)
+	err := PullCommand(context.Background(), cfg, &api, &arguments)
 	if err != nil {
 		t.Errorf("%s", err)
 	}
@@ -315,7 +321,7 @@ func TestPullCommandMultipleLangParameters(t *testing.T) {
 		Workers:           1,
 	}
 
-	err := PullCommand(cfg, &api, &arguments)
+	err := PullCommand(context.Background(), cfg, &api, &arguments)
 	if err != nil {
 		t.Errorf("%s", err)
 	}
@@ -364,7 +370,7 @@ func TestPullCommandSkipOnReviewedMinPerc(t *testing.T) {
 		Workers:           1,
 	}
 
-	err := PullCommand(cfg, &api, &arguments)
+	err := PullCommand(context.Background(), cfg, &api, &arguments)
 	if err != nil {
 		t.Errorf("%s", err)
 	}
@@ -409,7 +415,7 @@ func TestPullCommandSkipOnProofreadMinPerc(t *testing.T) {
 		Workers:           1,
 	}
 
-	err := PullCommand(cfg, &api, &arguments)
+	err := PullCommand(context.Background(), cfg, &api, &arguments)
 	if err != nil {
 		t.Errorf("%s", err)
 	}
@@ -455,7 +461,7 @@ func TestPullCommandPercentageArgumentShouldWinOverResource(t *testing.T) {
 		Workers:           1,
 	}
 
-	err := PullCommand(cfg, &api, &arguments)
+	err := PullCommand(context.Background(), cfg, &api, &arguments)
 	if err != nil {
 		t.Errorf("%s", err)
 	}
@@ -501,7 +507,7 @@ func TestPercentageWinsOverForce(t *testing.T) {
 		Force:             true,
 	}
 
-	err := PullCommand(cfg, &api, &arguments)
+	err := PullCommand(context.Background(), cfg, &api, &arguments)
 	if err != nil {
 		t.Errorf("%s", err)
 	}
@@ -579,7 +585,7 @@ func TestDownloadPseudoTranslations(t *testing.T) {
 		Pseudo:            true,
 	}
 
-	err := PullCommand(cfg, &api, &arguments)
+	err := PullCommand(context.Background(), cfg, &api, &arguments)
 	if err != nil {
 		t.Errorf("%s", err)
 	}
@@ -612,6 +618,7 @@ func TestKeepNewFilesSource(t *testing.T) {
 
 	api := jsonapi.GetTestConnection(mockData)
 	err := PullCommand(
+		context.Background(),
 		cfg,
 		&api,
 		&PullCommandArguments{
@@ -660,6 +667,7 @@ func TestKeepNewFilesSourceOnlyWithDisableOverride(t *testing.T) {
 
 	api := jsonapi.GetTestConnection(mockData)
 	err := PullCommand(
+		context.Background(),
 		cfg,
 		&api,
 		&PullCommandArguments{
@@ -721,7 +729,7 @@ func TestKeepNewFilesTranslation(t *testing.T) {
 		DisableOverwrite:  true,
 	}
 
-	err := PullCommand(cfg, &api, &arguments)
+	err := PullCommand(context.Background(), cfg, &api, &arguments)
 	if err != nil {
 		t.Errorf("%s", err)
 	}
@@ -820,3 +828,229 @@ func testSimpleSourceDownload(
 		),
 	)
 }
+
+func TestPullDryRun(t *testing.T) {
+	afterTest := beforeTest(t, nil, nil)
+	defer afterTest()
+
+	mockData := jsonapi.MockData{
+		resourceUrl:             getResourceEndpoint(),
+		projectUrl:              getProjectEndpoint(),
+		statsUrlAllLanguages:    getStatsEndpointAllLanguages(),
+		translationDownloadsUrl: getTranslationDownloadsEndpoint(),
+	}
+	api := jsonapi.GetTestConnection(mockData)
+
+	arguments := PullCommandArguments{
+		FileType:          "default",
+		Mode:              "default",
+		Force:             true,
+		All:               true,
+		MinimumPercentage: -1,
+		Workers:           1,
+		DryRun:            true,
+	}
+
+	err := PullCommand(context.Background(), getStandardConfig(), &api, &arguments)
+	if err != nil {
+		t.Errorf("%s", err)
+	}
+
+	testSimpleGet(t, mockData, resourceUrl)
+	testSimpleGet(t, mockData, projectUrl)
+	testSimpleGet(t, mockData, statsUrlAllLanguages)
+	if mockData[translationDownloadsUrl].Count != 0 {
+		t.Error("Download job was created during a dry run")
+	}
+	_, err = os.Stat("aaa-el.json")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestPullReport(t *testing.T) {
+	afterTest := beforeTest(t, nil, nil)
+	defer afterTest()
+
+	mockData := jsonapi.MockData{
+		resourceUrl: getEmptyEndpoint(),
+	}
+	api := jsonapi.GetTestConnection(mockData)
+
+	arguments := PullCommandArguments{
+		FileType:          "default",
+		Mode:              "default",
+		MinimumPercentage: -1,
+		Workers:           1,
+		ReportPath:        "report.json",
+	}
+
+	err := PullCommand(context.Background(), getStandardConfig(), &api, &arguments)
+	if err != nil {
+		t.Errorf("%s", err)
+	}
+
+	data, err := ioutil.ReadFile("report.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report Report
+	err = json.Unmarshal(data, &report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, report.Command, "pull")
+	assert.Equal(t, len(report.Outcomes), 1)
+	assert.Equal(t, report.Outcomes[0].Status, OutcomeSkipped)
+	assert.Equal(t, report.Outcomes[0].Reason, "Resource does not exist")
+}
+
+func TestPullTimeout(t *testing.T) {
+	afterTest := beforeTest(t, nil, nil)
+	defer afterTest()
+
+	// The download job is created but it never finishes
+	mockData := jsonapi.MockData{
+		resourceUrl:             getResourceEndpoint(),
+		projectUrl:              getProjectEndpoint(),
+		statsUrlAllLanguages:    getStatsEndpointAllLanguages(),
+		translationDownloadsUrl: getTranslationDownloadsEndpoint(),
+	}
+	api := jsonapi.GetTestConnection(mockData)
+
+	arguments := PullCommandArguments{
+		FileType:          "default",
+		Mode:              "default",
+		Force:             true,
+		Skip:              true,
+		All:               true,
+		MinimumPercentage: -1,
+		Workers:           1,
+		Timeout:           10 * time.Millisecond,
+		ReportPath:        "report.json",
+	}
+
+	err := PullCommand(context.Background(), getStandardConfig(), &api, &arguments)
+	var tasksFailedError *TasksFailedError
+	if !errors.As(err, &tasksFailedError) {
+		t.Fatalf("Expected a TasksFailedError, got %v", err)
+	}
+
+	data, err := ioutil.ReadFile("report.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report Report
+	err = json.Unmarshal(data, &report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, len(report.Outcomes), 1)
+	assert.Equal(t, report.Outcomes[0].Status, OutcomeFailed)
+	assert.Equal(t, report.Outcomes[0].Error, "Timed out after 10ms")
+	_, err = os.Stat("aaa-el.json")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestPullBackup(t *testing.T) {
+	afterTest := beforeTest(t, []string{"el"}, nil)
+	defer afterTest()
+
+	cfg := getStandardConfig()
+	cfg.Local.Resources[0].Overrides = map[string]string{"el": "custom_path.json"}
+	err := ioutil.WriteFile("custom_path.json", []byte("Old content"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts := getNewTestServer("This is the content")
+	defer ts.Close()
+
+	mockData := jsonapi.MockData{
+		resourceUrl:             getResourceEndpoint(),
+		projectUrl:              getProjectEndpoint(),
+		statsUrlAllLanguages:    getStatsEndpointAllLanguages(),
+		translationDownloadsUrl: getTranslationDownloadsEndpoint(),
+		translationDownloadUrl:  getDownloadEndpoint(ts.URL),
+	}
+
+	api := jsonapi.GetTestConnection(mockData)
+
+	arguments := PullCommandArguments{
+		FileType:          "default",
+		Mode:              "default",
+		Force:             true,
+		All:               true,
+		ResourceIds:       nil,
+		MinimumPercentage: -1,
+		Workers:           1,
+		Backup:            true,
+	}
+
+	err = PullCommand(context.Background(), cfg, &api, &arguments)
+	if err != nil {
+		t.Errorf("%s", err)
+	}
+
+	assertFileContent(t, "custom_path.json", "This is the content")
+	assertFileContent(t, "custom_path.json.bak", "Old content")
+}
+
+func TestPullRecordsState(t *testing.T) {
+	afterTest := beforeTest(t, []string{"el"}, nil)
+	defer afterTest()
+
+	cfg := getStandardConfig()
+	cfg.Local.Path = filepath.Join(".tx", "config")
+	cfg.Local.Resources[0].Overrides = map[string]string{"el": "custom_path.json"}
+
+	ts := getNewTestServer("This is the content")
+	defer ts.Close()
+
+	mockData := jsonapi.MockData{
+		resourceUrl:             getResourceEndpoint(),
+		projectUrl:              getProjectEndpoint(),
+		statsUrlAllLanguages:    getStatsEndpointAllLanguages(),
+		translationDownloadsUrl: getTranslationDownloadsEndpoint(),
+		translationDownloadUrl:  getDownloadEndpoint(ts.URL),
+	}
+
+	api := jsonapi.GetTestConnection(mockData)
+
+	arguments := PullCommandArguments{
+		FileType:          "default",
+		Mode:              "default",
+		Force:             true,
+		All:               true,
+		ResourceIds:       nil,
+		MinimumPercentage: -1,
+		Workers:           1,
+	}
+
+	err := PullCommand(context.Background(), cfg, &api, &arguments)
+	if err != nil {
+		t.Errorf("%s", err)
+	}
+
+	state, err := LoadState(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unchanged, _, err := state.Check(
+		"o:orgslug:p:projslug:r:resslug", "el", "custom_path.json",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, unchanged, "Pulled file was not recorded in the state")
+
+	err = ioutil.WriteFile("custom_path.json", []byte("Changed"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unchanged, _, err = state.Check(
+		"o:orgslug:p:projslug:r:resslug", "el", "custom_path.json",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, !unchanged, "Changed file was reported as unchanged")
+}
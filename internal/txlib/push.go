@@ -1,7 +1,7 @@
 package txlib
 
 import (
-	"errors"
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -24,6 +24,9 @@ type PushCommandArguments struct {
 	Xliff                bool
 	Languages            []string
 	ResourceIds          []string
+	ExcludeResourceIds   []string
+	Tags                 []string
+	ExcludeTags          []string
 	UseGitTimestamps     bool
 	Branch               string
 	Base                 string
@@ -32,16 +35,43 @@ type PushCommandArguments struct {
 	Silent               bool
 	ReplaceEditedStrings bool
 	KeepTranslations     bool
+	DryRun               bool
+	ReportPath           string
+	Timeout              time.Duration
+	AllowDeletions       bool
 }
 
 func PushCommand(
+	ctx context.Context,
 	cfg *config.Config,
 	api jsonapi.Connection,
 	args PushCommandArguments,
+) error {
+	report := NewReport("push")
+	state, err := LoadState(cfg)
+	if err != nil {
+		return err
+	}
+	err = pushResources(ctx, cfg, api, args, report, state, nil)
+	err = saveState(state, err)
+	return finishReport(report, args.ReportPath, err)
+}
+
+func pushResources(
+	ctx context.Context,
+	cfg *config.Config,
+	api jsonapi.Connection,
+	args PushCommandArguments,
+	report *Report,
+	state *State,
+	cache *resourceCache,
 ) error {
 	args.Branch = figureOutBranch(args.Branch)
 
-	cfgResources, err := figureOutResources(args.ResourceIds, cfg)
+	cfgResources, err := selectResources(
+		args.ResourceIds, args.ExcludeResourceIds,
+		args.Tags, args.ExcludeTags, cfg,
+	)
 	if err != nil {
 		return err
 	}
@@ -54,11 +84,14 @@ func PushCommand(
 
 	// Step 1: Resources
 
+	if args.DryRun {
+		fmt.Print("# Dry run: nothing will be changed on Transifex\n\n")
+	}
 	if !args.Silent {
 		fmt.Print("# Getting info about resources\n\n")
 	}
 
-	pool := worker_pool.New(args.Workers, len(cfgResources), args.Silent)
+	pool := worker_pool.New(ctx, args.Workers, len(cfgResources), args.Silent)
 	sourceTaskChannel := make(chan *SourceFilePushTask)
 	translationTaskChannel := make(chan *TranslationFileTask)
 	targetLanguagesChannel := make(chan TargetLanguageMessage)
@@ -72,6 +105,9 @@ func PushCommand(
 				&api,
 				args,
 				targetLanguagesChannel,
+				report,
+				state.For(cfgResource),
+				cache,
 			},
 		)
 	}
@@ -118,8 +154,9 @@ func PushCommand(
 		}
 	}
 
-	if pool.IsAborted {
-		return errors.New("Aborted")
+	err = checkPool(pool, report)
+	if err != nil {
+		return err
 	}
 	if args.Silent {
 		var names []string
@@ -140,19 +177,22 @@ func PushCommand(
 			fmt.Print("\n# Create missing remote target languages\n\n")
 		}
 
-		pool = worker_pool.New(args.Workers, len(targetLanguages), args.Silent)
+		pool = worker_pool.New(ctx, args.Workers, len(targetLanguages), args.Silent)
 		for projectId, languages := range targetLanguages {
 			sort.Slice(languages, func(i, j int) bool {
 				return languages[i] < languages[j]
 			})
-			pool.Add(&LanguagePushTask{projects[projectId], languages, args})
+			pool.Add(&LanguagePushTask{
+				projects[projectId], languages, args, report,
+			})
 		}
 		pool.Start()
 		<-pool.Wait()
-		if pool.IsAborted {
-			return errors.New("Aborted")
+		err = checkPool(pool, report)
+		if err != nil {
+			return err
 		}
-		if args.Silent {
+		if args.Silent && !args.DryRun {
 			var names []string
 			for projectId, languages := range targetLanguages {
 				parts := strings.Split(projectId, ":")
@@ -183,17 +223,18 @@ func PushCommand(
 		sort.Slice(sourceFileTasks, func(i, j int) bool {
 			return sourceFileTasks[i].resource.Id < sourceFileTasks[j].resource.Id
 		})
-		pool = worker_pool.New(args.Workers, len(sourceFileTasks), args.Silent)
+		pool = worker_pool.New(ctx, args.Workers, len(sourceFileTasks), args.Silent)
 		for _, sourceFileTask := range sourceFileTasks {
 			pool.Add(sourceFileTask)
 		}
 		pool.Start()
 		<-pool.Wait()
 
-		if pool.IsAborted {
-			return errors.New("Aborted")
+		err = checkPool(pool, report)
+		if err != nil {
+			return err
 		}
-		if args.Silent {
+		if args.Silent && !args.DryRun {
 			var names []string
 			for _, sourceFileTask := range sourceFileTasks {
 				parts := strings.Split(sourceFileTask.resource.Id, ":")
@@ -223,17 +264,18 @@ func PushCommand(
 			fmt.Print("\n# Pushing translations\n\n")
 		}
 
-		pool = worker_pool.New(args.Workers, len(translationFileTasks), args.Silent)
+		pool = worker_pool.New(ctx, args.Workers, len(translationFileTasks), args.Silent)
 		for _, translationFileTask := range translationFileTasks {
 			pool.Add(translationFileTask)
 		}
 		pool.Start()
 		<-pool.Wait()
 
-		if pool.IsAborted {
-			return errors.New("Aborted")
+		err = checkPool(pool, report)
+		if err != nil {
+			return err
 		}
-		if args.Silent {
+		if args.Silent && !args.DryRun {
 			var names []string
 			for _, translationFileTask := range translationFileTasks {
 				parts := strings.Split(translationFileTask.resource.Id, ":")
@@ -264,9 +306,14 @@ type ResourcePushTask struct {
 	api                    *jsonapi.Connection
 	args                   PushCommandArguments
 	targetLanguagesChannel chan TargetLanguageMessage
+	report                 *Report
+	state                  *State
+	cache                  *resourceCache
 }
 
-func (task *ResourcePushTask) Run(send func(string), abort func()) {
+func (task *ResourcePushTask) Run(
+	ctx context.Context, send func(string), abort func(),
+) {
 	cfg := task.cfg
 	cfgResource := task.cfgResource
 	sourceTaskChannel := task.sourceTaskChannel
@@ -286,35 +333,32 @@ func (task *ResourcePushTask) Run(send func(string), abort func()) {
 			body,
 		))
 	}
-	sendMessage("Getting info", false)
-	resource, err := txapi.GetResourceById(api, cfgResource.GetAPv3Id())
-	if err != nil {
-		sendMessage(fmt.Sprintf("Error while fetching resource: %s", err), true)
+	fail := func(err string) {
+		sendMessage(err, true)
+		task.report.addFailure(ctx, task.outcome(), err)
 		if !args.Skip {
 			abort()
 		}
+	}
+	sendMessage("Getting info", false)
+	resource, err := txapi.GetResourceById(api, cfgResource.GetAPv3Id())
+	if err != nil {
+		fail(fmt.Sprintf("Error while fetching resource: %s", err))
 		return
 	}
 
 	resourceIsNew := resource == nil
 	if resourceIsNew {
 		if args.Translation && !args.Source {
-			sendMessage(
-				"You are attempting to push translations for a resource that doesn't "+
-					"exist yet",
-				true,
+			fail(
+				"You are attempting to push translations for a resource that " +
+					"doesn't exist yet",
 			)
-			if !args.Skip {
-				abort()
-			}
 			return
 		}
 		sendMessage("Resource does not exist; creating", false)
 		if cfgResource.Type == "" {
-			sendMessage("Error: Cannot create resource, i18n type is unknown", true)
-			if !args.Skip {
-				abort()
-			}
+			fail("Error: Cannot create resource, i18n type is unknown")
 			return
 		}
 		var resourceName string
@@ -339,18 +383,12 @@ func (task *ResourcePushTask) Run(send func(string), abort func()) {
 			)
 			baseResource, err := txapi.GetResourceById(api, baseResourceId)
 			if err != nil {
-				sendMessage(fmt.Sprintf("Error while fetching base resource: %s", err), true)
-				if !args.Skip {
-					abort()
-				}
+				fail(fmt.Sprintf("Error while fetching base resource: %s", err))
 				return
 			}
 			if args.Base != "-1" {
 				if baseResource == nil {
-					sendMessage(fmt.Sprintf("Base Resource does not exist: %s", baseResourceId), true)
-					if !args.Skip {
-						abort()
-					}
+					fail(fmt.Sprintf("Base Resource does not exist: %s", baseResourceId))
 					return
 				}
 			} else {
@@ -360,23 +398,33 @@ func (task *ResourcePushTask) Run(send func(string), abort func()) {
 			}
 		}
 
-		resource, err = txapi.CreateResource(
-			api,
-			fmt.Sprintf(
-				"o:%s:p:%s",
-				cfgResource.OrganizationSlug,
-				cfgResource.ProjectSlug,
-			),
-			resourceName,
-			cfgResource.ResourceSlug,
-			cfgResource.Type,
-			baseResourceId,
-		)
-		if err != nil {
-			sendMessage(fmt.Sprintf("Error while creating resource, %s", err), true)
-			if !args.Skip {
-				abort()
+		if args.DryRun {
+			message := fmt.Sprintf(
+				"Would create resource '%s' of type '%s'",
+				resourceName,
+				cfgResource.Type,
+			)
+			if baseResourceId != "" {
+				message = fmt.Sprintf("%s with base '%s'", message, baseResourceId)
 			}
+			sendMessage(message, true)
+			resource = getDryRunResource(api, cfgResource)
+		} else {
+			resource, err = txapi.CreateResource(
+				api,
+				fmt.Sprintf(
+					"o:%s:p:%s",
+					cfgResource.OrganizationSlug,
+					cfgResource.ProjectSlug,
+				),
+				resourceName,
+				cfgResource.ResourceSlug,
+				cfgResource.Type,
+				baseResourceId,
+			)
+		}
+		if err != nil {
+			fail(fmt.Sprintf("Error while creating resource, %s", err))
 			return
 		}
 	} else {
@@ -392,12 +440,13 @@ func (task *ResourcePushTask) Run(send func(string), abort func()) {
 
 			applyBranchToResources([]*config.Resource{cfgResource}, args.Branch)
 			resource.SetRelated("base", &jsonapi.Resource{Type: "resources", Id: baseResourceId})
-			err = resource.Save([]string{"base"})
+			if args.DryRun {
+				sendMessage(fmt.Sprintf("Would set base to '%s'", baseResourceId), true)
+			} else {
+				err = resource.Save([]string{"base"})
+			}
 			if err != nil {
-				sendMessage(err.Error(), true)
-				if !args.Skip {
-					abort()
-				}
+				fail(err.Error())
 				return
 			}
 		}
@@ -406,39 +455,39 @@ func (task *ResourcePushTask) Run(send func(string), abort func()) {
 	sendMessage("Getting stats", false)
 	projectRelationship, err := resource.Fetch("project")
 	if err != nil {
-		sendMessage(err.Error(), true)
-		if !args.Skip {
-			abort()
-		}
+		fail(err.Error())
 		return
 	}
 	project := projectRelationship.DataSingular
 	sourceLanguageRelationship, exists := project.Relationships["source_language"]
 	if !exists {
-		sendMessage(
-			"Invalid API response, project does not have a 'source_language' "+
+		fail(
+			"Invalid API response, project does not have a 'source_language' " +
 				"relationship",
-			true,
 		)
-		if !args.Skip {
-			abort()
-		}
 		return
 	}
 	sourceLanguage := sourceLanguageRelationship.DataSingular
 	var remoteStats map[string]*jsonapi.Resource
-	if args.Translation {
+	if args.DryRun && resourceIsNew {
+		// The resource was not actually created so there are no stats to fetch
+		remoteStats = make(map[string]*jsonapi.Resource)
+	} else if args.Translation {
 		remoteStats, err = txapi.GetResourceStats(api, resource, nil)
 	} else {
 		remoteStats, err = txapi.GetResourceStats(api, resource, sourceLanguage)
 	}
 	if err != nil {
-		sendMessage(fmt.Sprintf("Error while fetching stats, %s", err), true)
-		if !args.Skip {
-			abort()
-		}
+		fail(fmt.Sprintf("Error while fetching stats, %s", err))
 		return
 	}
+	if !args.DryRun || !resourceIsNew {
+		if args.Translation {
+			task.cache.store(resource, project, remoteStats)
+		} else {
+			task.cache.store(resource, project, nil)
+		}
+	}
 	if args.Source || !args.Translation {
 		sourceTaskChannel <- &SourceFilePushTask{
 			api,
@@ -449,6 +498,10 @@ func (task *ResourcePushTask) Run(send func(string), abort func()) {
 			resourceIsNew,
 			args.ReplaceEditedStrings || cfgResource.ReplaceEditedStrings,
 			args.KeepTranslations || cfgResource.KeepTranslations,
+			cfgResource,
+			task.report,
+			task.state,
+			task.cache,
 		}
 	}
 	if args.Translation { // -t flag is set
@@ -456,24 +509,21 @@ func (task *ResourcePushTask) Run(send func(string), abort func()) {
 			*cfg,
 			*cfgResource,
 		)
+		remoteToLocalLanguageMappings := makeRemoteToLocalLanguageMappings(
+			localToRemoteLanguageMappings,
+		)
 		overrides := cfgResource.Overrides
 
 		sendMessage("Fetching remote languages", false)
 		curDir, err := os.Getwd()
 		if err != nil {
-			sendMessage(err.Error(), true)
-			if !args.Skip {
-				abort()
-			}
+			fail(err.Error())
 			return
 		}
 		fileFilter := cfgResource.FileFilter
 		err = checkFileFilter(fileFilter)
 		if err != nil {
-			sendMessage(err.Error(), true)
-			if !args.Skip {
-				abort()
-			}
+			fail(err.Error())
 			return
 		}
 		if args.Xliff {
@@ -485,16 +535,13 @@ func (task *ResourcePushTask) Run(send func(string), abort func()) {
 			remoteStats, overrides, args, resourceIsNew,
 		)
 		if err != nil {
-			sendMessage(err.Error(), true)
-			if !args.Skip {
-				abort()
-			}
+			fail(err.Error())
 			return
 		}
 
 		allLanguages, err := txapi.GetLanguages(api)
 		if err != nil {
-			sendMessage(err.Error(), true)
+			fail(err.Error())
 			abort()
 			return
 		}
@@ -510,15 +557,23 @@ func (task *ResourcePushTask) Run(send func(string), abort func()) {
 			if !exists || fmt.Sprintf("l:%s", languageCode) == sourceLanguage.Id {
 				continue
 			}
+			localLanguageCode, exists := remoteToLocalLanguageMappings[languageCode]
+			if !exists {
+				localLanguageCode = languageCode
+			}
 
 			translationTaskChannel <- &TranslationFileTask{
 				api,
 				languageCode,
+				localLanguageCode,
 				path,
 				resource,
 				args,
 				remoteStats,
 				resourceIsNew,
+				task.report,
+				task.state,
+				task.cache,
 			}
 		}
 	}
@@ -529,9 +584,12 @@ type LanguagePushTask struct {
 	project   *jsonapi.Resource
 	languages []string
 	args      PushCommandArguments
+	report    *Report
 }
 
-func (task *LanguagePushTask) Run(send func(string), abort func()) {
+func (task *LanguagePushTask) Run(
+	ctx context.Context, send func(string), abort func(),
+) {
 	project := task.project
 	languages := task.languages
 	args := task.args
@@ -549,6 +607,10 @@ func (task *LanguagePushTask) Run(send func(string), abort func()) {
 			body,
 		))
 	}
+	if args.DryRun {
+		sendMessage("Would add missing target languages", true)
+		return
+	}
 	sendMessage("Pushing", false)
 
 	var payload []*jsonapi.Resource
@@ -561,6 +623,7 @@ func (task *LanguagePushTask) Run(send func(string), abort func()) {
 	err := project.Add("languages", payload)
 	if err != nil {
 		sendMessage(err.Error(), true)
+		task.report.addFailure(ctx, task.outcome(), err.Error())
 		abort()
 		return
 	}
@@ -577,9 +640,15 @@ type SourceFilePushTask struct {
 	resourceIsNew        bool
 	replaceEditedStrings bool
 	keepTranslations     bool
+	cfgResource          *config.Resource
+	report               *Report
+	state                *State
+	cache                *resourceCache
 }
 
-func (task *SourceFilePushTask) Run(send func(string), abort func()) {
+func (task *SourceFilePushTask) Run(
+	ctx context.Context, send func(string), abort func(),
+) {
 	api := task.api
 	resource := task.resource
 	sourceFile := task.sourceFile
@@ -596,44 +665,112 @@ func (task *SourceFilePushTask) Run(send func(string), abort func()) {
 		}
 		send(fmt.Sprintf("%s.%s - %s", parts[3], parts[5], body))
 	}
-
-	file, err := os.Open(sourceFile)
-	if err != nil {
-		sendMessage(err.Error(), true)
+	outcome := task.outcome()
+	fail := func(err string) {
+		sendMessage(err, true)
+		task.report.addFailure(ctx, outcome, err)
 		if !args.Skip {
 			abort()
 		}
+	}
+
+	_, err := os.Stat(sourceFile)
+	if err != nil {
+		fail(err.Error())
 		return
 	}
-	defer file.Close()
 
-	// Only check timestamps if -f isn't set and if resource isn't new
+	// Only check contents and timestamps if -f isn't set and if resource
+	// isn't new
+	var hash string
 	if !args.Force && !resourceIsNew {
+		var unchanged bool
+		unchanged, hash, err = task.state.Check(resource.Id, "", sourceFile)
+		if err != nil {
+			fail(err.Error())
+			return
+		}
+		if unchanged {
+			sendMessage(
+				"Skipping because file has not changed since last push or pull",
+				args.DryRun,
+			)
+			outcome.Status = OutcomeSkipped
+			outcome.Reason = "file has not changed since last push or pull"
+			task.report.Add(outcome)
+			return
+		}
+
 		// Project should already be pre-fetched
 		skip, err := shouldSkipPush(
 			sourceFile, remoteStats, args.UseGitTimestamps,
 		)
 		if skip {
-			sendMessage("Skipping", false)
+			sendMessage(
+				"Skipping because remote file is newer than local",
+				args.DryRun,
+			)
+			outcome.Status = OutcomeSkipped
+			outcome.Reason = "remote file is newer than local"
+			task.report.Add(outcome)
 			return
 		}
 		if err != nil {
-			sendMessage(err.Error(), true)
-			if !args.Skip {
-				abort()
-			}
+			fail(err.Error())
+			return
+		}
+	}
+
+	// The deletion check below creates a download job, so a dry run stops
+	// before it
+	if args.DryRun {
+		sendMessage(fmt.Sprintf("Would push source file '%s'", sourceFile), true)
+		outcome.Status = OutcomePlanned
+		task.report.Add(outcome)
+		return
+	}
+
+	jobCtx, jobApi, cancel := withJobTimeout(ctx, api, args.Timeout)
+	defer cancel()
+
+	// Make sure a broken source file doesn't wipe the remote strings (and
+	// their translations)
+	if !resourceIsNew && !args.AllowDeletions {
+		err = checkSourceDeletions(
+			jobCtx,
+			jobApi,
+			resource,
+			task.cfgResource,
+			sourceFile,
+			args.Timeout,
+			func(msg string) { sendMessage(msg, false) },
+		)
+		if err != nil {
+			fail(err.Error())
 			return
 		}
 	}
 
 	// Uploading file
 
+	uploadStartedAt := time.Now()
 	var sourceUpload *jsonapi.Resource
 	err = handleThrottling(
+		jobCtx,
 		func() error {
 			var err error
 			sourceUpload, err = txapi.UploadSource(
-				api, resource, file, replaceEditedStrings, keepTranslations,
+				jobApi,
+				resource,
+				&jsonapi.File{
+					Path: sourceFile,
+					Progress: progressReporter(
+						"Uploading file",
+						func(msg string) { sendMessage(msg, false) },
+					),
+				},
+				replaceEditedStrings,
+				keepTranslations,
 			)
 			return err
 		},
@@ -641,44 +778,168 @@ func (task *SourceFilePushTask) Run(send func(string), abort func()) {
 		func(msg string) { sendMessage(msg, false) },
 	)
 	if err != nil {
-		sendMessage(err.Error(), true)
-		if !args.Skip {
-			abort()
-		}
+		fail(jobErrorMessage(err, args.Timeout))
 		return
 	}
 
 	// Polling
 
 	err = handleThrottling(
+		jobCtx,
 		func() error {
-			return txapi.PollSourceUpload(sourceUpload)
+			return txapi.PollSourceUpload(jobCtx, sourceUpload)
 		},
 		"",
 		func(msg string) { sendMessage(msg, false) },
 	)
 	if err != nil {
-		sendMessage(err.Error(), true)
-		if !args.Skip {
-			abort()
-		}
+		fail(jobErrorMessage(err, args.Timeout))
 		return
 	}
 
+	outcome.Status = OutcomePushed
+	var uploadAttributes txapi.ResourceStringAsyncUploadAttributes
+	err = sourceUpload.MapAttributes(&uploadAttributes)
+	if err == nil {
+		outcome.Details = map[string]int{
+			"strings_created": uploadAttributes.Details.StringsCreated,
+			"strings_updated": uploadAttributes.Details.StringsUpdated,
+			"strings_deleted": uploadAttributes.Details.StringsDeleted,
+			"strings_skipped": uploadAttributes.Details.StringsSkipped,
+		}
+	}
+	if err != nil || uploadAttributes.Details.StringsCreated > 0 ||
+		uploadAttributes.Details.StringsUpdated > 0 ||
+		uploadAttributes.Details.StringsDeleted > 0 {
+		task.cache.sourcePushed(resource.Id, uploadStartedAt)
+	}
+	task.report.Add(outcome)
+	err = task.state.Record(resource.Id, "", sourceFile, hash)
+	if err != nil {
+		sendMessage(fmt.Sprintf("Could not record file state: %s", err), true)
+		return
+	}
 	sendMessage("Done", false)
 }
 
+/*
+Pushes that would delete more than this percentage of the remote source
+strings of a resource are refused, unless the resource configures its own
+limits
+*/
+const defaultMaxDeletionPercent = 25
+
+// How many of the strings that would be deleted to list in the error
+const maxListedDeletions = 20
+
+/*
+Compare the local source file with the source strings on Transifex and return
+an error listing the strings that would be deleted if they are more than the
+'max_deletions' and 'max_deletion_percent' limits of the resource allow
+*/
+func checkSourceDeletions(
+	ctx context.Context,
+	api *jsonapi.Connection,
+	resource *jsonapi.Resource,
+	cfgResource *config.Resource,
+	sourceFile string,
+	timeout time.Duration,
+	send func(string),
+) error {
+	// If the resource doesn't configure any limits and we can't make sense of
+	// the local file, leave it to Transifex to validate it
+	hasLimits := cfgResource.MaxDeletions != nil ||
+		cfgResource.MaxDeletionPercent != nil
+	cannotCheck := func(err error) error {
+		if !hasLimits {
+			return nil
+		}
+		return fmt.Errorf(
+			"Could not check for deleted strings: %s; use --allow-deletions "+
+				"to push anyway",
+			err,
+		)
+	}
+	parse, err := getSourceStringsParser(cfgResource.Type, sourceFile)
+	if err != nil {
+		return cannotCheck(err)
+	}
+	data, err := os.ReadFile(sourceFile)
+	if err != nil {
+		return err
+	}
+	local, err := parse(data)
+	if err != nil {
+		return cannotCheck(
+			fmt.Errorf("could not parse '%s': %s", sourceFile, err),
+		)
+	}
+
+	send("Checking for deleted strings")
+	remote, err := getRemoteSourceStrings(
+		ctx, api, resource, parse, timeout, send,
+	)
+	if err != nil {
+		return err
+	}
+	return checkDeletions(diffStrings(remote, local), cfgResource)
+}
+
+func checkDeletions(diff StringsDiff, cfgResource *config.Resource) error {
+	deleted := len(diff.Deleted)
+	if deleted == 0 {
+		return nil
+	}
+	total := deleted + len(diff.Updated) + diff.Unchanged
+
+	maxDeletions := cfgResource.MaxDeletions
+	maxDeletionPercent := cfgResource.MaxDeletionPercent
+	if maxDeletions == nil && maxDeletionPercent == nil {
+		defaultPercent := defaultMaxDeletionPercent
+		maxDeletionPercent = &defaultPercent
+	}
+
+	var limit string
+	if maxDeletions != nil && deleted > *maxDeletions {
+		limit = fmt.Sprintf("max_deletions is %d", *maxDeletions)
+	} else if maxDeletionPercent != nil &&
+		deleted*100 > *maxDeletionPercent*total {
+		limit = fmt.Sprintf("max_deletion_percent is %d", *maxDeletionPercent)
+	} else {
+		return nil
+	}
+
+	keys := diff.Deleted
+	if len(keys) > maxListedDeletions {
+		keys = append(
+			append([]string{}, keys[:maxListedDeletions]...),
+			fmt.Sprintf("and %d more", len(diff.Deleted)-maxListedDeletions),
+		)
+	}
+	return fmt.Errorf(
+		"Refusing to push source file because it would delete %d of %d "+
+			"strings (%s): %s; use --allow-deletions to push anyway",
+		deleted, total, limit, strings.Join(keys, ", "),
+	)
+}
+
 type TranslationFileTask struct {
-	api           *jsonapi.Connection
-	languageCode  string
-	path          string
-	resource      *jsonapi.Resource
-	args          PushCommandArguments
-	remoteStats   map[string]*jsonapi.Resource
-	resourceIsNew bool
+	api               *jsonapi.Connection
+	languageCode      string
+	localLanguageCode string
+	path              string
+	resource          *jsonapi.Resource
+	args              PushCommandArguments
+	remoteStats       map[string]*jsonapi.Resource
+	resourceIsNew     bool
+	report            *Report
+	state             *State
+	cache             *resourceCache
 }
 
-func (task *TranslationFileTask) Run(send func(string), abort func()) {
+func (task *TranslationFileTask) Run(
+	ctx context.Context, send func(string), abort func(),
+) {
 	api := task.api
 	languageCode := task.languageCode
 	path := task.path
@@ -698,35 +959,84 @@ func (task *TranslationFileTask) Run(send func(string), abort func()) {
 			cyan("["+languageCode+"]"), body,
 		))
 	}
+	outcome := task.outcome()
+	fail := func(err string) {
+		sendMessage(err, true)
+		task.report.addFailure(ctx, outcome, err)
+		if !args.Skip {
+			abort()
+		}
+	}
 
-	// Only check timestamps if -f isn't set and if resource isn't new
+	// Only check contents and timestamps if -f isn't set and if resource
+	// isn't new
+	var hash string
 	if !args.Force && !resourceIsNew {
+		var unchanged bool
+		var err error
+		unchanged, hash, err = task.state.Check(resource.Id, languageCode, path)
+		if err != nil {
+			fail(err.Error())
+			return
+		}
+		if unchanged {
+			sendMessage(
+				"Skipping because file has not changed since last push or pull",
+				args.DryRun,
+			)
+			outcome.Status = OutcomeSkipped
+			outcome.Reason = "file has not changed since last push or pull"
+			task.report.Add(outcome)
+			return
+		}
+
 		languageId := fmt.Sprintf("l:%s", languageCode)
 		remoteStat, exists := remoteStats[languageId]
 		if exists {
 			skip, err := shouldSkipPush(path, remoteStat, args.UseGitTimestamps)
 			if err != nil {
-				sendMessage(err.Error(), true)
-				if !args.Skip {
-					abort()
-				}
+				fail(err.Error())
 				return
 			}
 			if skip {
-				sendMessage("Skipping because remote file is newer than local", false)
+				sendMessage(
+					"Skipping because remote file is newer than local",
+					args.DryRun,
+				)
+				outcome.Status = OutcomeSkipped
+				outcome.Reason = "remote file is newer than local"
+				task.report.Add(outcome)
 				return
 			}
 		}
 	}
 
+	if args.DryRun {
+		sendMessage(fmt.Sprintf(
+			"Would push translation file '%s' (%s -> %s)",
+			path, task.localLanguageCode, languageCode,
+		), true)
+		outcome.Status = OutcomePlanned
+		task.report.Add(outcome)
+		return
+	}
+
+	jobCtx, jobApi, cancel := withJobTimeout(ctx, api, args.Timeout)
+	defer cancel()
+
 	// Uploading file
 
 	var upload *jsonapi.Resource
 	err := handleThrottling(
+		jobCtx,
 		func() error {
 			var err error
 			upload, err = pushTranslation(
-				api, languageCode, path, resource, args,
+				jobApi, languageCode, path, resource, args,
+				progressReporter(
+					"Uploading file",
+					func(msg string) { sendMessage(msg, false) },
+				),
 			)
 			return err
 		},
@@ -734,31 +1044,99 @@ func (task *TranslationFileTask) Run(send func(string), abort func()) {
 		func(msg string) { sendMessage(msg, false) },
 	)
 	if err != nil {
-		sendMessage(err.Error(), true)
-		if !args.Skip {
-			abort()
-		}
+		fail(jobErrorMessage(err, args.Timeout))
 		return
 	}
 
 	// Polling
 	err = handleThrottling(
+		jobCtx,
 		func() error {
-			return txapi.PollTranslationUpload(upload)
+			return txapi.PollTranslationUpload(jobCtx, upload)
 		},
 		"",
 		func(msg string) { sendMessage(msg, false) },
 	)
 	if err != nil {
-		sendMessage(err.Error(), true)
-		if !args.Skip {
-			abort()
+		fail(jobErrorMessage(err, args.Timeout))
+		return
+	}
+
+	outcome.Status = OutcomePushed
+	var uploadAttributes txapi.ResourceTranslationsAsyncUploadAttributes
+	err = upload.MapAttributes(&uploadAttributes)
+	if err == nil {
+		outcome.Details = map[string]int{
+			"translations_created": uploadAttributes.Details.TranslationsCreated,
+			"translations_updated": uploadAttributes.Details.TranslationsUpdated,
 		}
+	}
+	task.cache.invalidate(resource.Id)
+	task.report.Add(outcome)
+	err = task.state.Record(resource.Id, languageCode, path, hash)
+	if err != nil {
+		sendMessage(fmt.Sprintf("Could not record file state: %s", err), true)
 		return
 	}
 	sendMessage("Done", false)
 }
 
+func (task *ResourcePushTask) outcome() TaskOutcome {
+	return TaskOutcome{Resource: fmt.Sprintf(
+		"%s.%s", task.cfgResource.ProjectSlug, task.cfgResource.ResourceSlug,
+	)}
+}
+
+func (task *LanguagePushTask) outcome() TaskOutcome {
+	return TaskOutcome{
+		Resource: strings.Split(task.project.Id, ":")[3],
+		Language: strings.Join(task.languages, ", "),
+	}
+}
+
+func (task *SourceFilePushTask) outcome() TaskOutcome {
+	parts := strings.Split(task.resource.Id, ":")
+	return TaskOutcome{
+		Resource: fmt.Sprintf("%s.%s", parts[3], parts[5]),
+		Path:     task.sourceFile,
+	}
+}
+
+func (task *TranslationFileTask) outcome() TaskOutcome {
+	parts := strings.Split(task.resource.Id, ":")
+	return TaskOutcome{
+		Resource: fmt.Sprintf("%s.%s", parts[3], parts[5]),
+		Language: task.languageCode,
+		Path:     task.path,
+	}
+}
+
+/*
+Return a stand-in for a resource that would have been created if we were not in
+dry-run mode, so that the rest of the planning (fetching the project, figuring
+out which files would be pushed) can proceed.
+*/
+func getDryRunResource(
+	api *jsonapi.Connection, cfgResource *config.Resource,
+) *jsonapi.Resource {
+	resource := &jsonapi.Resource{
+		API:  api,
+		Type: "resources",
+		Id:   cfgResource.GetAPv3Id(),
+	}
+	resource.SetRelated("project", &jsonapi.Resource{
+		API:  api,
+		Type: "projects",
+		Id: fmt.Sprintf(
+			"o:%s:p:%s",
+			cfgResource.OrganizationSlug,
+			cfgResource.ProjectSlug,
+		),
+	})
+	resource.Relationships["project"].Fetched = false
+	return resource
+}
+
 func getFilesToPush(
 	curDir, fileFilter string,
 	localToRemoteLanguageMappings map[string]string,
@@ -822,19 +1200,20 @@ func pushTranslation(
 	languageCode, path string,
 	resource *jsonapi.Resource,
 	args PushCommandArguments,
+	progress jsonapi.ProgressFunc,
 ) (*jsonapi.Resource, error) {
-	file, err := os.Open(path)
-	if err != nil {
-		return nil, err
-	}
-	defer file.Close()
-
 	language := &jsonapi.Resource{
 		API:  api,
 		Type: "languages",
 		Id:   fmt.Sprintf("l:%s", languageCode),
 	}
-	upload, err := txapi.UploadTranslation(api, resource, language, file, args.Xliff)
+	upload, err := txapi.UploadTranslation(
+		api,
+		resource,
+		language,
+		&jsonapi.File{Path: path, Progress: progress},
+		args.Xliff,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -1,13 +1,19 @@
 package txlib
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/url"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/transifex/cli/internal/txlib/config"
+	"github.com/transifex/cli/pkg/assert"
 	"github.com/transifex/cli/pkg/jsonapi"
 )
 
@@ -25,7 +31,7 @@ func TestPushCommandResourceExists(t *testing.T) {
 	}
 	api := jsonapi.GetTestConnection(mockData)
 
-	err := PushCommand(getStandardConfig(), api, PushCommandArguments{
+	err := PushCommand(context.Background(), getStandardConfig(), api, PushCommandArguments{
 		Force: true, Branch: "-1", Workers: 1,
 	})
 	if err != nil {
@@ -53,7 +59,7 @@ func TestPushSpecificResource(t *testing.T) {
 	}
 	api := jsonapi.GetTestConnection(mockData)
 
-	err := PushCommand(getStandardConfig(), api, PushCommandArguments{
+	err := PushCommand(context.Background(), getStandardConfig(), api, PushCommandArguments{
 		Force:       true,
 		ResourceIds: []string{"projslug.resslug"},
 		Branch:      "-1",
@@ -85,7 +91,7 @@ func TestPushCommandResourceDoesNotExist(t *testing.T) {
 	}
 	api := jsonapi.GetTestConnection(mockData)
 
-	err := PushCommand(getStandardConfig(), api, PushCommandArguments{
+	err := PushCommand(context.Background(), getStandardConfig(), api, PushCommandArguments{
 		Force:   true,
 		Branch:  "-1",
 		Workers: 1,
@@ -133,7 +139,7 @@ func TestPushCommandBranchResourceDoesNotExist(t *testing.T) {
 	}
 	api := jsonapi.GetTestConnection(mockData)
 
-	err := PushCommand(getStandardConfig(), api, PushCommandArguments{
+	err := PushCommand(context.Background(), getStandardConfig(), api, PushCommandArguments{
 		Force:   true,
 		Branch:  "branch",
 		Base:    "-1",
@@ -178,7 +184,7 @@ func TestPushTranslation(t *testing.T) {
 	}
 	api := jsonapi.GetTestConnection(mockData)
 
-	err := PushCommand(getStandardConfig(), api, PushCommandArguments{
+	err := PushCommand(context.Background(), getStandardConfig(), api, PushCommandArguments{
 		Translation: true,
 		Force:       true,
 		Branch:      "-1",
@@ -223,7 +229,7 @@ func TestPushXliff(t *testing.T) {
 	}
 	api := jsonapi.GetTestConnection(mockData)
 
-	err = PushCommand(getStandardConfig(), api, PushCommandArguments{
+	err = PushCommand(context.Background(), getStandardConfig(), api, PushCommandArguments{
 		Translation: true,
 		Force:       true,
 		Xliff:       true,
@@ -260,7 +266,7 @@ func TestPushTranslationWithLanguageMapping(t *testing.T) {
 	}
 	api := jsonapi.GetTestConnection(mockData)
 
-	err := PushCommand(cfg, api, PushCommandArguments{
+	err := PushCommand(context.Background(), cfg, api, PushCommandArguments{
 		Translation: true,
 		Force:       true,
 		Branch:      "-1",
@@ -298,7 +304,7 @@ func TestPushTranslationWithOverrides(t *testing.T) {
 	}
 	api := jsonapi.GetTestConnection(mockData)
 
-	err := PushCommand(cfg, api, PushCommandArguments{
+	err := PushCommand(context.Background(), cfg, api, PushCommandArguments{
 		Translation: true,
 		Force:       true,
 		Branch:      "-1",
@@ -327,7 +333,7 @@ func TestPushTranslationRemoteLanguageDoesNotExist(t *testing.T) {
 	}
 	api := jsonapi.GetTestConnection(mockData)
 
-	err := PushCommand(getStandardConfig(), api, PushCommandArguments{
+	err := PushCommand(context.Background(), getStandardConfig(), api, PushCommandArguments{
 		Translation: true,
 		Force:       true,
 		Branch:      "-1",
@@ -358,7 +364,7 @@ func TestPushTranslationLocalFileIsOlderThanRemote(t *testing.T) {
 	}
 	api := jsonapi.GetTestConnection(mockData)
 
-	err := PushCommand(getStandardConfig(), api, PushCommandArguments{
+	err := PushCommand(context.Background(), getStandardConfig(), api, PushCommandArguments{
 		Translation: true,
 		Branch:      "-1",
 		Workers:     1,
@@ -388,7 +394,7 @@ func TestPushTranslationLocalFileIsNewerThanRemote(t *testing.T) {
 	}
 	api := jsonapi.GetTestConnection(mockData)
 
-	err := PushCommand(getStandardConfig(), api, PushCommandArguments{
+	err := PushCommand(context.Background(), getStandardConfig(), api, PushCommandArguments{
 		Translation: true,
 		Branch:      "-1",
 		Workers:     1,
@@ -418,7 +424,7 @@ func TestPushTranslationLimitLanguages(t *testing.T) {
 	}
 	api := jsonapi.GetTestConnection(mockData)
 
-	err := PushCommand(getStandardConfig(), api, PushCommandArguments{
+	err := PushCommand(context.Background(), getStandardConfig(), api, PushCommandArguments{
 		Translation: true,
 		Force:       true,
 		Languages:   []string{"el"},
@@ -445,6 +451,7 @@ func TestProjectNotFound(t *testing.T) {
 	}
 	api := jsonapi.GetTestConnection(mockData)
 	err := PushCommand(
+		context.Background(),
 		getStandardConfig(),
 		api,
 		PushCommandArguments{Branch: "-1", Workers: 1},
@@ -515,7 +522,7 @@ func TestPushCommandBranch(t *testing.T) {
 	}
 	api := jsonapi.GetTestConnection(mockData)
 
-	err := PushCommand(getStandardConfig(), api, PushCommandArguments{
+	err := PushCommand(context.Background(), getStandardConfig(), api, PushCommandArguments{
 		Force:   true,
 		Branch:  "branch",
 		Workers: 1,
@@ -592,7 +599,7 @@ func TestPushCommandChangeBase(t *testing.T) {
 	}
 	api := jsonapi.GetTestConnection(mockData)
 
-	err := PushCommand(getStandardConfig(), api, PushCommandArguments{
+	err := PushCommand(context.Background(), getStandardConfig(), api, PushCommandArguments{
 		Force:   true,
 		Branch:  "branch",
 		Workers: 1,
@@ -639,7 +646,7 @@ func TestPushNewLanguage(t *testing.T) {
 	}
 	api := jsonapi.GetTestConnection(mockData)
 
-	err := PushCommand(getStandardConfig(), api, PushCommandArguments{
+	err := PushCommand(context.Background(), getStandardConfig(), api, PushCommandArguments{
 		Branch:      "-1",
 		Force:       true,
 		Translation: true,
@@ -679,7 +686,7 @@ func TestPushAll(t *testing.T) {
 	}
 	api := jsonapi.GetTestConnection(mockData)
 
-	err := PushCommand(getStandardConfig(), api, PushCommandArguments{
+	err := PushCommand(context.Background(), getStandardConfig(), api, PushCommandArguments{
 		Branch:      "-1",
 		Force:       true,
 		Translation: true,
@@ -809,3 +816,396 @@ func testSimpleUpload(t *testing.T, mockData jsonapi.MockData, path string) {
 		t.Errorf("Something was wrong with the request '%+v'", actual)
 	}
 }
+
+func TestPushDryRun(t *testing.T) {
+	afterTest := beforeTest(t, []string{"el"}, nil)
+	defer afterTest()
+
+	mockData := jsonapi.MockData{
+		"/languages":          getLanguagesEndpoint([]string{"en", "fr", "el"}),
+		resourceUrl:           getResourceEndpoint(),
+		projectUrl:            getProjectEndpoint(),
+		statsUrlAllLanguages:  getStatsEndpointAllLanguages(),
+		sourceDownloadsUrl:    getSourceDownloadsEndpoint(),
+		sourceUploadsUrl:      getSourceUploadPostEndpoint(),
+		translationUploadsUrl: getTranslationUploadPostEndpoint(),
+	}
+	api := jsonapi.GetTestConnection(mockData)
+
+	err := PushCommand(context.Background(), getStandardConfig(), api, PushCommandArguments{
+		Source:      true,
+		Translation: true,
+		Force:       true,
+		Branch:      "-1",
+		Workers:     1,
+		DryRun:      true,
+	})
+	if err != nil {
+		t.Error(err)
+	}
+
+	testSimpleGet(t, mockData, resourceUrl)
+	testSimpleGet(t, mockData, projectUrl)
+	testSimpleGet(t, mockData, statsUrlAllLanguages)
+	if mockData[sourceDownloadsUrl].Count != 0 {
+		t.Error("Remote strings were downloaded during a dry run")
+	}
+	if mockData[sourceUploadsUrl].Count != 0 {
+		t.Error("Source file was uploaded during a dry run")
+	}
+	if mockData[translationUploadsUrl].Count != 0 {
+		t.Error("Translation file was uploaded during a dry run")
+	}
+}
+
+func TestPushDryRunResourceDoesNotExist(t *testing.T) {
+	afterTest := beforeTest(t, nil, nil)
+	defer afterTest()
+
+	mockData := jsonapi.MockData{
+		"/languages":     getLanguagesEndpoint([]string{"en", "fr", "el"}),
+		resourceUrl:      getEmptyEndpoint(),
+		projectUrl:       getProjectEndpoint(),
+		resourcesUrl:     getResourceCreatedEndpoint(),
+		sourceUploadsUrl: getSourceUploadPostEndpoint(),
+	}
+	api := jsonapi.GetTestConnection(mockData)
+
+	err := PushCommand(context.Background(), getStandardConfig(), api, PushCommandArguments{
+		Branch:  "-1",
+		Workers: 1,
+		DryRun:  true,
+	})
+	if err != nil {
+		t.Error(err)
+	}
+
+	testSimpleGet(t, mockData, resourceUrl)
+	testSimpleGet(t, mockData, projectUrl)
+	if mockData[resourcesUrl].Count != 0 {
+		t.Error("Resource was created during a dry run")
+	}
+	if mockData[sourceUploadsUrl].Count != 0 {
+		t.Error("Source file was uploaded during a dry run")
+	}
+}
+
+func TestPushReport(t *testing.T) {
+	afterTest := beforeTest(t, nil, nil)
+	defer afterTest()
+
+	mockData := jsonapi.MockData{
+		"/languages":           getLanguagesEndpoint([]string{"en", "fr", "el"}),
+		resourceUrl:            getResourceEndpoint(),
+		projectUrl:             getProjectEndpoint(),
+		statsUrlSourceLanguage: getStatsEndpointSourceLanguage(),
+		sourceUploadsUrl:       getSourceUploadPostEndpoint(),
+		"/resource_strings_async_uploads/upload_1": jsonapi.GetMockTextResponse(
+			`{"data": {"type": "resource_strings_async_uploads",
+			           "id": "upload_1",
+			           "attributes": {"status": "succeeded",
+			                          "details": {"strings_created": 3,
+			                                      "strings_updated": 1}}}}`,
+		),
+	}
+	api := jsonapi.GetTestConnection(mockData)
+
+	err := PushCommand(context.Background(), getStandardConfig(), api, PushCommandArguments{
+		Force: true, Branch: "-1", Workers: 1, ReportPath: "report.json",
+	})
+	if err != nil {
+		t.Errorf("%s", err)
+	}
+
+	data, err := os.ReadFile("report.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report Report
+	err = json.Unmarshal(data, &report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, report.Command, "push")
+	assert.Equal(t, report.Failed, false)
+	assert.Equal(t, len(report.Outcomes), 1)
+	outcome := report.Outcomes[0]
+	assert.Equal(t, outcome.Resource, "projslug.resslug")
+	assert.Equal(t, outcome.Status, OutcomePushed)
+	assert.Equal(t, outcome.Details["strings_created"], 3)
+	assert.Equal(t, outcome.Details["strings_updated"], 1)
+}
+
+func TestPushSkipWithFailures(t *testing.T) {
+	afterTest := beforeTest(t, nil, nil)
+	defer afterTest()
+
+	// The source upload endpoint is missing so the upload will fail
+	mockData := jsonapi.MockData{
+		"/languages":           getLanguagesEndpoint([]string{"en", "fr", "el"}),
+		resourceUrl:            getResourceEndpoint(),
+		projectUrl:             getProjectEndpoint(),
+		statsUrlSourceLanguage: getStatsEndpointSourceLanguage(),
+	}
+	api := jsonapi.GetTestConnection(mockData)
+
+	err := PushCommand(context.Background(), getStandardConfig(), api, PushCommandArguments{
+		Force: true, Skip: true, Branch: "-1", Workers: 1,
+	})
+
+	var tasksFailedError *TasksFailedError
+	if !errors.As(err, &tasksFailedError) {
+		t.Fatalf("Expected a TasksFailedError, got %v", err)
+	}
+	assert.Equal(t, tasksFailedError.Failed, 1)
+}
+
+func TestPushInterrupted(t *testing.T) {
+	afterTest := beforeTest(t, nil, nil)
+	defer afterTest()
+
+	mockData := jsonapi.MockData{
+		resourceUrl: getResourceEndpoint(),
+	}
+	api := jsonapi.GetTestConnection(mockData)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := PushCommand(ctx, getStandardConfig(), api, PushCommandArguments{
+		Force: true, Branch: "-1", Workers: 1, ReportPath: "report.json",
+	})
+	if !errors.Is(err, ErrInterrupted) {
+		t.Errorf("Expected ErrInterrupted, got %v", err)
+	}
+	assert.Equal(t, mockData[resourceUrl].Count, 0)
+
+	data, err := os.ReadFile("report.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report Report
+	err = json.Unmarshal(data, &report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, len(report.Outcomes), 1)
+	assert.Equal(t, report.Outcomes[0].Resource, "projslug.resslug")
+	assert.Equal(t, report.Outcomes[0].Status, OutcomeCancelled)
+	assert.Equal(t, report.Outcomes[0].Reason, "interrupted")
+}
+
+func TestPushSkipsUnchangedFiles(t *testing.T) {
+	afterTest := beforeTest(t, nil, nil)
+	defer afterTest()
+
+	cfg := getStandardConfig()
+	cfg.Local.Path = filepath.Join(".tx", "config")
+
+	mockData := jsonapi.MockData{
+		"/languages":           getLanguagesEndpoint([]string{"en", "fr", "el"}),
+		resourceUrl:            getResourceEndpoint(),
+		projectUrl:             getProjectEndpoint(),
+		statsUrlSourceLanguage: getStatsEndpointSourceLanguage(),
+		sourceUploadsUrl:       getSourceUploadPostEndpoint(),
+		"/resource_strings_async_uploads/upload_1": getSourceUploadGetEndpoint(),
+	}
+	api := jsonapi.GetTestConnection(mockData)
+
+	err := PushCommand(context.Background(), cfg, api, PushCommandArguments{
+		Force: true, Branch: "-1", Workers: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testSimpleUpload(t, mockData, sourceUploadsUrl)
+
+	state, err := LoadState(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resourceState := state.Resources["o:orgslug:p:projslug:r:resslug"]
+	if resourceState == nil || resourceState.Source == nil {
+		t.Fatalf("Source file was not recorded in the state")
+	}
+	assert.Equal(t, resourceState.Source.Path, "aaa.json")
+
+	// Nothing has changed, so the second push should not upload anything
+	mockData = jsonapi.MockData{
+		"/languages":           getLanguagesEndpoint([]string{"en", "fr", "el"}),
+		resourceUrl:            getResourceEndpoint(),
+		projectUrl:             getProjectEndpoint(),
+		statsUrlSourceLanguage: getStatsEndpointSourceLanguage(),
+	}
+	api = jsonapi.GetTestConnection(mockData)
+
+	err = PushCommand(context.Background(), cfg, api, PushCommandArguments{
+		Branch: "-1", Workers: 1, ReportPath: "report.json",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile("report.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report Report
+	err = json.Unmarshal(data, &report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, len(report.Outcomes), 1)
+	assert.Equal(t, report.Outcomes[0].Status, OutcomeSkipped)
+	assert.Equal(
+		t,
+		report.Outcomes[0].Reason,
+		"file has not changed since last push or pull",
+	)
+}
+
+func TestPushRefusesMassDeletion(t *testing.T) {
+	afterTest := beforeTest(t, nil, nil)
+	defer afterTest()
+
+	err := os.WriteFile("aaa.json", []byte(`{"a": "1"}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := getNewTestServer(`{"a": "1", "b": "2", "c": "3"}`)
+	defer ts.Close()
+
+	getMockData := func() jsonapi.MockData {
+		return jsonapi.MockData{
+			"/languages": getLanguagesEndpoint(
+				[]string{"en", "fr", "el"},
+			),
+			resourceUrl:            getResourceEndpoint(),
+			projectUrl:             getProjectEndpoint(),
+			statsUrlSourceLanguage: getStatsEndpointSourceLanguage(),
+			sourceDownloadsUrl:     getSourceDownloadsEndpoint(),
+			sourceDownloadUrl:      getDownloadEndpoint(ts.URL),
+			sourceUploadsUrl:       getSourceUploadPostEndpoint(),
+			"/resource_strings_async_uploads/upload_1": getSourceUploadGetEndpoint(),
+		}
+	}
+	cfg := getStandardConfig()
+	cfg.Local.Resources[0].Type = "KEYVALUEJSON"
+
+	// 2 out of 3 strings would be deleted
+	mockData := getMockData()
+	api := jsonapi.GetTestConnection(mockData)
+	err = PushCommand(context.Background(), cfg, api, PushCommandArguments{
+		Force: true, Branch: "-1", Workers: 1,
+	})
+	assert.True(t, err != nil)
+	assert.Equal(t, mockData[sourceUploadsUrl].Count, 0)
+
+	// --allow-deletions
+	mockData = getMockData()
+	api = jsonapi.GetTestConnection(mockData)
+	err = PushCommand(context.Background(), cfg, api, PushCommandArguments{
+		Force: true, Branch: "-1", Workers: 1, AllowDeletions: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, mockData[sourceDownloadsUrl].Count, 0)
+	testSimpleUpload(t, mockData, sourceUploadsUrl)
+
+	// max_deletions in the configuration
+	maxDeletions := 2
+	cfg.Local.Resources[0].MaxDeletions = &maxDeletions
+	mockData = getMockData()
+	api = jsonapi.GetTestConnection(mockData)
+	err = PushCommand(context.Background(), cfg, api, PushCommandArguments{
+		Force: true, Branch: "-1", Workers: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testSimpleGet(t, mockData, sourceDownloadUrl)
+	testSimpleUpload(t, mockData, sourceUploadsUrl)
+}
+
+func TestPushRefusesMassDeletionWithoutLimits(t *testing.T) {
+	afterTest := beforeTest(t, nil, nil)
+	defer afterTest()
+
+	err := os.WriteFile("aaa.json", []byte(`{"a": "1", "b": "2"}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	push := func(remote string) (jsonapi.MockData, error) {
+		ts := getNewTestServer(remote)
+		defer ts.Close()
+		mockData := jsonapi.MockData{
+			"/languages": getLanguagesEndpoint(
+				[]string{"en", "fr", "el"},
+			),
+			resourceUrl:            getResourceEndpoint(),
+			projectUrl:             getProjectEndpoint(),
+			statsUrlSourceLanguage: getStatsEndpointSourceLanguage(),
+			sourceDownloadsUrl:     getSourceDownloadsEndpoint(),
+			sourceDownloadUrl:      getDownloadEndpoint(ts.URL),
+			sourceUploadsUrl:       getSourceUploadPostEndpoint(),
+			"/resource_strings_async_uploads/upload_1": getSourceUploadGetEndpoint(),
+		}
+		cfg := getStandardConfig()
+		cfg.Local.Resources[0].Type = "KEYVALUEJSON"
+		api := jsonapi.GetTestConnection(mockData)
+		err := PushCommand(context.Background(), cfg, api, PushCommandArguments{
+			Force: true, Branch: "-1", Workers: 1,
+		})
+		return mockData, err
+	}
+
+	// 1 out of 3 strings would be deleted, more than the 25% default
+	mockData, err := push(`{"a": "1", "b": "2", "c": "3"}`)
+	assert.True(t, err != nil)
+	testSimpleGet(t, mockData, sourceDownloadUrl)
+	assert.Equal(t, mockData[sourceUploadsUrl].Count, 0)
+
+	// 1 out of 4 strings would be deleted, which the 25% default allows
+	err = os.WriteFile(
+		"aaa.json", []byte(`{"a": "1", "b": "2", "c": "3"}`), 0644,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mockData, err = push(`{"a": "1", "b": "2", "c": "3", "d": "4"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testSimpleGet(t, mockData, sourceDownloadUrl)
+	testSimpleUpload(t, mockData, sourceUploadsUrl)
+}
+
+func TestCheckDeletions(t *testing.T) {
+	var deleted []string
+	for i := 0; i < 30; i++ {
+		deleted = append(deleted, fmt.Sprintf("key%02d", i))
+	}
+	diff := StringsDiff{Deleted: deleted, Unchanged: 70}
+
+	err := checkDeletions(diff, &config.Resource{})
+	if err == nil {
+		t.Fatal("Expected deleting 30% of the strings to be refused")
+	}
+	assert.True(t, strings.Contains(err.Error(), "would delete 30 of 100"))
+	assert.True(t, strings.Contains(err.Error(), "key00, key01"))
+	assert.True(t, strings.Contains(err.Error(), "key19, and 10 more"))
+	assert.True(t, !strings.Contains(err.Error(), "key20"))
+
+	maxDeletionPercent := 30
+	err = checkDeletions(
+		diff, &config.Resource{MaxDeletionPercent: &maxDeletionPercent},
+	)
+	assert.True(t, err == nil)
+
+	maxDeletions := 29
+	err = checkDeletions(diff, &config.Resource{
+		MaxDeletions: &maxDeletions, MaxDeletionPercent: &maxDeletionPercent,
+	})
+	assert.True(t, err != nil)
+}
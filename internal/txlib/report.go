@@ -0,0 +1,289 @@
+package txlib
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/fatih/color"
+	"github.com/transifex/cli/pkg/worker_pool"
+)
+
+const (
+	OutcomePushed    = "pushed"
+	OutcomePulled    = "pulled"
+	OutcomeSkipped   = "skipped"
+	OutcomeFailed    = "failed"
+	OutcomePlanned   = "planned"
+	OutcomeCancelled = "cancelled"
+)
+
+// Returned by commands that were stopped by the user (eg with Ctrl-C)
+var ErrInterrupted = errors.New("Interrupted")
+
+/*
+TaskOutcome
+What happened to a single file (or resource, if the task failed before any
+files could be figured out) during a push or a pull.
+*/
+type TaskOutcome struct {
+	Resource string         `json:"resource"`
+	Language string         `json:"language,omitempty"`
+	Path     string         `json:"path,omitempty"`
+	Status   string         `json:"status"`
+	Reason   string         `json:"reason,omitempty"`
+	Error    string         `json:"error,omitempty"`
+	Details  map[string]int `json:"details,omitempty"`
+}
+
+/*
+Report
+Collects the outcomes of the tasks of a command. It is safe to add outcomes
+from multiple workers at the same time.
+*/
+type Report struct {
+	Command  string        `json:"command"`
+	Outcomes []TaskOutcome `json:"outcomes"`
+	Failed   bool          `json:"failed"`
+
+	mutex sync.Mutex
+}
+
+func NewReport(command string) *Report {
+	return &Report{Command: command, Outcomes: []TaskOutcome{}}
+}
+
+func (report *Report) Add(outcome TaskOutcome) {
+	if report == nil {
+		return
+	}
+	report.mutex.Lock()
+	defer report.mutex.Unlock()
+	report.Outcomes = append(report.Outcomes, outcome)
+	if outcome.Status == OutcomeFailed {
+		report.Failed = true
+	}
+}
+
+/*
+Record that the task described by 'outcome' failed with 'err', or that it did
+not complete if 'ctx' was cancelled in the meantime (in which case 'err' is
+most likely a consequence of the cancellation).
+*/
+func (report *Report) addFailure(
+	ctx context.Context, outcome TaskOutcome, err string,
+) {
+	if ctx.Err() != nil {
+		outcome.Status = OutcomeCancelled
+		outcome.Reason = "interrupted"
+	} else {
+		outcome.Status = OutcomeFailed
+		outcome.Error = err
+	}
+	report.Add(outcome)
+}
+
+func (report *Report) Count(status string) int {
+	count := 0
+	for _, outcome := range report.Outcomes {
+		if outcome.Status == status {
+			count++
+		}
+	}
+	return count
+}
+
+func (report *Report) sortOutcomes() {
+	sort.SliceStable(report.Outcomes, func(i, j int) bool {
+		left := report.Outcomes[i]
+		right := report.Outcomes[j]
+		if left.Resource != right.Resource {
+			return left.Resource < right.Resource
+		}
+		return left.Language < right.Language
+	})
+}
+
+/*
+Print an aggregated summary of the outcomes: how many files were
+pushed/pulled, skipped and failed, the totals of the upload details, the
+list of failures and the list of tasks that did not complete.
+*/
+func (report *Report) Print() {
+	report.mutex.Lock()
+	defer report.mutex.Unlock()
+	report.sortOutcomes()
+
+	counts := report.describeCounts()
+	if counts == "" {
+		return
+	}
+	fmt.Print("\n# Summary\n\n")
+	fmt.Println(counts)
+	details := report.describeDetails()
+	if details != "" {
+		fmt.Println(details)
+	}
+
+	if report.Failed {
+		red := color.New(color.FgRed).SprintFunc()
+		fmt.Println(red("Failures:"))
+		for _, outcome := range report.Outcomes {
+			if outcome.Status != OutcomeFailed {
+				continue
+			}
+			fmt.Println(red(fmt.Sprintf(
+				"- %s: %s", outcome.describe(), outcome.Error,
+			)))
+		}
+	}
+
+	if report.Count(OutcomeCancelled) > 0 {
+		yellow := color.New(color.FgYellow).SprintFunc()
+		fmt.Println(yellow("Did not complete:"))
+		for _, outcome := range report.Outcomes {
+			if outcome.Status != OutcomeCancelled {
+				continue
+			}
+			fmt.Println(yellow(fmt.Sprintf(
+				"- %s (%s)", outcome.describe(), outcome.Reason,
+			)))
+		}
+	}
+}
+
+// Eg "pushed: 2, failed: 1"; empty if there are no outcomes
+func (report *Report) describeCounts() string {
+	var counts []string
+	for _, status := range []string{
+		OutcomePushed, OutcomePulled, OutcomePlanned, OutcomeSkipped,
+		OutcomeFailed, OutcomeCancelled,
+	} {
+		count := report.Count(status)
+		if count > 0 {
+			counts = append(counts, fmt.Sprintf("%s: %d", status, count))
+		}
+	}
+	return strings.Join(counts, ", ")
+}
+
+// The totals of the upload details, eg "strings created: 3, strings deleted: 1"
+func (report *Report) describeDetails() string {
+	details := make(map[string]int)
+	for _, outcome := range report.Outcomes {
+		for key, value := range outcome.Details {
+			details[key] += value
+		}
+	}
+	var keys []string
+	for key := range details {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, key := range keys {
+		parts = append(parts, fmt.Sprintf(
+			"%s: %d", strings.ReplaceAll(key, "_", " "), details[key],
+		))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (outcome *TaskOutcome) describe() string {
+	if outcome.Language == "" {
+		return outcome.Resource
+	}
+	return fmt.Sprintf("%s [%s]", outcome.Resource, outcome.Language)
+}
+
+/*
+Save the report as JSON in 'path'.
+*/
+func (report *Report) Save(path string) error {
+	report.mutex.Lock()
+	defer report.mutex.Unlock()
+	report.sortOutcomes()
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+/*
+TasksFailedError
+Returned by push and pull when the command ran to completion (because of the
+'--skip' flag) but some of its tasks failed, and by status when the status of
+some resources could not be fetched.
+*/
+type TasksFailedError struct {
+	Failed int
+}
+
+func (err *TasksFailedError) Error() string {
+	return fmt.Sprintf("%d task(s) failed", err.Failed)
+}
+
+/*
+Print the summary, save the report to 'reportPath' if set and turn the
+command's error into a TasksFailedError if some of the tasks failed even though
+the command itself didn't.
+*/
+func finishReport(report *Report, reportPath string, err error) error {
+	report.Print()
+	if reportPath != "" {
+		saveErr := report.Save(reportPath)
+		if saveErr != nil {
+			if err != nil {
+				return err
+			}
+			return fmt.Errorf("could not save report: %w", saveErr)
+		}
+	}
+	if err == nil && report.Failed {
+		return &TasksFailedError{Failed: report.Count(OutcomeFailed)}
+	}
+	return err
+}
+
+/*
+Tasks that can describe which resource/language/file they are about, so that
+they can be reported even if they never ran
+*/
+type reportableTask interface {
+	outcome() TaskOutcome
+}
+
+/*
+Record the tasks of a finished pool that never ran and turn the pool's state
+into the error the command should return, if any.
+*/
+func checkPool(pool *worker_pool.Pool, report *Report) error {
+	reason := "aborted"
+	if pool.IsCancelled {
+		reason = "interrupted"
+	}
+	for _, task := range pool.NotStarted() {
+		reportable, ok := task.(reportableTask)
+		if !ok {
+			continue
+		}
+		outcome := reportable.outcome()
+		outcome.Status = OutcomeCancelled
+		outcome.Reason = reason
+		report.Add(outcome)
+	}
+	if pool.IsCancelled {
+		return ErrInterrupted
+	}
+	if pool.IsAborted {
+		return errors.New("Aborted")
+	}
+	return nil
+}
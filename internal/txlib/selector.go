@@ -0,0 +1,270 @@
+package txlib
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/transifex/cli/internal/txlib/config"
+)
+
+/*
+resourcePattern
+One way of selecting resources from the local configuration:
+
+  - 'project.resource', where both parts may contain the '*', '?' and '[...]'
+    wildcards, eg 'web.*' or '*.emails-*'
+  - 'o:organization', 'o:organization:p:project' or
+    'o:organization:p:project:r:resource' (also with wildcards) to select
+    whole organizations or projects
+  - 're:<regular expression>', matched against 'project.resource'
+
+A leading '!' turns the pattern into an exclusion.
+*/
+type resourcePattern struct {
+	text    string
+	exclude bool
+	match   func(*config.Resource) bool
+}
+
+func parseResourcePattern(text string) (*resourcePattern, error) {
+	pattern := &resourcePattern{text: text}
+	if strings.HasPrefix(text, "!") {
+		pattern.exclude = true
+		text = text[1:]
+	}
+	if text == "" {
+		return nil, fmt.Errorf("empty resource pattern '%s'", pattern.text)
+	}
+
+	switch {
+	case strings.HasPrefix(text, "re:"):
+		expression, err := regexp.Compile(text[len("re:"):])
+		if err != nil {
+			return nil, fmt.Errorf(
+				"invalid regular expression in '%s': %s", pattern.text, err,
+			)
+		}
+		pattern.match = func(resource *config.Resource) bool {
+			return expression.MatchString(
+				fmt.Sprintf("%s.%s", resource.ProjectSlug, resource.ResourceSlug),
+			)
+		}
+
+	case strings.HasPrefix(text, "o:"):
+		parts := strings.Split(text, ":")
+		valid := len(parts) == 2 ||
+			(len(parts) == 4 && parts[2] == "p") ||
+			(len(parts) == 6 && parts[2] == "p" && parts[4] == "r")
+		if !valid {
+			return nil, fmt.Errorf(
+				"invalid resource pattern '%s', expected 'o:<organization>', "+
+					"'o:<organization>:p:<project>' or "+
+					"'o:<organization>:p:<project>:r:<resource>'",
+				pattern.text,
+			)
+		}
+		for i := 1; i < len(parts); i += 2 {
+			_, err := path.Match(parts[i], "")
+			if err != nil {
+				return nil, fmt.Errorf(
+					"invalid resource pattern '%s': %s", pattern.text, err,
+				)
+			}
+		}
+		pattern.match = func(resource *config.Resource) bool {
+			slugs := []string{
+				resource.OrganizationSlug,
+				resource.ProjectSlug,
+				resource.ResourceSlug,
+			}
+			for i := 1; i < len(parts); i += 2 {
+				matched, _ := path.Match(parts[i], slugs[i/2])
+				if !matched {
+					return false
+				}
+			}
+			return true
+		}
+
+	default:
+		_, err := path.Match(text, "")
+		if err != nil {
+			return nil, fmt.Errorf(
+				"invalid resource pattern '%s': %s", pattern.text, err,
+			)
+		}
+		pattern.match = func(resource *config.Resource) bool {
+			matched, _ := path.Match(
+				text,
+				fmt.Sprintf("%s.%s", resource.ProjectSlug, resource.ResourceSlug),
+			)
+			return matched
+		}
+	}
+	return pattern, nil
+}
+
+/*
+resourceSelector
+Selects resources with the union of its inclusion patterns (or all resources,
+if there are none) minus the ones that match any of its exclusion patterns.
+If tags are given, the resources also need to have at least one of them, and
+none of the excluded tags.
+*/
+type resourceSelector struct {
+	include     []*resourcePattern
+	exclude     []*resourcePattern
+	tags        []string
+	excludeTags []string
+}
+
+/*
+Build a selector out of the patterns given as arguments ('resourceIds', where
+patterns starting with '!' are exclusions), the ones given to
+'--exclude-resources' ('excludeResourceIds') and the tags given to '--tag' and
+'--exclude-tag'
+*/
+func newResourceSelector(
+	resourceIds, excludeResourceIds, tags, excludeTags []string,
+) (*resourceSelector, error) {
+	selector := &resourceSelector{
+		tags:        config.ParseTags(strings.Join(tags, ",")),
+		excludeTags: config.ParseTags(strings.Join(excludeTags, ",")),
+	}
+	for _, text := range resourceIds {
+		pattern, err := parseResourcePattern(text)
+		if err != nil {
+			return nil, err
+		}
+		if pattern.exclude {
+			selector.exclude = append(selector.exclude, pattern)
+		} else {
+			selector.include = append(selector.include, pattern)
+		}
+	}
+	for _, text := range excludeResourceIds {
+		pattern, err := parseResourcePattern(strings.TrimPrefix(text, "!"))
+		if err != nil {
+			return nil, err
+		}
+		selector.exclude = append(selector.exclude, pattern)
+	}
+	return selector, nil
+}
+
+/*
+Return the selected resources, in the order of the configuration, along with
+the inclusion patterns that didn't match any resource
+*/
+func (selector *resourceSelector) apply(
+	cfg *config.Config,
+) ([]*config.Resource, []string) {
+	matchedPatterns := make(map[*resourcePattern]bool)
+	var result []*config.Resource
+	for i := range cfg.Local.Resources {
+		resource := &cfg.Local.Resources[i]
+		included := len(selector.include) == 0
+		for _, pattern := range selector.include {
+			if pattern.match(resource) {
+				matchedPatterns[pattern] = true
+				included = true
+			}
+		}
+		if !included || !selector.matchesTags(resource) {
+			continue
+		}
+		excluded := false
+		for _, pattern := range selector.exclude {
+			if pattern.match(resource) {
+				excluded = true
+				break
+			}
+		}
+		if !excluded {
+			result = append(result, resource)
+		}
+	}
+
+	var unmatched []string
+	for _, pattern := range selector.include {
+		if !matchedPatterns[pattern] {
+			unmatched = append(unmatched, pattern.text)
+		}
+	}
+	return result, unmatched
+}
+
+func (selector *resourceSelector) matchesTags(resource *config.Resource) bool {
+	for _, tag := range selector.excludeTags {
+		if resource.HasTag(tag) {
+			return false
+		}
+	}
+	if len(selector.tags) == 0 {
+		return true
+	}
+	for _, tag := range selector.tags {
+		if resource.HasTag(tag) {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+Return the included tags that no resource of the configuration has, which are
+most likely typos. Excluding a tag nobody has is fine, like excluding a
+resource that doesn't exist.
+*/
+func (selector *resourceSelector) unknownTags(cfg *config.Config) []string {
+	var result []string
+	for _, tag := range selector.tags {
+		known := false
+		for i := range cfg.Local.Resources {
+			if cfg.Local.Resources[i].HasTag(tag) {
+				known = true
+				break
+			}
+		}
+		if !known {
+			result = append(result, tag)
+		}
+	}
+	return result
+}
+
+/*
+Return the resources of the local configuration selected by 'resourceIds',
+'excludeResourceIds', 'tags' and 'excludeTags' (see 'resourceSelector'). It is
+an error if a pattern of 'resourceIds' doesn't match any resource or if no
+resource has one of the tags.
+*/
+func selectResources(
+	resourceIds, excludeResourceIds, tags, excludeTags []string,
+	cfg *config.Config,
+) ([]*config.Resource, error) {
+	selector, err := newResourceSelector(
+		resourceIds, excludeResourceIds, tags, excludeTags,
+	)
+	if err != nil {
+		return nil, err
+	}
+	unknownTags := selector.unknownTags(cfg)
+	if len(unknownTags) > 0 {
+		return nil, fmt.Errorf(
+			"no resource in local configuration has the tag '%s'",
+			unknownTags[0],
+		)
+	}
+	result, unmatched := selector.apply(cfg)
+	if len(unmatched) > 0 {
+		return nil, fmt.Errorf(
+			"could not find resource '%s' in local configuration or your "+
+				"resource slug is invalid",
+			unmatched[0],
+		)
+	}
+	return result, nil
+}
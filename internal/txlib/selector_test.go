@@ -0,0 +1,130 @@
+package txlib
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/transifex/cli/internal/txlib/config"
+	"github.com/transifex/cli/pkg/assert"
+)
+
+func getSelectorTestConfig() *config.Config {
+	return &config.Config{Local: &config.LocalConfig{
+		Resources: []config.Resource{
+			{
+				OrganizationSlug: "acme", ProjectSlug: "web", ResourceSlug: "ui",
+				Tags: []string{"web"},
+			},
+			{
+				OrganizationSlug: "acme", ProjectSlug: "web",
+				ResourceSlug: "emails-v1", Tags: []string{"web", "legacy"},
+			},
+			{
+				OrganizationSlug: "acme", ProjectSlug: "mobile", ResourceSlug: "ui",
+				Tags: []string{"mobile", "ios"},
+			},
+			{
+				OrganizationSlug: "acme", ProjectSlug: "mobile",
+				ResourceSlug: "emails-v2", Tags: []string{"mobile"},
+			},
+			{OrganizationSlug: "other", ProjectSlug: "docs", ResourceSlug: "guide"},
+		},
+	}}
+}
+
+func selectedIds(resources []*config.Resource) string {
+	var result []string
+	for _, resource := range resources {
+		result = append(
+			result, resource.ProjectSlug+"."+resource.ResourceSlug,
+		)
+	}
+	return strings.Join(result, " ")
+}
+
+func TestSelectResources(t *testing.T) {
+	cfg := getSelectorTestConfig()
+
+	test := func(resourceIds, excludeResourceIds []string, expected string) {
+		t.Helper()
+		result, err := selectResources(
+			resourceIds, excludeResourceIds, nil, nil, cfg,
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, selectedIds(result), expected)
+	}
+
+	all := "web.ui web.emails-v1 mobile.ui mobile.emails-v2 docs.guide"
+	test(nil, nil, all)
+	test([]string{"web.*"}, nil, "web.ui web.emails-v1")
+	test([]string{"*.emails-*"}, nil, "web.emails-v1 mobile.emails-v2")
+	test([]string{"*.emails-v?"}, nil, "web.emails-v1 mobile.emails-v2")
+	test([]string{"*.ui", "web.*"}, nil, "web.ui web.emails-v1 mobile.ui")
+	test([]string{`re:^(web|docs)\.`}, nil, "web.ui web.emails-v1 docs.guide")
+	test([]string{"re:v[0-9]$"}, nil, "web.emails-v1 mobile.emails-v2")
+
+	// Whole organizations and projects
+	test([]string{"o:other"}, nil, "docs.guide")
+	test([]string{"o:acme:p:mobile"}, nil, "mobile.ui mobile.emails-v2")
+	test([]string{"o:*:p:*:r:ui"}, nil, "web.ui mobile.ui")
+
+	// Exclusions
+	test([]string{"web.*"}, []string{"web.emails-*"}, "web.ui")
+	test([]string{"o:acme", "!*.ui"}, nil, "web.emails-v1 mobile.emails-v2")
+	test([]string{"!o:acme"}, nil, "docs.guide")
+	test(nil, []string{"re:emails", "docs.guide"}, "web.ui mobile.ui")
+}
+
+func TestSelectResourcesErrors(t *testing.T) {
+	cfg := getSelectorTestConfig()
+
+	for _, resourceIds := range [][]string{
+		{"web.missing"},
+		{"web.*", "o:nobody"},
+		{"re:("},
+		{"web.[a"},
+		{"o:acme:x:web"},
+		{"!"},
+	} {
+		result, err := selectResources(resourceIds, nil, nil, nil, cfg)
+		if err == nil {
+			t.Errorf("Did not get error with %v", resourceIds)
+		}
+		assert.True(t, result == nil)
+	}
+
+	// Excluding something that doesn't exist is fine
+	result, err := selectResources(
+		nil, []string{"web.missing"}, nil, nil, cfg,
+	)
+	assert.True(t, err == nil)
+	assert.Equal(t, len(result), 5)
+}
+
+func TestSelectResourcesByTag(t *testing.T) {
+	cfg := getSelectorTestConfig()
+
+	test := func(resourceIds, tags, excludeTags []string, expected string) {
+		t.Helper()
+		result, err := selectResources(resourceIds, nil, tags, excludeTags, cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, selectedIds(result), expected)
+	}
+
+	test(nil, []string{"mobile"}, nil, "mobile.ui mobile.emails-v2")
+	test(nil, []string{"ios", "legacy"}, nil, "web.emails-v1 mobile.ui")
+	test(nil, []string{"web,ios"}, nil, "web.ui web.emails-v1 mobile.ui")
+	test(nil, nil, []string{"legacy", "mobile"}, "web.ui docs.guide")
+	test(nil, []string{"web"}, []string{"legacy"}, "web.ui")
+	test([]string{"*.ui"}, []string{"mobile"}, nil, "mobile.ui")
+	// Excluding a tag that no resource has is fine
+	test(nil, nil, []string{"missing"}, "web.ui web.emails-v1 mobile.ui "+
+		"mobile.emails-v2 docs.guide")
+
+	_, err := selectResources(nil, nil, []string{"mobil"}, nil, cfg)
+	assert.True(t, err != nil)
+}
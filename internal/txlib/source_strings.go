@@ -0,0 +1,424 @@
+package txlib
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"gopkg.in/yaml.v3"
+)
+
+/*
+Return the parser for the source strings of files of the 'i18nType' resource
+type, or of files with the extension of 'path' if the type is not known. The
+parsers return the strings of a file by key; the values are only used to
+figure out whether a string was updated.
+*/
+func getSourceStringsParser(
+	i18nType, path string,
+) (func([]byte) (map[string]string, error), error) {
+	switch strings.ToUpper(i18nType) {
+	case "PO":
+		return parsePoStrings, nil
+	case "KEYVALUEJSON", "CHROME":
+		return parseJsonStrings, nil
+	case "STRUCTURED_JSON":
+		return parseStructuredJsonStrings, nil
+	case "YML", "YML_KEY", "YML_GENERIC", "YAML_GENERIC":
+		return parseYamlStrings, nil
+	case "ANDROID":
+		return parseAndroidStrings, nil
+	case "STRINGS":
+		return parseAppleStrings, nil
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".po", ".pot":
+		return parsePoStrings, nil
+	case ".json":
+		return parseJsonStrings, nil
+	case ".yml", ".yaml":
+		return parseYamlStrings, nil
+	case ".xml":
+		return parseAndroidStrings, nil
+	case ".strings":
+		return parseAppleStrings, nil
+	}
+	return nil, fmt.Errorf(
+		"comparing files of type '%s' is not supported", i18nType,
+	)
+}
+
+/*
+PO: strings are identified by their msgctxt and msgid
+*/
+func parsePoStrings(data []byte) (map[string]string, error) {
+	result := make(map[string]string)
+	var context, msgid, msgidPlural string
+	var current *string
+	inEntry := false
+
+	flush := func() {
+		if inEntry && msgid != "" {
+			key := msgid
+			if context != "" {
+				key = context + " | " + msgid
+			}
+			result[key] = msgidPlural
+		}
+		context, msgid, msgidPlural = "", "", ""
+		current = nil
+		inEntry = false
+	}
+
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			flush()
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			// Comments and obsolete entries
+			continue
+		}
+		keyword := line
+		value := ""
+		if !strings.HasPrefix(line, `"`) {
+			parts := strings.SplitN(line, " ", 2)
+			keyword = parts[0]
+			if len(parts) == 2 {
+				value = strings.TrimSpace(parts[1])
+			}
+		}
+		switch {
+		case strings.HasPrefix(line, `"`):
+			if current == nil {
+				return nil, fmt.Errorf("line %d: unexpected string", i+1)
+			}
+			value = line
+		case keyword == "msgctxt":
+			if msgid != "" {
+				flush()
+			}
+			inEntry = true
+			current = &context
+		case keyword == "msgid":
+			if msgid != "" {
+				flush()
+			}
+			inEntry = true
+			current = &msgid
+		case keyword == "msgid_plural":
+			current = &msgidPlural
+		case strings.HasPrefix(keyword, "msgstr"):
+			// Values of source files are not interesting
+			current = new(string)
+		default:
+			return nil, fmt.Errorf("line %d: unexpected '%s'", i+1, keyword)
+		}
+		if value != "" {
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid string %s", i+1, value)
+			}
+			*current += unquoted
+		}
+	}
+	flush()
+	return result, nil
+}
+
+/*
+JSON: nested keys are joined with dots, array items are identified by their
+index
+*/
+func parseJsonStrings(data []byte) (map[string]string, error) {
+	var parsed interface{}
+	err := json.Unmarshal(data, &parsed)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]string)
+	flattenStrings(result, "", parsed, false)
+	return result, nil
+}
+
+/*
+Structured JSON: like JSON, but an object with a 'string' member is a single
+string (its other members, eg 'context' and 'developer_comment', describe it)
+identified by the key of the object
+*/
+func parseStructuredJsonStrings(data []byte) (map[string]string, error) {
+	var parsed interface{}
+	err := json.Unmarshal(data, &parsed)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]string)
+	flattenStrings(result, "", parsed, true)
+	return result, nil
+}
+
+/*
+YAML: same as JSON
+*/
+func parseYamlStrings(data []byte) (map[string]string, error) {
+	var parsed interface{}
+	err := yaml.Unmarshal(data, &parsed)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]string)
+	flattenStrings(result, "", parsed, false)
+	return result, nil
+}
+
+func flattenStrings(
+	result map[string]string, prefix string, value interface{}, structured bool,
+) {
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + "." + key
+	}
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		if str, ok := typed["string"]; structured && ok {
+			result[prefix] = fmt.Sprint(str)
+			return
+		}
+		for key, item := range typed {
+			flattenStrings(result, join(key), item, structured)
+		}
+	case map[interface{}]interface{}:
+		for key, item := range typed {
+			flattenStrings(result, join(fmt.Sprint(key)), item, structured)
+		}
+	case []interface{}:
+		for i, item := range typed {
+			flattenStrings(
+				result, fmt.Sprintf("%s[%d]", prefix, i), item, structured,
+			)
+		}
+	case nil:
+		result[prefix] = ""
+	default:
+		result[prefix] = fmt.Sprint(typed)
+	}
+}
+
+/*
+Android: strings are identified by their name, items of string arrays by
+their index and plurals by their quantity
+*/
+func parseAndroidStrings(data []byte) (map[string]string, error) {
+	type item struct {
+		Quantity string `xml:"quantity,attr"`
+		Value    string `xml:",innerxml"`
+	}
+	type element struct {
+		XMLName      xml.Name
+		Name         string `xml:"name,attr"`
+		Translatable string `xml:"translatable,attr"`
+		Value        string `xml:",innerxml"`
+		Items        []item `xml:"item"`
+	}
+	var resources struct {
+		Elements []element `xml:",any"`
+	}
+	err := xml.Unmarshal(data, &resources)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]string)
+	for _, element := range resources.Elements {
+		if element.Name == "" || element.Translatable == "false" {
+			continue
+		}
+		switch element.XMLName.Local {
+		case "string":
+			result[element.Name] = strings.TrimSpace(element.Value)
+		case "string-array":
+			for i, item := range element.Items {
+				key := fmt.Sprintf("%s[%d]", element.Name, i)
+				result[key] = strings.TrimSpace(item.Value)
+			}
+		case "plurals":
+			for _, item := range element.Items {
+				key := fmt.Sprintf("%s[%s]", element.Name, item.Quantity)
+				result[key] = strings.TrimSpace(item.Value)
+			}
+		}
+	}
+	return result, nil
+}
+
+/*
+Apple .strings: "key" = "value"; pairs, possibly encoded in UTF-16
+*/
+func parseAppleStrings(data []byte) (map[string]string, error) {
+	text := strings.TrimPrefix(decodeUtf16(data), "\ufeff")
+	result := make(map[string]string)
+	position := 0
+
+	skip := func() {
+		for position < len(text) {
+			switch {
+			case strings.HasPrefix(text[position:], "/*"):
+				end := strings.Index(text[position+2:], "*/")
+				if end == -1 {
+					position = len(text)
+				} else {
+					position += end + 4
+				}
+			case strings.HasPrefix(text[position:], "//"):
+				end := strings.Index(text[position:], "\n")
+				if end == -1 {
+					position = len(text)
+				} else {
+					position += end + 1
+				}
+			case strings.ContainsRune(" \t\r\n", rune(text[position])):
+				position++
+			default:
+				return
+			}
+		}
+	}
+	token := func() (string, error) {
+		skip()
+		if position >= len(text) {
+			return "", errors.New("unexpected end of file")
+		}
+		if text[position] != '"' {
+			start := position
+			for position < len(text) &&
+				!strings.ContainsRune(" \t\r\n=;", rune(text[position])) {
+				position++
+			}
+			return text[start:position], nil
+		}
+		var value strings.Builder
+		position++
+		for position < len(text) {
+			char := text[position]
+			if char == '"' {
+				position++
+				return value.String(), nil
+			}
+			if char == '\\' && position+1 < len(text) {
+				position++
+				switch text[position] {
+				case 'n':
+					value.WriteByte('\n')
+				case 't':
+					value.WriteByte('\t')
+				default:
+					value.WriteByte(text[position])
+				}
+			} else {
+				value.WriteByte(char)
+			}
+			position++
+		}
+		return "", errors.New("unterminated string")
+	}
+	expect := func(char byte) error {
+		skip()
+		if position >= len(text) || text[position] != char {
+			return fmt.Errorf("expected '%c' at offset %d", char, position)
+		}
+		position++
+		return nil
+	}
+
+	for {
+		skip()
+		if position >= len(text) {
+			return result, nil
+		}
+		key, err := token()
+		if err != nil {
+			return nil, err
+		}
+		err = expect('=')
+		if err != nil {
+			return nil, err
+		}
+		value, err := token()
+		if err != nil {
+			return nil, err
+		}
+		err = expect(';')
+		if err != nil {
+			return nil, err
+		}
+		result[key] = value
+	}
+}
+
+func decodeUtf16(data []byte) string {
+	var bigEndian bool
+	if bytes.HasPrefix(data, []byte{0xff, 0xfe}) {
+		bigEndian = false
+	} else if bytes.HasPrefix(data, []byte{0xfe, 0xff}) {
+		bigEndian = true
+	} else {
+		return string(data)
+	}
+	data = data[2:]
+	units := make([]uint16, 0, len(data)/2)
+	for i := 0; i+1 < len(data); i += 2 {
+		if bigEndian {
+			units = append(units, uint16(data[i])<<8|uint16(data[i+1]))
+		} else {
+			units = append(units, uint16(data[i+1])<<8|uint16(data[i]))
+		}
+	}
+	return string(utf16.Decode(units))
+}
+
+/*
+StringsDiff
+The differences between the remote and the local source strings of a
+resource, by key
+*/
+type StringsDiff struct {
+	Created   []string `json:"created"`
+	Updated   []string `json:"updated"`
+	Deleted   []string `json:"deleted"`
+	Unchanged int      `json:"unchanged"`
+}
+
+func diffStrings(remote, local map[string]string) StringsDiff {
+	diff := StringsDiff{
+		Created: []string{}, Updated: []string{}, Deleted: []string{},
+	}
+	for key, localValue := range local {
+		remoteValue, exists := remote[key]
+		if !exists {
+			diff.Created = append(diff.Created, key)
+		} else if remoteValue != localValue {
+			diff.Updated = append(diff.Updated, key)
+		} else {
+			diff.Unchanged++
+		}
+	}
+	for key := range remote {
+		_, exists := local[key]
+		if !exists {
+			diff.Deleted = append(diff.Deleted, key)
+		}
+	}
+	sort.Strings(diff.Created)
+	sort.Strings(diff.Updated)
+	sort.Strings(diff.Deleted)
+	return diff
+}
@@ -0,0 +1,285 @@
+package txlib
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/transifex/cli/internal/txlib/config"
+	"github.com/transifex/cli/pkg/atomicfile"
+)
+
+/*
+FileState
+The contents of a local file (by their SHA-256 hash) as of the last time it
+was successfully pushed or pulled.
+*/
+type FileState struct {
+	Path string `json:"path"`
+	Hash string `json:"sha256"`
+}
+
+type ResourceState struct {
+	Source       *FileState            `json:"source,omitempty"`
+	Translations map[string]*FileState `json:"translations,omitempty"`
+}
+
+/*
+State
+Kept in '.tx/state.json' next to the local configuration. It records the hash
+of every file that was pushed or pulled, per resource (which includes the
+branch in its ID) and language, so that push can skip files whose contents
+haven't changed since, regardless of their timestamps. It is safe to use from
+multiple workers at the same time and a nil *State does nothing, which is what
+commands use when there is no local configuration file.
+
+For a workspace (see 'config.LoadWorkspace'), the state only holds the states
+of the packages; 'For' picks the one of each resource.
+*/
+type State struct {
+	Resources map[string]*ResourceState `json:"resources"`
+
+	path string
+	// The directory that contains '.tx'; paths are recorded relative to it
+	root string
+	// The states of the packages of a workspace, by the path of their local
+	// configuration
+	packages map[string]*State
+	dirty    bool
+	mutex    sync.Mutex
+}
+
+/*
+Load the state that belongs to the local configuration of 'cfg', or the states
+of all the local configurations that the resources of a workspace come from.
+A missing state file is the same as an empty one.
+*/
+func LoadState(cfg *config.Config) (*State, error) {
+	if cfg.Local == nil {
+		return nil, nil
+	}
+	if cfg.Local.Path != "" {
+		return loadStateFile(cfg.Local.Path)
+	}
+	packages := make(map[string]*State)
+	for _, cfgResource := range cfg.Local.Resources {
+		configPath := cfgResource.ConfigPath
+		if _, exists := packages[configPath]; exists || configPath == "" {
+			continue
+		}
+		state, err := loadStateFile(configPath)
+		if err != nil {
+			return nil, err
+		}
+		packages[configPath] = state
+	}
+	if len(packages) == 0 {
+		return nil, nil
+	}
+	return &State{
+		Resources: make(map[string]*ResourceState), packages: packages,
+	}, nil
+}
+
+func loadStateFile(configPath string) (*State, error) {
+	configDir := filepath.Dir(configPath)
+	path := filepath.Join(configDir, "state.json")
+	state := &State{
+		Resources: make(map[string]*ResourceState),
+		path:      path,
+		root:      filepath.Dir(configDir),
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	} else if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(data, state)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse '%s': %w", path, err)
+	}
+	if state.Resources == nil {
+		state.Resources = make(map[string]*ResourceState)
+	}
+	return state, nil
+}
+
+/*
+For
+Return the state that 'cfgResource' should be checked against and recorded in:
+the state of its package for workspaces, otherwise 'state' itself
+*/
+func (state *State) For(cfgResource *config.Resource) *State {
+	if state == nil || cfgResource.ConfigPath == "" {
+		return state
+	}
+	packageState, exists := state.packages[cfgResource.ConfigPath]
+	if !exists {
+		return state
+	}
+	return packageState
+}
+
+/*
+Save the state (and the states of the packages of a workspace), if anything
+was recorded since it was loaded
+*/
+func (state *State) Save() error {
+	if state == nil {
+		return nil
+	}
+	var configPaths []string
+	for configPath := range state.packages {
+		configPaths = append(configPaths, configPath)
+	}
+	sort.Strings(configPaths)
+	for _, configPath := range configPaths {
+		err := state.packages[configPath].Save()
+		if err != nil {
+			return err
+		}
+	}
+	state.mutex.Lock()
+	defer state.mutex.Unlock()
+	if !state.dirty || state.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = atomicfile.WriteFile(
+		state.path, append(data, '\n'), 0644, atomicfile.Options{},
+	)
+	if err != nil {
+		return err
+	}
+	state.dirty = false
+	return nil
+}
+
+/*
+Return whether the contents of 'path' are the same as the last time it was
+pushed or pulled for 'resourceId' and 'languageCode' (empty for the source
+file), along with the hash of its current contents so that it can be passed
+to 'Record' later
+*/
+func (state *State) Check(
+	resourceId, languageCode, path string,
+) (bool, string, error) {
+	if state == nil {
+		return false, "", nil
+	}
+	hash, err := hashFile(path)
+	if err != nil {
+		return false, "", err
+	}
+	state.mutex.Lock()
+	defer state.mutex.Unlock()
+	fileState := state.get(resourceId, languageCode)
+	unchanged := fileState != nil &&
+		fileState.Path == state.normalizePath(path) &&
+		fileState.Hash == hash
+	return unchanged, hash, nil
+}
+
+/*
+Record that 'path', whose contents have the 'hash' returned by 'Check' (or
+are hashed now if it is empty), was pushed or pulled for 'resourceId' and
+'languageCode' (empty for the source file)
+*/
+func (state *State) Record(resourceId, languageCode, path, hash string) error {
+	if state == nil {
+		return nil
+	}
+	if hash == "" {
+		var err error
+		hash, err = hashFile(path)
+		if err != nil {
+			return err
+		}
+	}
+	state.mutex.Lock()
+	defer state.mutex.Unlock()
+	resourceState, exists := state.Resources[resourceId]
+	if !exists {
+		resourceState = &ResourceState{}
+		state.Resources[resourceId] = resourceState
+	}
+	fileState := &FileState{Path: state.normalizePath(path), Hash: hash}
+	if languageCode == "" {
+		resourceState.Source = fileState
+	} else {
+		if resourceState.Translations == nil {
+			resourceState.Translations = make(map[string]*FileState)
+		}
+		resourceState.Translations[languageCode] = fileState
+	}
+	state.dirty = true
+	return nil
+}
+
+func (state *State) get(resourceId, languageCode string) *FileState {
+	resourceState, exists := state.Resources[resourceId]
+	if !exists {
+		return nil
+	}
+	if languageCode == "" {
+		return resourceState.Source
+	}
+	return resourceState.Translations[languageCode]
+}
+
+/*
+Paths are saved relative to the root of the project (the directory that
+contains '.tx', which is usually the current one) and with forward slashes so
+that the state file can be shared between machines and so that running a
+command from the root of a workspace or from the package itself records the
+same paths
+*/
+func (state *State) normalizePath(path string) string {
+	absPath, err := filepath.Abs(path)
+	if err == nil && state.root != "" {
+		absRoot, err := filepath.Abs(state.root)
+		if err == nil {
+			relative, err := filepath.Rel(absRoot, absPath)
+			if err == nil {
+				path = relative
+			}
+		}
+	}
+	return filepath.ToSlash(filepath.Clean(path))
+}
+
+func hashFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	_, err = io.Copy(hash, file)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+/*
+Save 'state' once a command is done, turning a failure to do so into the
+command's error if it didn't already fail
+*/
+func saveState(state *State, err error) error {
+	saveErr := state.Save()
+	if saveErr != nil && err == nil {
+		return fmt.Errorf("could not save state: %w", saveErr)
+	}
+	return err
+}
@@ -1,16 +1,70 @@
 package txlib
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/transifex/cli/internal/txlib/config"
 	"github.com/transifex/cli/pkg/jsonapi"
 	"github.com/transifex/cli/pkg/txapi"
+	"gopkg.in/yaml.v3"
 )
 
 type StatusCommandArguments struct {
-	ResourceIds []string
+	ResourceIds        []string
+	ExcludeResourceIds []string
+	Tags               []string
+	ExcludeTags        []string
+	Format             string
+}
+
+/*
+ResourceStatus
+Structured description of a resource's local files and remote statistics, used
+by 'tx status' when a machine-readable format has been requested.
+*/
+type ResourceStatus struct {
+	Id             string            `json:"id" yaml:"id"`
+	Organization   string            `json:"organization" yaml:"organization"`
+	Project        string            `json:"project" yaml:"project"`
+	Resource       string            `json:"resource" yaml:"resource"`
+	SourceLanguage string            `json:"source_language" yaml:"source_language"`
+	SourceFile     string            `json:"source_file" yaml:"source_file"`
+	FileFilter     string            `json:"file_filter" yaml:"file_filter"`
+	Overrides      map[string]string `json:"overrides" yaml:"overrides"`
+	Languages      []LanguageStatus  `json:"languages" yaml:"languages"`
+	Error          string            `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+type LanguageStatus struct {
+	Code      string               `json:"code" yaml:"code"`
+	LocalCode string               `json:"local_code" yaml:"local_code"`
+	LocalFile string               `json:"local_file,omitempty" yaml:"local_file,omitempty"`
+	Source    bool                 `json:"source" yaml:"source"`
+	Remote    *RemoteLanguageStats `json:"remote,omitempty" yaml:"remote,omitempty"`
+	// Either "newer" or "older", depending on how the modification time of
+	// the local file compares to the remote 'last_update'
+	LocalState string `json:"local_state,omitempty" yaml:"local_state,omitempty"`
+}
+
+type RemoteLanguageStats struct {
+	TotalStrings        int    `json:"total_strings" yaml:"total_strings"`
+	TotalWords          int    `json:"total_words" yaml:"total_words"`
+	TranslatedStrings   int    `json:"translated_strings" yaml:"translated_strings"`
+	TranslatedWords     int    `json:"translated_words" yaml:"translated_words"`
+	UntranslatedStrings int    `json:"untranslated_strings" yaml:"untranslated_strings"`
+	UntranslatedWords   int    `json:"untranslated_words" yaml:"untranslated_words"`
+	ReviewedStrings     int    `json:"reviewed_strings" yaml:"reviewed_strings"`
+	ReviewedWords       int    `json:"reviewed_words" yaml:"reviewed_words"`
+	ProofreadStrings    int    `json:"proofread_strings" yaml:"proofread_strings"`
+	ProofreadWords      int    `json:"proofread_words" yaml:"proofread_words"`
+	LastUpdate          string `json:"last_update" yaml:"last_update"`
 }
 
 func StatusCommand(
@@ -20,38 +74,68 @@ func StatusCommand(
 ) error {
 	var cfgResources []config.Resource
 
-	fmt.Print("# Gathering data for resources\n")
+	switch arguments.Format {
+	case "", "text", "json", "yaml", "table":
+	default:
+		return fmt.Errorf(
+			"invalid format '%s', use one of 'text', 'json', 'yaml' or 'table'",
+			arguments.Format,
+		)
+	}
+	structured := arguments.Format != "" && arguments.Format != "text"
 
-	for _, resourceId := range arguments.ResourceIds {
-		// Find Resources for delete in config
-		cfgResource := cfg.FindResource(resourceId)
-		if cfgResource == nil {
-			return fmt.Errorf(
-				"could not find resource '%s' in local configuration",
-				resourceId,
-			)
-		}
+	if !structured {
+		fmt.Print("# Gathering data for resources\n")
+	}
+
+	selectedResources, err := selectResources(
+		arguments.ResourceIds, arguments.ExcludeResourceIds,
+		arguments.Tags, arguments.ExcludeTags, cfg,
+	)
+	if err != nil {
+		return err
+	}
+	for _, cfgResource := range selectedResources {
 		cfgResources = append(cfgResources, *cfgResource)
 	}
 
 	cfgResourcesLen := len(cfgResources)
-
-	if cfgResourcesLen == 0 {
-		// cfgResources = append(cfgResources, cfg.Local.Resources)
-		cfgResources = cfg.Local.Resources
-		cfgResourcesLen = len(cfgResources)
-	}
 	// If there are no resources found stop
 	if cfgResourcesLen == 0 {
+		if structured {
+			return printResourceStatuses([]*ResourceStatus{}, arguments.Format)
+		}
 		color.Red("Given resources not found in config file.")
 		return nil
 	}
-	cyan := color.New(color.FgCyan).SprintFunc()
-	for i, cfgResource := range cfgResources {
-		sourceLang, err := getSourceLanguage(cfg, &api, &cfgResource)
+
+	// Source languages by project, so that each project is fetched once
+	sourceLanguages := make(map[string]string)
+	if structured {
+		var statuses []*ResourceStatus
+		for i := range cfgResources {
+			statuses = append(
+				statuses,
+				getResourceStatus(cfg, &api, &cfgResources[i], sourceLanguages),
+			)
+		}
+		err := printResourceStatuses(statuses, arguments.Format)
 		if err != nil {
-			fmt.Print(err)
+			return err
 		}
+		return statusesFailed(statuses)
+	}
+
+	cyan := color.New(color.FgCyan).SprintFunc()
+	red := color.New(color.FgRed).SprintFunc()
+	faint := color.New(color.Faint).SprintFunc()
+	summaries := make(map[string]*projectSummary)
+	var projectSlugs []string
+	var statuses []*ResourceStatus
+	for i := range cfgResources {
+		cfgResource := &cfgResources[i]
+		status := getResourceStatus(cfg, &api, cfgResource, sourceLanguages)
+		statuses = append(statuses, status)
 
 		fmt.Printf("\n%s -> %s (%d of %d)\n",
 			cfgResource.ProjectSlug,
@@ -59,25 +143,375 @@ func StatusCommand(
 			i+1,
 			cfgResourcesLen,
 		)
-		localLanguages := searchFileFilter(".", cfgResource.FileFilter)
-		overrides := cfgResource.Overrides
-		if len(overrides) > 0 {
-			for langOverride := range overrides {
-				localLanguages[langOverride] = overrides[langOverride]
-			}
+		if status.Error != "" {
+			fmt.Println(red(fmt.Sprintf(
+				"Could not fetch remote stats: %s", status.Error,
+			)))
 		}
-		for language := range localLanguages {
+		for _, language := range status.Languages {
 			source := ""
-			if sourceLang == language {
+			if language.Source {
 				source = " (source)"
 			}
-			fmt.Printf("- %s: %s %s\n", cyan(language),
-				localLanguages[language], source)
+			localFile := language.LocalFile
+			if localFile == "" {
+				localFile = faint("(no local file)")
+			}
+			line := fmt.Sprintf("- %s: %s %s", cyan(language.LocalCode),
+				localFile, source)
+			if language.Remote != nil && !language.Source {
+				line = fmt.Sprintf("%s %s", line, faint(formatLanguageProgress(
+					language,
+				)))
+			}
+			fmt.Println(line)
+		}
+
+		summary, exists := summaries[cfgResource.ProjectSlug]
+		if !exists {
+			summary = &projectSummary{}
+			summaries[cfgResource.ProjectSlug] = summary
+			projectSlugs = append(projectSlugs, cfgResource.ProjectSlug)
+		}
+		summary.add(status)
+	}
+
+	fmt.Print("\n# Summary\n\n")
+	for _, projectSlug := range projectSlugs {
+		summary := summaries[projectSlug]
+		fmt.Printf(
+			"%s: %d resource(s), %d language(s), translated %.1f%%, "+
+				"reviewed %.1f%%, proofread %.1f%%, %d untranslated words\n",
+			projectSlug,
+			summary.resources,
+			len(summary.languages),
+			percentage(summary.translatedStrings, summary.totalStrings),
+			percentage(summary.reviewedStrings, summary.totalStrings),
+			percentage(summary.proofreadStrings, summary.totalStrings),
+			summary.untranslatedWords,
+		)
+	}
+	return statusesFailed(statuses)
+}
+
+/*
+Return a TasksFailedError if the status of some resources could not be
+fetched, so that scripts can tell an incomplete status from a complete one
+*/
+func statusesFailed(statuses []*ResourceStatus) error {
+	failed := 0
+	for _, status := range statuses {
+		if status.Error != "" {
+			failed++
 		}
 	}
+	if failed > 0 {
+		return &TasksFailedError{Failed: failed}
+	}
 	return nil
 }
 
+/*
+Aggregated remote statistics of the resources of a project. Source languages
+are not taken into account.
+*/
+type projectSummary struct {
+	resources         int
+	languages         map[string]bool
+	totalStrings      int
+	translatedStrings int
+	reviewedStrings   int
+	proofreadStrings  int
+	untranslatedWords int
+}
+
+func (summary *projectSummary) add(status *ResourceStatus) {
+	if summary.languages == nil {
+		summary.languages = make(map[string]bool)
+	}
+	summary.resources++
+	for _, language := range status.Languages {
+		if language.Source || language.Remote == nil {
+			continue
+		}
+		summary.languages[language.Code] = true
+		summary.totalStrings += language.Remote.TotalStrings
+		summary.translatedStrings += language.Remote.TranslatedStrings
+		summary.reviewedStrings += language.Remote.ReviewedStrings
+		summary.proofreadStrings += language.Remote.ProofreadStrings
+		summary.untranslatedWords += language.Remote.UntranslatedWords
+	}
+}
+
+func formatLanguageProgress(language LanguageStatus) string {
+	remote := language.Remote
+	result := fmt.Sprintf(
+		"translated %.1f%%, reviewed %.1f%%, proofread %.1f%%, "+
+			"%d untranslated words",
+		percentage(remote.TranslatedStrings, remote.TotalStrings),
+		percentage(remote.ReviewedStrings, remote.TotalStrings),
+		percentage(remote.ProofreadStrings, remote.TotalStrings),
+		remote.UntranslatedWords,
+	)
+	if remote.LastUpdate != "" {
+		result = fmt.Sprintf("%s, last update %s", result, remote.LastUpdate)
+	}
+	switch language.LocalState {
+	case "newer":
+		result += " (local file is newer)"
+	case "older":
+		result += " (local file is older)"
+	}
+	return result
+}
+
+func percentage(part, total int) float32 {
+	if total == 0 {
+		return 0
+	}
+	return getActedOnStringsPercentage(float32(part), float32(total))
+}
+
+func getLocalLanguages(cfgResource *config.Resource) map[string]string {
+	localLanguages := searchFileFilter(".", cfgResource.FileFilter)
+	for langOverride, path := range cfgResource.Overrides {
+		localLanguages[langOverride] = path
+	}
+	return localLanguages
+}
+
+/*
+Gather the local files and the remote statistics of a resource. Errors are
+recorded in the 'Error' field of the result so that a single failing resource
+does not prevent the report for the rest from being printed. 'sourceLanguages'
+caches the source languages of the projects that were already fetched.
+*/
+func getResourceStatus(
+	cfg *config.Config,
+	api *jsonapi.Connection,
+	cfgResource *config.Resource,
+	sourceLanguages map[string]string,
+) *ResourceStatus {
+	result := &ResourceStatus{
+		Id: fmt.Sprintf(
+			"%s.%s", cfgResource.ProjectSlug, cfgResource.ResourceSlug,
+		),
+		Organization: cfgResource.OrganizationSlug,
+		Project:      cfgResource.ProjectSlug,
+		Resource:     cfgResource.ResourceSlug,
+		SourceFile:   cfgResource.SourceFile,
+		FileFilter:   cfgResource.FileFilter,
+		Overrides:    cfgResource.Overrides,
+		Languages:    []LanguageStatus{},
+	}
+	if result.Overrides == nil {
+		result.Overrides = make(map[string]string)
+	}
+
+	localToRemoteLanguageMappings := makeLocalToRemoteLanguageMappings(
+		*cfg, *cfgResource,
+	)
+	remoteToLocalLanguageMappings := makeRemoteToLocalLanguageMappings(
+		localToRemoteLanguageMappings,
+	)
+
+	languages := make(map[string]*LanguageStatus)
+	for localCode, path := range getLocalLanguages(cfgResource) {
+		remoteCode, exists := localToRemoteLanguageMappings[localCode]
+		if !exists {
+			remoteCode = localCode
+		}
+		languages[remoteCode] = &LanguageStatus{
+			Code:      remoteCode,
+			LocalCode: localCode,
+			LocalFile: path,
+		}
+	}
+
+	stats, sourceLanguage, err := getRemoteResourceStats(
+		cfg, api, cfgResource, sourceLanguages,
+	)
+	if err != nil {
+		result.Error = err.Error()
+	}
+	if cfgResource.SourceLanguage != "" {
+		sourceLanguage = cfgResource.SourceLanguage
+	}
+	result.SourceLanguage = sourceLanguage
+
+	for languageId, stat := range stats {
+		remoteCode := strings.TrimPrefix(languageId, "l:")
+		var attributes txapi.ResourceLanguageStatsAttributes
+		err := stat.MapAttributes(&attributes)
+		if err != nil {
+			result.Error = err.Error()
+			continue
+		}
+		language, exists := languages[remoteCode]
+		if !exists {
+			localCode, exists := remoteToLocalLanguageMappings[remoteCode]
+			if !exists {
+				localCode = remoteCode
+			}
+			language = &LanguageStatus{Code: remoteCode, LocalCode: localCode}
+			languages[remoteCode] = language
+		}
+		language.Remote = &RemoteLanguageStats{
+			TotalStrings:        attributes.TotalStrings,
+			TotalWords:          attributes.TotalWords,
+			TranslatedStrings:   attributes.TranslatedStrings,
+			TranslatedWords:     attributes.TranslatedWords,
+			UntranslatedStrings: attributes.UntranslatedStrings,
+			UntranslatedWords:   attributes.UntranslatedWords,
+			ReviewedStrings:     attributes.ReviewedStrings,
+			ReviewedWords:       attributes.ReviewedWords,
+			ProofreadStrings:    attributes.ProofreadStrings,
+			ProofreadWords:      attributes.ProofreadWords,
+			LastUpdate:          attributes.LastUpdate,
+		}
+	}
+
+	for code, language := range languages {
+		language.Source = code == sourceLanguage
+		language.LocalState = getLocalState(language)
+		result.Languages = append(result.Languages, *language)
+	}
+	sort.Slice(result.Languages, func(i, j int) bool {
+		return result.Languages[i].Code < result.Languages[j].Code
+	})
+
+	return result
+}
+
+func getLocalState(language *LanguageStatus) string {
+	if language.LocalFile == "" || language.Remote == nil ||
+		language.Remote.LastUpdate == "" {
+		return ""
+	}
+	localStat, err := os.Stat(language.LocalFile)
+	if err != nil {
+		return ""
+	}
+	remoteTime, err := time.Parse(time.RFC3339, language.Remote.LastUpdate)
+	if err != nil {
+		return ""
+	}
+	if localStat.ModTime().UTC().Before(remoteTime) {
+		return "older"
+	}
+	return "newer"
+}
+
+/*
+Fetch the resource language stats of a resource from the API, keyed by
+language id, together with the source language code of the resource's
+project (from 'sourceLanguages' if the project was already fetched). If the
+resource does not exist on Transifex, no stats are returned and the source
+language is looked up through the project.
+*/
+func getRemoteResourceStats(
+	cfg *config.Config,
+	api *jsonapi.Connection,
+	cfgResource *config.Resource,
+	sourceLanguages map[string]string,
+) (map[string]*jsonapi.Resource, string, error) {
+	projectId := fmt.Sprintf(
+		"o:%s:p:%s", cfgResource.OrganizationSlug, cfgResource.ProjectSlug,
+	)
+	sourceLanguage, known := sourceLanguages[projectId]
+	resource, err := txapi.GetResourceById(api, cfgResource.GetAPv3Id())
+	if err != nil {
+		return nil, sourceLanguage, err
+	}
+	if resource == nil {
+		// A source language from the configuration only applies to its own
+		// resource, so it is not cached
+		if !known && cfgResource.SourceLanguage == "" {
+			sourceLanguage, err = getSourceLanguage(cfg, api, cfgResource)
+			if err != nil {
+				return nil, "", err
+			}
+			sourceLanguages[projectId] = sourceLanguage
+		}
+		return nil, sourceLanguage, nil
+	}
+	if !known {
+		projectRelationship, err := resource.Fetch("project")
+		if err != nil {
+			return nil, "", err
+		}
+		sourceLanguageRelationship, exists :=
+			projectRelationship.DataSingular.Relationships["source_language"]
+		if exists && sourceLanguageRelationship.DataSingular != nil {
+			sourceLanguage = strings.TrimPrefix(
+				sourceLanguageRelationship.DataSingular.Id, "l:",
+			)
+		}
+		sourceLanguages[projectId] = sourceLanguage
+	}
+	stats, err := txapi.GetResourceStats(api, resource, nil)
+	if err != nil {
+		return nil, sourceLanguage, err
+	}
+	return stats, sourceLanguage, nil
+}
+
+func printResourceStatuses(statuses []*ResourceStatus, format string) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(statuses)
+	case "yaml":
+		encoder := yaml.NewEncoder(os.Stdout)
+		encoder.SetIndent(2)
+		err := encoder.Encode(statuses)
+		if err != nil {
+			return err
+		}
+		return encoder.Close()
+	case "table":
+		writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(
+			writer,
+			"RESOURCE\tLANGUAGE\tLOCAL FILE\tTRANSLATED\tREVIEWED\t"+
+				"PROOFREAD\tTOTAL\tLAST UPDATE",
+		)
+		for _, status := range statuses {
+			if status.Error != "" {
+				fmt.Fprintf(writer, "%s\t-\t-\t-\t-\t-\t-\terror: %s\n",
+					status.Id, status.Error)
+			}
+			for _, language := range status.Languages {
+				code := language.Code
+				if language.Source {
+					code += " (source)"
+				}
+				localFile := language.LocalFile
+				if localFile == "" {
+					localFile = "-"
+				}
+				if language.Remote == nil {
+					fmt.Fprintf(writer, "%s\t%s\t%s\t-\t-\t-\t-\t-\n",
+						status.Id, code, localFile)
+					continue
+				}
+				fmt.Fprintf(
+					writer, "%s\t%s\t%s\t%d\t%d\t%d\t%d\t%s\n",
+					status.Id, code, localFile,
+					language.Remote.TranslatedStrings,
+					language.Remote.ReviewedStrings,
+					language.Remote.ProofreadStrings,
+					language.Remote.TotalStrings,
+					language.Remote.LastUpdate,
+				)
+			}
+		}
+		return writer.Flush()
+	}
+	return fmt.Errorf("invalid format '%s'", format)
+}
+
 func getSourceLanguage(
 	cfg *config.Config,
 	api *jsonapi.Connection,
@@ -1,6 +1,8 @@
 package txlib
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
@@ -167,6 +169,221 @@ func TestStatusWithResourceAsParameter(t *testing.T) {
 		result, "aaa-el.json  (source)"))
 }
 
+func TestStatusJsonFormat(t *testing.T) {
+	var pkgDir, tmpDir = beforeStatusTest(t, []string{"el", "fr"})
+	defer afterStatusTest(pkgDir, tmpDir)
+
+	mockData := jsonapi.MockData{
+		resourceUrl:          getResourceEndpoint(),
+		projectUrl:           getProjectEndpoint(),
+		statsUrlAllLanguages: getStatusStatsEndpoint(),
+	}
+	api := jsonapi.GetTestConnection(mockData)
+	cfg := getStandardConfig()
+
+	rescueStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err := StatusCommand(
+		cfg,
+		api,
+		&StatusCommandArguments{Format: "json"},
+	)
+
+	w.Close()
+	out, _ := ioutil.ReadAll(r)
+	os.Stdout = rescueStdout
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var result []ResourceStatus
+	err = json.Unmarshal(out, &result)
+	if err != nil {
+		t.Fatalf("Output is not valid JSON: %s\n%s", err, out)
+	}
+	assert.Equal(t, len(result), 1)
+	status := result[0]
+	assert.Equal(t, status.Id, "projslug.resslug")
+	assert.Equal(t, status.SourceLanguage, "en")
+	assert.Equal(t, status.Error, "")
+	assert.Equal(t, len(status.Languages), 3)
+
+	// el: local and remote
+	assert.Equal(t, status.Languages[0].Code, "el")
+	assert.True(t, strings.HasSuffix(status.Languages[0].LocalFile, "aaa-el.json"))
+	assert.Equal(t, status.Languages[0].Remote.TranslatedStrings, 5)
+	assert.Equal(t, status.Languages[0].Remote.LastUpdate, "2021-01-01T00:00:00Z")
+
+	// en: remote only, source
+	assert.Equal(t, status.Languages[1].Code, "en")
+	assert.True(t, status.Languages[1].Source)
+	assert.Equal(t, status.Languages[1].LocalFile, "")
+
+	// fr: local only
+	assert.Equal(t, status.Languages[2].Code, "fr")
+	assert.True(t, status.Languages[2].Remote == nil)
+}
+
+func TestStatusFailsIfStatsCannotBeFetched(t *testing.T) {
+	var pkgDir, tmpDir = beforeStatusTest(t, []string{"el"})
+	defer afterStatusTest(pkgDir, tmpDir)
+
+	// No stats endpoint
+	mockData := jsonapi.MockData{
+		resourceUrl: getResourceEndpoint(),
+		projectUrl:  getProjectEndpoint(),
+	}
+	api := jsonapi.GetTestConnection(mockData)
+
+	rescueStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err := StatusCommand(
+		getStandardConfig(), api, &StatusCommandArguments{Format: "json"},
+	)
+
+	w.Close()
+	out, _ := ioutil.ReadAll(r)
+	os.Stdout = rescueStdout
+
+	var tasksFailedError *TasksFailedError
+	if !errors.As(err, &tasksFailedError) {
+		t.Fatalf("Expected a TasksFailedError, got %v", err)
+	}
+	assert.Equal(t, tasksFailedError.Failed, 1)
+
+	// The status is still printed
+	var result []ResourceStatus
+	err = json.Unmarshal(out, &result)
+	if err != nil {
+		t.Fatalf("Output is not valid JSON: %s\n%s", err, out)
+	}
+	assert.Equal(t, len(result), 1)
+	assert.True(t, result[0].Error != "")
+}
+
+func TestStatusRemoteProgress(t *testing.T) {
+	var pkgDir, tmpDir = beforeStatusTest(t, []string{"el", "fr"})
+	defer afterStatusTest(pkgDir, tmpDir)
+
+	mockData := jsonapi.MockData{
+		resourceUrl:          getResourceEndpoint(),
+		projectUrl:           getProjectEndpoint(),
+		statsUrlAllLanguages: getStatusStatsEndpoint(),
+	}
+	api := jsonapi.GetTestConnection(mockData)
+	cfg := getStandardConfig()
+
+	rescueStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err := StatusCommand(cfg, api, &StatusCommandArguments{})
+
+	w.Close()
+	out, _ := ioutil.ReadAll(r)
+	os.Stdout = rescueStdout
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := string(out)
+	assert.True(t, strings.Contains(
+		result,
+		"aaa-el.json  translated 50.0%, reviewed 0.0%, proofread 0.0%, "+
+			"12 untranslated words, last update 2021-01-01T00:00:00Z "+
+			"(local file is newer)",
+	), result)
+	assert.True(t, strings.Contains(result, "en: (no local file)  (source)"))
+	assert.True(t, strings.Contains(
+		result,
+		"projslug: 1 resource(s), 1 language(s), translated 50.0%, "+
+			"reviewed 0.0%, proofread 0.0%, 12 untranslated words",
+	), result)
+}
+
+func TestStatusFetchesEachProjectOnce(t *testing.T) {
+	var pkgDir, tmpDir = beforeStatusTest(t, []string{"el"})
+	defer afterStatusTest(pkgDir, tmpDir)
+
+	otherResourceId := fmt.Sprintf("%s:r:other", projectId)
+	mockData := jsonapi.MockData{
+		resourceUrl: getResourceEndpoint(),
+		"/resources/" + otherResourceId: jsonapi.GetMockTextResponse(
+			fmt.Sprintf(`{"data": {"type": "resources", "id": "%s",
+			               "attributes": {"slug": "other"},
+			               "relationships": {"project": {"data": {
+			                 "type": "projects", "id": "%s"}}}}}`,
+				otherResourceId, projectId),
+		),
+		// Only one response, a second request for the project would fail
+		projectUrl:           getProjectEndpoint(),
+		statsUrlAllLanguages: getStatusStatsEndpoint(),
+		strings.Replace(
+			statsUrlAllLanguages, "resslug", "other", 1,
+		): getStatusStatsEndpoint(),
+	}
+	api := jsonapi.GetTestConnection(mockData)
+	cfg := getStandardConfig()
+	otherResource := cfg.Local.Resources[0]
+	otherResource.ResourceSlug = "other"
+	cfg.Local.Resources = append(cfg.Local.Resources, otherResource)
+
+	rescueStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err := StatusCommand(cfg, api, &StatusCommandArguments{})
+
+	w.Close()
+	out, _ := ioutil.ReadAll(r)
+	os.Stdout = rescueStdout
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := string(out)
+	assert.Equal(t, mockData[projectUrl].Count, 1)
+	assert.True(t, !strings.Contains(result, "Could not fetch"), result)
+	assert.Equal(t, strings.Count(result, "en: (no local file)  (source)"), 2)
+}
+
+func TestStatusInvalidFormat(t *testing.T) {
+	err := StatusCommand(
+		getStandardConfig(),
+		jsonapi.GetTestConnection(jsonapi.MockData{}),
+		&StatusCommandArguments{Format: "xml"},
+	)
+	assert.True(t, err != nil)
+}
+
+func getStatusStatsEndpoint() *jsonapi.MockEndpoint {
+	return jsonapi.GetMockTextResponse(fmt.Sprintf(
+		`{"data": [{"type": "resource_language_stats",
+		            "id": "%s:l:en",
+		            "attributes": {"total_strings": 10,
+		                           "translated_strings": 10,
+		                           "last_update": "2021-01-01T00:00:00Z"},
+		            "relationships": {"language": {"data": {"type": "languages",
+		                                                    "id": "l:en"}}}},
+		           {"type": "resource_language_stats",
+		            "id": "%s:l:el",
+		            "attributes": {"total_strings": 10,
+		                           "translated_strings": 5,
+		                           "untranslated_strings": 5,
+		                           "untranslated_words": 12,
+		                           "last_update": "2021-01-01T00:00:00Z"},
+		            "relationships": {"language": {"data": {"type": "languages",
+		                                                    "id": "l:el"}}}}]}`,
+		resourceId,
+		resourceId,
+	))
+}
+
 func getStandardConfigStatus() *config.Config {
 	return &config.Config{
 		Local: &config.LocalConfig{
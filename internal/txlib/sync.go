@@ -0,0 +1,277 @@
+package txlib
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/transifex/cli/internal/txlib/config"
+	"github.com/transifex/cli/pkg/jsonapi"
+	"github.com/transifex/cli/pkg/txapi"
+)
+
+type SyncCommandArguments struct {
+	Push PushCommandArguments
+	Pull PullCommandArguments
+	// Wait for Transifex to finish processing the pushed source files (eg
+	// updating the statistics) before pulling
+	Wait bool
+}
+
+/*
+Push the source files (and translations, if asked to) and then pull the
+translations, reusing what the push found out about each resource so that it
+is not fetched twice. Both parts share one report, one state and one
+summary.
+*/
+func SyncCommand(
+	ctx context.Context,
+	cfg *config.Config,
+	api jsonapi.Connection,
+	args SyncCommandArguments,
+) error {
+	report := NewReport("sync")
+	state, err := LoadState(cfg)
+	if err != nil {
+		return err
+	}
+	err = syncResources(ctx, cfg, api, args, report, state)
+	err = saveState(state, err)
+	return finishReport(report, args.Push.ReportPath, err)
+}
+
+func syncResources(
+	ctx context.Context,
+	cfg *config.Config,
+	api jsonapi.Connection,
+	args SyncCommandArguments,
+	report *Report,
+	state *State,
+) error {
+	cache := newResourceCache()
+
+	// Both parts apply the branch to the resources of the configuration they
+	// are given, so each gets its own copy
+	err := pushResources(
+		ctx, copyConfig(cfg), api, args.Push, report, state, cache,
+	)
+	if err != nil {
+		return err
+	}
+
+	if args.Wait && !args.Push.DryRun {
+		err = waitForSourceProcessing(ctx, &api, args, cache)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !args.Pull.Silent {
+		fmt.Print("\n")
+	}
+	return pullResources(
+		ctx, copyConfig(cfg), &api, &args.Pull, report, state, cache,
+	)
+}
+
+func copyConfig(cfg *config.Config) *config.Config {
+	if cfg.Local == nil {
+		return cfg
+	}
+	local := *cfg.Local
+	local.Resources = append([]config.Resource{}, cfg.Local.Resources...)
+	return &config.Config{Root: cfg.Root, Local: &local}
+}
+
+/*
+resourceCache
+Remembers what the push part of 'tx sync' found out about each resource, by
+APIv3 ID, so that the pull part doesn't have to ask Transifex again. Stats are
+only kept for resources that fetched them for all languages and nothing was
+pushed for since. It is safe to use from multiple workers at the same time
+and a nil *resourceCache does nothing, which is what push and pull use on
+their own.
+*/
+type resourceCache struct {
+	entries map[string]*cachedResource
+	mutex   sync.Mutex
+}
+
+type cachedResource struct {
+	resource *jsonapi.Resource
+	project  *jsonapi.Resource
+	stats    map[string]*jsonapi.Resource
+	// When the upload of a source file that changed strings started
+	sourcePushedAt time.Time
+}
+
+func newResourceCache() *resourceCache {
+	return &resourceCache{entries: make(map[string]*cachedResource)}
+}
+
+func (cache *resourceCache) store(
+	resource, project *jsonapi.Resource, stats map[string]*jsonapi.Resource,
+) {
+	if cache == nil {
+		return
+	}
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	cache.entries[resource.Id] = &cachedResource{
+		resource: resource, project: project, stats: stats,
+	}
+}
+
+func (cache *resourceCache) get(id string) *cachedResource {
+	if cache == nil {
+		return nil
+	}
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	entry, exists := cache.entries[id]
+	if !exists {
+		return nil
+	}
+	result := *entry
+	return &result
+}
+
+// The stats of the resource are outdated because a file was pushed
+func (cache *resourceCache) invalidate(id string) {
+	if cache == nil {
+		return
+	}
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	entry, exists := cache.entries[id]
+	if exists {
+		entry.stats = nil
+	}
+}
+
+func (cache *resourceCache) sourcePushed(id string, startedAt time.Time) {
+	if cache == nil {
+		return
+	}
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	entry, exists := cache.entries[id]
+	if exists {
+		entry.stats = nil
+		entry.sourcePushedAt = startedAt
+	}
+}
+
+// How often and for how long to check whether pushed source files have been
+// processed; vars so that tests don't have to wait
+var syncWaitInterval = 2 * time.Second
+var syncWaitTimeout = 5 * time.Minute
+
+/*
+Wait until the source language stats of every resource whose source file was
+pushed have been updated since the upload started, which is when Transifex
+has finished processing the new strings. Resources that take too long are
+reported and pulled anyway.
+*/
+func waitForSourceProcessing(
+	ctx context.Context,
+	api *jsonapi.Connection,
+	args SyncCommandArguments,
+	cache *resourceCache,
+) error {
+	cache.mutex.Lock()
+	var pending []*cachedResource
+	for _, entry := range cache.entries {
+		if !entry.sourcePushedAt.IsZero() {
+			pending = append(pending, entry)
+		}
+	}
+	cache.mutex.Unlock()
+	if len(pending) == 0 {
+		return nil
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].resource.Id < pending[j].resource.Id
+	})
+
+	if !args.Push.Silent {
+		fmt.Print("\n# Waiting for Transifex to process the source files\n\n")
+	}
+	timeout := syncWaitTimeout
+	if args.Push.Timeout > 0 {
+		timeout = args.Push.Timeout
+	}
+	for _, entry := range pending {
+		err := waitForSourceFile(ctx, api, entry, timeout, args.Push.Silent)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+Wait until the source file of a single resource has been processed. Every
+resource gets its own 'timeout', so that a slow one doesn't use up the time of
+the ones after it.
+*/
+func waitForSourceFile(
+	ctx context.Context,
+	api *jsonapi.Connection,
+	entry *cachedResource,
+	timeout time.Duration,
+	silent bool,
+) error {
+	waitCtx, waitApi, cancel := withJobTimeout(ctx, api, timeout)
+	defer cancel()
+
+	parts := strings.Split(entry.resource.Id, ":")
+	name := fmt.Sprintf("%s.%s", parts[3], parts[5])
+	sourceLanguage := entry.project.Relationships["source_language"].DataSingular
+	for {
+		stats, err := txapi.GetResourceStats(
+			waitApi, entry.resource, sourceLanguage,
+		)
+		if ctx.Err() != nil {
+			return ErrInterrupted
+		}
+		if waitCtx.Err() != nil {
+			fmt.Printf(
+				"%s - Timed out after %s, pulling anyway\n", name, timeout,
+			)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if sourceIsProcessed(stats[sourceLanguage.Id], entry.sourcePushedAt) {
+			if !silent {
+				fmt.Printf("%s - Done\n", name)
+			}
+			return nil
+		}
+		select {
+		case <-waitCtx.Done():
+		case <-time.After(syncWaitInterval):
+		}
+	}
+}
+
+func sourceIsProcessed(stats *jsonapi.Resource, since time.Time) bool {
+	if stats == nil {
+		return false
+	}
+	var attributes txapi.ResourceLanguageStatsAttributes
+	err := stats.MapAttributes(&attributes)
+	if err != nil {
+		return false
+	}
+	lastUpdate, err := time.Parse(time.RFC3339, attributes.LastUpdate)
+	if err != nil {
+		return false
+	}
+	return !lastUpdate.Before(since.Truncate(time.Second))
+}
@@ -0,0 +1,210 @@
+package txlib
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/transifex/cli/pkg/assert"
+	"github.com/transifex/cli/pkg/jsonapi"
+)
+
+func getSyncArguments() SyncCommandArguments {
+	return SyncCommandArguments{
+		Push: PushCommandArguments{
+			Source: true, Force: true, Branch: "-1", Workers: 1,
+		},
+		Pull: PullCommandArguments{
+			FileType:          "default",
+			Mode:              "default",
+			Translations:      true,
+			Force:             true,
+			All:               true,
+			Branch:            "-1",
+			MinimumPercentage: -1,
+			Workers:           1,
+		},
+	}
+}
+
+func TestSyncReusesResourceLookups(t *testing.T) {
+	afterTest := beforeTest(t, nil, nil)
+	defer afterTest()
+
+	ts := getNewTestServer("This is the content")
+	defer ts.Close()
+
+	// Every endpoint can only be used once, so the pull part fails if it
+	// fetches the resource or the project again
+	mockData := jsonapi.MockData{
+		resourceUrl:             getResourceEndpoint(),
+		projectUrl:              getProjectEndpoint(),
+		statsUrlSourceLanguage:  getStatsEndpointSourceLanguage(),
+		sourceUploadsUrl:        getSourceUploadPostEndpoint(),
+		sourceUploadUrl:         getSourceUploadGetEndpoint(),
+		statsUrlAllLanguages:    getStatsEndpointAllLanguages(),
+		translationDownloadsUrl: getTranslationDownloadsEndpoint(),
+		translationDownloadUrl:  getDownloadEndpoint(ts.URL),
+	}
+	api := jsonapi.GetTestConnection(mockData)
+
+	err := SyncCommand(
+		context.Background(), getStandardConfig(), api, getSyncArguments(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testSimpleGet(t, mockData, resourceUrl)
+	testSimpleGet(t, mockData, projectUrl)
+	testSimpleUpload(t, mockData, sourceUploadsUrl)
+	testSimpleGet(t, mockData, statsUrlAllLanguages)
+	testSimpleTranslationDownload(t, mockData, "false")
+	assertFileContent(t, "aaa-el.json", "This is the content")
+}
+
+func TestSyncWaitsForSourceProcessing(t *testing.T) {
+	afterTest := beforeTest(t, nil, nil)
+	defer afterTest()
+
+	ts := getNewTestServer("This is the content")
+	defer ts.Close()
+
+	defaultInterval := syncWaitInterval
+	syncWaitInterval = time.Millisecond
+	defer func() { syncWaitInterval = defaultInterval }()
+
+	getSourceStats := func(lastUpdate time.Time) jsonapi.MockRequest {
+		return jsonapi.MockRequest{Response: jsonapi.MockResponse{
+			Text: fmt.Sprintf(
+				`{"data": [{"type": "resource_language_stats",
+				            "id": "%s:l:en",
+				            "attributes": {"last_update": "%s"},
+				            "relationships": {"language": {"data": {
+				              "type": "languages", "id": "l:en"}}}}]}`,
+				resourceId, lastUpdate.UTC().Format(time.RFC3339),
+			),
+		}}
+	}
+	mockData := jsonapi.MockData{
+		resourceUrl: getResourceEndpoint(),
+		projectUrl:  getProjectEndpoint(),
+		statsUrlSourceLanguage: &jsonapi.MockEndpoint{
+			Requests: []jsonapi.MockRequest{
+				getSourceStats(time.Now().Add(-time.Hour)),
+				// Not processed yet
+				getSourceStats(time.Now().Add(-time.Hour)),
+				getSourceStats(time.Now().Add(time.Hour)),
+			},
+		},
+		sourceUploadsUrl: getSourceUploadPostEndpoint(),
+		sourceUploadUrl: jsonapi.GetMockTextResponse(
+			`{"data": {"type": "resource_strings_async_uploads",
+			           "id": "upload_1",
+			           "attributes": {"status": "succeeded",
+			                          "details": {"strings_created": 1}}}}`,
+		),
+		statsUrlAllLanguages:    getStatsEndpointAllLanguages(),
+		translationDownloadsUrl: getTranslationDownloadsEndpoint(),
+		translationDownloadUrl:  getDownloadEndpoint(ts.URL),
+	}
+	api := jsonapi.GetTestConnection(mockData)
+
+	args := getSyncArguments()
+	args.Wait = true
+	err := SyncCommand(context.Background(), getStandardConfig(), api, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, mockData[statsUrlSourceLanguage].Count, 3)
+	testSimpleGet(t, mockData, statsUrlAllLanguages)
+	assertFileContent(t, "aaa-el.json", "This is the content")
+}
+
+func TestSyncStopsIfPushFails(t *testing.T) {
+	afterTest := beforeTest(t, nil, nil)
+	defer afterTest()
+
+	mockData := jsonapi.MockData{
+		resourceUrl:            getResourceEndpoint(),
+		projectUrl:             getProjectEndpoint(),
+		statsUrlSourceLanguage: getStatsEndpointSourceLanguage(),
+		sourceUploadsUrl: &jsonapi.MockEndpoint{
+			Requests: []jsonapi.MockRequest{{Response: jsonapi.MockResponse{
+				Status: 400,
+				Text: `{"errors": [{"status": "400", "code": "invalid",
+				                    "detail": "Invalid file"}]}`,
+			}}},
+		},
+	}
+	api := jsonapi.GetTestConnection(mockData)
+
+	err := SyncCommand(
+		context.Background(), getStandardConfig(), api, getSyncArguments(),
+	)
+	assert.True(t, err != nil)
+	_, pulled := mockData[statsUrlAllLanguages]
+	assert.True(t, !pulled)
+}
+
+func TestSyncWaitTimesOutEachResourceSeparately(t *testing.T) {
+	defaultInterval, defaultTimeout := syncWaitInterval, syncWaitTimeout
+	syncWaitInterval, syncWaitTimeout = time.Millisecond, 50*time.Millisecond
+	defer func() {
+		syncWaitInterval, syncWaitTimeout = defaultInterval, defaultTimeout
+	}()
+
+	project := &jsonapi.Resource{Type: "projects", Id: projectId}
+	project.SetRelated(
+		"source_language", &jsonapi.Resource{Type: "languages", Id: "l:en"},
+	)
+	cache := newResourceCache()
+	for _, slug := range []string{"aaa", "bbb"} {
+		resource := &jsonapi.Resource{
+			Type: "resources", Id: fmt.Sprintf("%s:r:%s", projectId, slug),
+		}
+		resource.SetRelated("project", project)
+		cache.entries[resource.Id] = &cachedResource{
+			resource:       resource,
+			project:        project,
+			sourcePushedAt: time.Now(),
+		}
+	}
+
+	// 'aaa' is never processed; 'bbb' is processed by the second check, which
+	// has to happen even though 'aaa' used up all of its time
+	checks := make(map[string]int)
+	api := &jsonapi.Connection{RequestMethod: func(
+		method, path string, payload []byte, contentType string,
+	) ([]byte, error) {
+		slug := "aaa"
+		if strings.Contains(path, "bbb") {
+			slug = "bbb"
+		}
+		checks[slug]++
+		lastUpdate := time.Now().Add(-time.Hour)
+		if slug == "bbb" && checks[slug] > 1 {
+			lastUpdate = time.Now().Add(time.Hour)
+		}
+		return []byte(fmt.Sprintf(
+			`{"data": [{"type": "resource_language_stats",
+			            "id": "%s:r:%s:l:en",
+			            "attributes": {"last_update": "%s"},
+			            "relationships": {"language": {"data": {
+			              "type": "languages", "id": "l:en"}}}}]}`,
+			projectId, slug, lastUpdate.UTC().Format(time.RFC3339),
+		)), nil
+	}}
+
+	args := getSyncArguments()
+	args.Wait = true
+	err := waitForSourceProcessing(context.Background(), api, args, cache)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, checks["aaa"] > 1)
+	assert.Equal(t, checks["bbb"], 2)
+}
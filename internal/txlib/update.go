@@ -11,10 +11,11 @@ import (
 )
 
 type UpdateCommandArguments struct {
-	Version       string
-	NoInteractive bool
-	Check         bool
-	Debug         bool
+	Version string
+	// Update without asking for confirmation
+	Yes   bool
+	Check bool
+	Debug bool
 }
 
 func UpdateCommand(arguments UpdateCommandArguments) error {
@@ -43,7 +44,7 @@ func UpdateCommand(arguments UpdateCommandArguments) error {
 			)
 			fmt.Println()
 			fmt.Println(
-				"Use `tx update` or `tx update --no-interactive` " +
+				"Use `tx update` or `tx update --yes` " +
 					"command to update to the latest version.")
 			fmt.Println("If you want to download and install it manually, " +
 				"you can get the asset from")
@@ -58,14 +59,21 @@ func UpdateCommand(arguments UpdateCommandArguments) error {
 					" v%s -> v%s", current, latest.Version.String(),
 			)
 			fmt.Println()
-			// Show prompt if there is no no-interactive flag
-			if !arguments.NoInteractive {
+			// Show prompt if there is no --yes flag
+			if !arguments.Yes {
+				err := checkInteractive(
+					"confirmation to update",
+					"run 'tx update --yes' to update without confirmation",
+				)
+				if err != nil {
+					return err
+				}
 				prompt := promptui.Prompt{
 					Label:     "Do you want to update",
 					IsConfirm: true,
 				}
 
-				_, err := prompt.Run()
+				_, err = prompt.Run()
 
 				if err != nil {
 					fmt.Println("Update Cancelled")
@@ -1,17 +1,17 @@
 package txlib
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
-	"regexp"
-	"strings"
 	"time"
 
 	"github.com/gosimple/slug"
 	"github.com/mattn/go-isatty"
 	"github.com/transifex/cli/internal/txlib/config"
 	"github.com/transifex/cli/pkg/jsonapi"
+	"github.com/transifex/cli/pkg/txapi"
 )
 
 func figureOutBranch(branch string) string {
@@ -28,44 +28,7 @@ func figureOutResources(
 	resourceIds []string,
 	cfg *config.Config,
 ) ([]*config.Resource, error) {
-	var result []*config.Resource
-
-	if len(resourceIds) != 0 {
-		existingResourceIds := make(map[string]*config.Resource)
-		for i := range cfg.Local.Resources {
-			resource := &cfg.Local.Resources[i]
-			resourceId := fmt.Sprintf("%s.%s", resource.ProjectSlug, resource.ResourceSlug)
-			existingResourceIds[resourceId] = resource
-		}
-
-		for _, resourceId := range resourceIds {
-			pattern, err := regexp.Compile(
-				"^" + strings.ReplaceAll(regexp.QuoteMeta(resourceId), "\\*", ".*") + "$",
-			)
-			if err != nil {
-				return nil, err
-			}
-			atLeastOne := false
-			for existingResourceId := range existingResourceIds {
-				if pattern.MatchString(existingResourceId) {
-					result = append(result, existingResourceIds[existingResourceId])
-					atLeastOne = true
-				}
-			}
-			if !atLeastOne {
-				return nil, fmt.Errorf(
-					"could not find resource '%s' in local configuration or your "+
-						"resource slug is invalid",
-					resourceId,
-				)
-			}
-		}
-	} else {
-		for i := range cfg.Local.Resources {
-			result = append(result, &cfg.Local.Resources[i])
-		}
-	}
-	return result, nil
+	return selectResources(resourceIds, nil, nil, nil, cfg)
 }
 
 func applyBranchToResources(cfgResources []*config.Resource, branch string) {
@@ -157,7 +120,9 @@ Run 'do'. If the error returned by 'do' is a jsonapi.ThrottleError, sleep the nu
 seconds indicated by the error and try again. Meanwhile, inform the user of
 what's going on using 'send'.
 */
-func handleThrottling(do func() error, initialMsg string, send func(string)) error {
+func handleThrottling(
+	ctx context.Context, do func() error, initialMsg string, send func(string),
+) error {
 	for {
 		if len(initialMsg) > 0 {
 			send(initialMsg)
@@ -175,7 +140,10 @@ func handleThrottling(do func() error, initialMsg string, send func(string)) err
 							"Throttled, will retry after %d seconds",
 							retryAfter,
 						))
-						time.Sleep(time.Second)
+						err = txapi.Sleep(ctx, time.Second)
+						if err != nil {
+							return err
+						}
 						retryAfter -= 1
 					}
 				} else {
@@ -183,7 +151,12 @@ func handleThrottling(do func() error, initialMsg string, send func(string)) err
 						"Throttled, will retry after %d seconds",
 						retryAfter,
 					))
-					time.Sleep(time.Duration(retryAfter) * time.Second)
+					err = txapi.Sleep(
+						ctx, time.Duration(retryAfter)*time.Second,
+					)
+					if err != nil {
+						return err
+					}
 				}
 			} else {
 				return err
@@ -192,6 +165,78 @@ func handleThrottling(do func() error, initialMsg string, send func(string)) err
 	}
 }
 
+/*
+Return a copy of 'api' whose requests are bound to a context that is done when
+'ctx' is or after 'timeout', if it is positive. Async jobs (uploads, downloads,
+merges) use it so that a job that never finishes cannot hang the command.
+*/
+func withJobTimeout(
+	ctx context.Context, api *jsonapi.Connection, timeout time.Duration,
+) (context.Context, *jsonapi.Connection, context.CancelFunc) {
+	var jobCtx context.Context
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		jobCtx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		jobCtx, cancel = context.WithCancel(ctx)
+	}
+	jobApi := *api
+	jobApi.Context = jobCtx
+	return jobCtx, &jobApi, cancel
+}
+
+/*
+Error message for a failed job, taking into account that it may have timed out
+*/
+func jobErrorMessage(err error, timeout time.Duration) string {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Sprintf("Timed out after %s", timeout)
+	}
+	return err.Error()
+}
+
+// Minimum time between two progress messages of the same transfer
+const progressInterval = 500 * time.Millisecond
+
+/*
+Return a function that reports the progress of a transfer through 'send', like
+"Uploading file (1.2 MB / 10.0 MB)". Messages are sent at most once every
+'progressInterval' (apart from the final one) so that the output isn't flooded
+when it is not a terminal.
+*/
+func progressReporter(action string, send func(string)) jsonapi.ProgressFunc {
+	var last time.Time
+	return func(transferred, total int64) {
+		done := total >= 0 && transferred >= total
+		if !done && time.Since(last) < progressInterval {
+			return
+		}
+		last = time.Now()
+		if total >= 0 {
+			send(fmt.Sprintf(
+				"%s (%s / %s)", action, formatBytes(transferred), formatBytes(total),
+			))
+		} else {
+			send(fmt.Sprintf("%s (%s)", action, formatBytes(transferred)))
+		}
+	}
+}
+
+func formatBytes(count int64) string {
+	const unit = 1024
+	if count < unit {
+		return fmt.Sprintf("%d B", count)
+	}
+	value := float64(count) / unit
+	for _, suffix := range []string{"KB", "MB", "GB"} {
+		if value < unit {
+			return fmt.Sprintf("%.1f %s", value, suffix)
+		}
+		value /= unit
+	}
+	return fmt.Sprintf("%.1f TB", value)
+}
+
 func checkFileFilter(fileFilter string) error {
 	if fileFilter == "" {
 		return errors.New("file filter is empty")
@@ -80,3 +80,25 @@ func TestConflictResolution(t *testing.T) {
 	}
 
 }
+
+func TestProgressReporter(t *testing.T) {
+	assert.Equal(t, formatBytes(512), "512 B")
+	assert.Equal(t, formatBytes(1536), "1.5 KB")
+	assert.Equal(t, formatBytes(10*1024*1024), "10.0 MB")
+
+	var messages []string
+	progress := progressReporter("Uploading file", func(msg string) {
+		messages = append(messages, msg)
+	})
+	progress(1024, 2048)
+	// Too soon after the previous message
+	progress(1536, 2048)
+	// Final message is always sent
+	progress(2048, 2048)
+	if !reflect.DeepEqual(messages, []string{
+		"Uploading file (1.0 KB / 2.0 KB)",
+		"Uploading file (2.0 KB / 2.0 KB)",
+	}) {
+		t.Errorf("Got wrong progress messages: %v", messages)
+	}
+}
@@ -0,0 +1,247 @@
+package txlib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/transifex/cli/internal/txlib/config"
+	"github.com/transifex/cli/pkg/jsonapi"
+)
+
+type WatchCommandArguments struct {
+	// Which resources to watch and how to push them; only source files are
+	// pushed
+	Push PushCommandArguments
+	// Pull pseudo-translations of the resources after pushing them
+	Pseudo bool
+	// How long a source file needs to stay unchanged before it is pushed, so
+	// that rapid saves are pushed once
+	Debounce time.Duration
+	// How often to check the source files for changes
+	Interval time.Duration
+}
+
+/*
+Watch the source files of the selected resources and push the ones that
+change, until the context is cancelled (eg with Ctrl-C). Pushing a resource
+that fails is reported and the watch goes on. The same connection is used for
+the whole session.
+*/
+func WatchCommand(
+	ctx context.Context,
+	cfg *config.Config,
+	api jsonapi.Connection,
+	args WatchCommandArguments,
+) error {
+	cfgResources, err := selectResources(
+		args.Push.ResourceIds, args.Push.ExcludeResourceIds,
+		args.Push.Tags, args.Push.ExcludeTags, cfg,
+	)
+	if err != nil {
+		return err
+	}
+	watcher, err := newSourceWatcher(cfgResources)
+	if err != nil {
+		return err
+	}
+	state, err := LoadState(cfg)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf(
+		"Watching %d source file(s) for changes (press Ctrl-C to stop)\n",
+		len(watcher.files),
+	)
+	ticker := time.NewTicker(args.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-ticker.C:
+			changed := watcher.poll(now, args.Debounce)
+			if len(changed) == 0 {
+				continue
+			}
+			err := pushChangedSources(ctx, cfg, api, args, changed, state)
+			if ctx.Err() != nil {
+				return nil
+			}
+			var tasksFailedError *TasksFailedError
+			if err != nil && !errors.As(err, &tasksFailedError) {
+				color.Red("[%s] %s", now.Format("15:04:05"), err)
+			}
+		}
+	}
+}
+
+/*
+Push the source files of 'resources' (and pull their pseudo-translations, if
+asked to) and print a one-line summary
+*/
+func pushChangedSources(
+	ctx context.Context,
+	cfg *config.Config,
+	api jsonapi.Connection,
+	args WatchCommandArguments,
+	resources []config.Resource,
+	state *State,
+) error {
+	// The resources are already selected and pushResources applies the branch
+	// to its own copy of them
+	local := *cfg.Local
+	local.Resources = append([]config.Resource{}, resources...)
+	changedCfg := &config.Config{Root: cfg.Root, Local: &local}
+
+	var paths []string
+	for _, resource := range resources {
+		paths = append(paths, resource.SourceFile)
+	}
+	fmt.Printf(
+		"[%s] Changed: %s\n",
+		time.Now().Format("15:04:05"), strings.Join(paths, ", "),
+	)
+
+	push := args.Push
+	push.Source = true
+	push.Translation = false
+	push.Silent = true
+	push.ResourceIds = nil
+	push.ExcludeResourceIds = nil
+	push.Tags = nil
+	push.ExcludeTags = nil
+
+	report := NewReport("push")
+	var err error
+	if args.Pseudo {
+		err = syncResources(ctx, changedCfg, api, SyncCommandArguments{
+			Push: push,
+			Pull: PullCommandArguments{
+				FileType:          "default",
+				Mode:              "default",
+				ContentEncoding:   "text",
+				Translations:      true,
+				All:               true,
+				Force:             true,
+				Skip:              push.Skip,
+				Branch:            push.Branch,
+				MinimumPercentage: -1,
+				Workers:           push.Workers,
+				Silent:            true,
+				Pseudo:            true,
+				DryRun:            push.DryRun,
+				Timeout:           push.Timeout,
+			},
+			Wait: true,
+		}, report, state)
+	} else {
+		err = pushResources(ctx, changedCfg, api, push, report, state, nil)
+	}
+	err = saveState(state, err)
+
+	report.mutex.Lock()
+	summary := report.describeCounts()
+	if details := report.describeDetails(); details != "" {
+		summary = fmt.Sprintf("%s (%s)", summary, details)
+	}
+	failed := report.Failed
+	report.mutex.Unlock()
+	if summary != "" {
+		line := fmt.Sprintf("[%s] %s", time.Now().Format("15:04:05"), summary)
+		if failed {
+			color.Red("%s", line)
+		} else {
+			fmt.Println(line)
+		}
+	}
+	if err == nil && failed {
+		return &TasksFailedError{Failed: report.Count(OutcomeFailed)}
+	}
+	return err
+}
+
+/*
+sourceWatcher
+Finds out which source files changed by comparing their modification times
+and sizes between polls. Files that are missing (eg while an editor replaces
+them) are not considered changed until they show up again.
+*/
+type sourceWatcher struct {
+	files      []*watchedFile
+	pending    map[*watchedFile]bool
+	lastChange time.Time
+}
+
+type watchedFile struct {
+	resource config.Resource
+	exists   bool
+	modTime  time.Time
+	size     int64
+}
+
+func newSourceWatcher(cfgResources []*config.Resource) (*sourceWatcher, error) {
+	watcher := &sourceWatcher{pending: make(map[*watchedFile]bool)}
+	for _, cfgResource := range cfgResources {
+		if cfgResource.SourceFile == "" {
+			continue
+		}
+		file := &watchedFile{resource: *cfgResource}
+		file.update()
+		watcher.files = append(watcher.files, file)
+	}
+	if len(watcher.files) == 0 {
+		return nil, errors.New(
+			"none of the selected resources has a source file to watch",
+		)
+	}
+	return watcher, nil
+}
+
+// Returns whether the file changed since the last time it was checked
+func (file *watchedFile) update() bool {
+	info, err := os.Stat(file.resource.SourceFile)
+	if err != nil {
+		file.exists = false
+		return false
+	}
+	changed := !file.exists ||
+		!info.ModTime().Equal(file.modTime) ||
+		info.Size() != file.size
+	file.exists = true
+	file.modTime = info.ModTime()
+	file.size = info.Size()
+	return changed
+}
+
+/*
+Check the source files for changes and return the resources whose source file
+changed, once none of them has changed for 'debounce'. Resources are returned
+in the order they were selected in.
+*/
+func (watcher *sourceWatcher) poll(
+	now time.Time, debounce time.Duration,
+) []config.Resource {
+	for _, file := range watcher.files {
+		if file.update() {
+			watcher.pending[file] = true
+			watcher.lastChange = now
+		}
+	}
+	if len(watcher.pending) == 0 || now.Sub(watcher.lastChange) < debounce {
+		return nil
+	}
+	var result []config.Resource
+	for _, file := range watcher.files {
+		if watcher.pending[file] {
+			result = append(result, file.resource)
+		}
+	}
+	watcher.pending = make(map[*watchedFile]bool)
+	return result
+}
@@ -0,0 +1,114 @@
+package txlib
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/transifex/cli/internal/txlib/config"
+	"github.com/transifex/cli/pkg/assert"
+	"github.com/transifex/cli/pkg/jsonapi"
+)
+
+func TestSourceWatcher(t *testing.T) {
+	afterTest := beforeTest(t, nil, nil)
+	defer afterTest()
+
+	cfg := getStandardConfig()
+	cfg.Local.Resources = append(cfg.Local.Resources, config.Resource{
+		OrganizationSlug: "orgslug",
+		ProjectSlug:      "projslug",
+		ResourceSlug:     "other",
+		SourceFile:       "bbb.json",
+	}, config.Resource{
+		OrganizationSlug: "orgslug",
+		ProjectSlug:      "projslug",
+		ResourceSlug:     "nosource",
+	})
+	cfgResources, err := selectResources(nil, nil, nil, nil, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	watcher, err := newSourceWatcher(cfgResources)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, len(watcher.files), 2)
+
+	write := func(path, content string) {
+		t.Helper()
+		err := os.WriteFile(path, []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	start := time.Now()
+	at := func(offset time.Duration) []config.Resource {
+		return watcher.poll(start.Add(offset), time.Second)
+	}
+
+	assert.Equal(t, len(at(0)), 0)
+
+	// Rapid saves are pushed once, after the debounce
+	write("aaa.json", `{"a": "a"}`)
+	assert.Equal(t, len(at(100*time.Millisecond)), 0)
+	write("aaa.json", `{"a": "a", "b": "b"}`)
+	assert.Equal(t, len(at(500*time.Millisecond)), 0)
+	assert.Equal(t, len(at(1200*time.Millisecond)), 0)
+	changed := at(1500 * time.Millisecond)
+	assert.Equal(t, len(changed), 1)
+	assert.Equal(t, changed[0].ResourceSlug, "resslug")
+	assert.Equal(t, len(at(3*time.Second)), 0)
+
+	// A file that shows up counts as changed
+	write("bbb.json", `{}`)
+	write("aaa.json", `{}`)
+	changed = at(5 * time.Second)
+	assert.Equal(t, len(changed), 0)
+	changed = at(7 * time.Second)
+	assert.Equal(t, len(changed), 2)
+	assert.Equal(t, changed[0].ResourceSlug, "resslug")
+	assert.Equal(t, changed[1].ResourceSlug, "other")
+
+	// Missing files are not pushed
+	err = os.Remove("bbb.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, len(at(10*time.Second)), 0)
+	assert.Equal(t, len(at(12*time.Second)), 0)
+
+	_, err = newSourceWatcher([]*config.Resource{
+		{ProjectSlug: "projslug", ResourceSlug: "nosource"},
+	})
+	assert.True(t, err != nil)
+}
+
+func TestPushChangedSources(t *testing.T) {
+	afterTest := beforeTest(t, nil, nil)
+	defer afterTest()
+
+	mockData := jsonapi.MockData{
+		resourceUrl:            getResourceEndpoint(),
+		projectUrl:             getProjectEndpoint(),
+		statsUrlSourceLanguage: getStatsEndpointSourceLanguage(),
+		sourceUploadsUrl:       getSourceUploadPostEndpoint(),
+		sourceUploadUrl:        getSourceUploadGetEndpoint(),
+	}
+	api := jsonapi.GetTestConnection(mockData)
+
+	cfg := getStandardConfig()
+	args := WatchCommandArguments{Push: PushCommandArguments{
+		Force: true, Branch: "-1", Workers: 1,
+		// Already applied by the watcher
+		ResourceIds: []string{"projslug.missing"},
+	}}
+	err := pushChangedSources(
+		context.Background(), cfg, api, args, cfg.Local.Resources, nil,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testSimpleUpload(t, mockData, sourceUploadsUrl)
+}
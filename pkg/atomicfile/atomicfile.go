@@ -0,0 +1,178 @@
+/*
+Package atomicfile
+Write files so that readers (and the user's version control) never see them
+half-written: the contents are written to a temporary file in the same
+directory which is then renamed over the target. If anything goes wrong along
+the way (the process is interrupted, the disk is full), the target keeps its
+previous contents.
+
+Usage:
+
+	err := atomicfile.WriteFile("locale/el.po", data, 0644, atomicfile.Options{
+		PreserveMode: true,
+		Backup:       true,
+	})
+*/
+package atomicfile
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+type Options struct {
+	// Keep the permissions of the file being replaced instead of using 'perm'
+	PreserveMode bool
+
+	// Keep the previous version of the file being replaced, next to it with a
+	// '.bak' suffix or, if BackupDir is set, in BackupDir under the same
+	// relative path (or under its absolute path, for files outside the
+	// current directory)
+	Backup    bool
+	BackupDir string
+}
+
+/*
+WriteFile
+Atomically replace the contents of 'path' with 'data', creating missing parent
+directories. Like with 'os.WriteFile', the umask applies to 'perm'.
+*/
+func WriteFile(path string, data []byte, perm os.FileMode, options Options) error {
+	return WriteFrom(path, bytes.NewReader(data), perm, options)
+}
+
+/*
+WriteFrom
+Like 'WriteFile', but the contents are copied from 'reader' so that they don't
+have to be kept in memory. If reading fails, the target is left untouched.
+*/
+func WriteFrom(
+	path string, reader io.Reader, perm os.FileMode, options Options,
+) error {
+	err := os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	existing, err := os.Stat(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	exactPerm := false
+	if existing != nil {
+		if options.PreserveMode {
+			perm = existing.Mode().Perm()
+			exactPerm = true
+		}
+		if options.Backup || options.BackupDir != "" {
+			err = backup(path, existing.Mode().Perm(), options.BackupDir)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return writeAndRename(path, reader, perm, exactPerm)
+}
+
+func backup(path string, perm os.FileMode, backupDir string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	var backupPath string
+	if backupDir == "" {
+		backupPath = path + ".bak"
+	} else {
+		cleanPath := filepath.Clean(path)
+		if cleanPath == ".." ||
+			strings.HasPrefix(cleanPath, ".."+string(filepath.Separator)) {
+			// Joined with 'backupDir' as it is, the backup would end up
+			// outside of it
+			cleanPath, err = filepath.Abs(cleanPath)
+			if err != nil {
+				return err
+			}
+		}
+		cleanPath = strings.TrimPrefix(cleanPath, filepath.VolumeName(cleanPath))
+		backupPath = filepath.Join(backupDir, cleanPath)
+		err = os.MkdirAll(filepath.Dir(backupPath), os.ModePerm)
+		if err != nil {
+			return err
+		}
+	}
+	return writeAndRename(backupPath, file, perm, true)
+}
+
+/*
+Write the contents of 'reader' to a temporary file next to 'path' and rename
+it over 'path'. The temporary file is created with 'perm', minus the umask,
+unless 'exactPerm' is set
+*/
+func writeAndRename(
+	path string, reader io.Reader, perm os.FileMode, exactPerm bool,
+) error {
+	file, err := createTemp(path, perm)
+	if err != nil {
+		return err
+	}
+	tempPath := file.Name()
+	cleanup := func() {
+		file.Close()
+		os.Remove(tempPath)
+	}
+
+	_, err = io.Copy(file, reader)
+	if err != nil {
+		cleanup()
+		return err
+	}
+	err = file.Sync()
+	if err != nil {
+		cleanup()
+		return err
+	}
+	err = file.Close()
+	if err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+	if exactPerm {
+		err = os.Chmod(tempPath, perm)
+		if err != nil {
+			os.Remove(tempPath)
+			return err
+		}
+	}
+	err = os.Rename(tempPath, path)
+	if err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+	return nil
+}
+
+/*
+Like 'os.CreateTemp', which always uses mode 0600, but with 'perm' so that the
+umask is applied the same way as for any other new file
+*/
+func createTemp(path string, perm os.FileMode) (*os.File, error) {
+	prefix := filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".tmp-")
+	for attempt := 0; ; attempt++ {
+		file, err := os.OpenFile(
+			prefix+strconv.FormatUint(uint64(rand.Uint32()), 10),
+			os.O_RDWR|os.O_CREATE|os.O_EXCL,
+			perm,
+		)
+		if os.IsExist(err) && attempt < 100 {
+			continue
+		}
+		return file, err
+	}
+}
@@ -0,0 +1,258 @@
+package atomicfile
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func assertContent(t *testing.T, path, expected string) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != expected {
+		t.Errorf("Wrong content in '%s'; expected '%s', got '%s'",
+			path, expected, data)
+	}
+}
+
+func assertNoTempFiles(t *testing.T, dir string) {
+	t.Helper()
+	matches, err := filepath.Glob(filepath.Join(dir, ".*.tmp-*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) > 0 {
+		t.Errorf("Temporary files were left behind: %v", matches)
+	}
+}
+
+func TestWriteFileCreatesDirectories(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "locale", "el", "messages.po")
+
+	err := WriteFile(path, []byte("new"), 0644, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertContent(t, path, "new")
+	assertNoTempFiles(t, filepath.Dir(path))
+}
+
+func TestWriteFileBackup(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "el.po")
+	err := os.WriteFile(path, []byte("old"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = WriteFile(path, []byte("new"), 0644, Options{Backup: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertContent(t, path, "new")
+	assertContent(t, path+".bak", "old")
+	assertNoTempFiles(t, dir)
+}
+
+func TestWriteFileBackupDir(t *testing.T) {
+	dir := t.TempDir()
+	curDir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Chdir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(curDir)
+
+	path := filepath.Join("locale", "el.po")
+	err = os.MkdirAll("locale", os.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(path, []byte("old"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = WriteFile(path, []byte("new"), 0644, Options{BackupDir: "backups"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertContent(t, path, "new")
+	assertContent(t, filepath.Join("backups", "locale", "el.po"), "old")
+	_, err = os.Stat(path + ".bak")
+	if !os.IsNotExist(err) {
+		t.Error("Backup was saved next to the file instead of the backup dir")
+	}
+}
+
+func TestWriteFileBackupDirOutsideCurrentDir(t *testing.T) {
+	dir := t.TempDir()
+	curDir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.MkdirAll(filepath.Join(dir, "project"), os.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Chdir(filepath.Join(dir, "project"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(curDir)
+
+	path := filepath.Join("..", "shared", "el.po")
+	err = os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(path, []byte("old"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = WriteFile(path, []byte("new"), 0644, Options{BackupDir: "backups"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	absPath = strings.TrimPrefix(absPath, filepath.VolumeName(absPath))
+	assertContent(t, filepath.Join("backups", absPath), "old")
+	// Where 'backups/../shared/el.po' points to
+	_, err = os.Stat(filepath.Join("shared", "el.po"))
+	if !os.IsNotExist(err) {
+		t.Error("Backup was saved outside of the backup dir")
+	}
+}
+
+func TestWriteFileRespectsUmask(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("File modes are not supported on windows")
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "el.po")
+	// Whatever the umask is, both files should end up with the same mode
+	err := os.WriteFile(filepath.Join(dir, "expected"), []byte("new"), 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = WriteFile(path, []byte("new"), 0666, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := os.Stat(filepath.Join(dir, "expected"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stat, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stat.Mode().Perm() != expected.Mode().Perm() {
+		t.Errorf("Umask was not applied, got %s instead of %s",
+			stat.Mode().Perm(), expected.Mode().Perm())
+	}
+}
+
+func TestWriteFilePreserveMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("File modes are not supported on windows")
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "el.po")
+	err := os.WriteFile(path, []byte("old"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Chmod(path, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = WriteFile(path, []byte("new"), 0644, Options{PreserveMode: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stat, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stat.Mode().Perm() != 0600 {
+		t.Errorf("Mode was not preserved, got %s", stat.Mode().Perm())
+	}
+
+	err = WriteFile(path, []byte("newer"), 0644, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stat, err = os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stat.Mode().Perm() != 0644 {
+		t.Errorf("Default mode was not used, got %s", stat.Mode().Perm())
+	}
+}
+
+func TestWriteFileFailureKeepsOriginal(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("File modes are not supported on windows")
+	}
+	if os.Getuid() == 0 {
+		t.Skip("Permissions are not enforced for root")
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "el.po")
+	err := os.WriteFile(path, []byte("old"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Make it impossible to create the temporary file
+	err = os.Chmod(dir, 0555)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chmod(dir, 0755)
+
+	err = WriteFile(path, []byte("new"), 0644, Options{})
+	if err == nil {
+		t.Error("Expected an error")
+	}
+	assertContent(t, path, "old")
+}
+
+type failingReader struct{}
+
+func (failingReader) Read(p []byte) (int, error) {
+	return 0, io.ErrUnexpectedEOF
+}
+
+func TestWriteFromReadFailureKeepsOriginal(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "el.po")
+	err := os.WriteFile(path, []byte("old"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reader := io.MultiReader(strings.NewReader("partial"), failingReader{})
+	err = WriteFrom(path, reader, 0644, Options{})
+	if err != io.ErrUnexpectedEOF {
+		t.Errorf("Expected io.ErrUnexpectedEOF, got %v", err)
+	}
+	assertContent(t, path, "old")
+	assertNoTempFiles(t, dir)
+}
@@ -0,0 +1,782 @@
+package fakeapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Languages every server knows about; more can be added with AddLanguage
+var defaultLanguages = [][2]string{
+	{"ar", "Arabic"},
+	{"de", "German"},
+	{"el", "Greek"},
+	{"en", "English"},
+	{"es", "Spanish"},
+	{"fr", "French"},
+	{"it", "Italian"},
+	{"ja", "Japanese"},
+	{"nl", "Dutch"},
+	{"pt_BR", "Portuguese (Brazil)"},
+	{"ru", "Russian"},
+	{"zh_CN", "Chinese (China)"},
+}
+
+// The i18n formats every organization supports, with their file extensions
+var i18nFormats = [][]string{
+	{"ANDROID", ".xml"},
+	{"CHROME", ".json"},
+	{"INI", ".ini"},
+	{"KEYVALUEJSON", ".json"},
+	{"PO", ".po", ".pot"},
+	{"PROPERTIES", ".properties"},
+	{"STRINGS", ".strings"},
+	{"STRUCTURED_JSON", ".json"},
+	{"XLIFF", ".xlf", ".xliff"},
+	{"YAML_GENERIC", ".yml", ".yaml"},
+	{"YML_KEY", ".yml", ".yaml"},
+}
+
+type organization struct {
+	slug string
+}
+
+func (o *organization) id() string {
+	return fmt.Sprintf("o:%s", o.slug)
+}
+
+func (o *organization) payload() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "organizations",
+		"id":   o.id(),
+		"attributes": map[string]interface{}{
+			"slug": o.slug, "name": o.slug, "private": true, "logo_url": "",
+		},
+	}
+}
+
+type project struct {
+	organization   *organization
+	slug           string
+	sourceLanguage string
+	languages      []string
+	created        time.Time
+}
+
+func (p *project) id() string {
+	return fmt.Sprintf("%s:p:%s", p.organization.id(), p.slug)
+}
+
+func (p *project) payload() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "projects",
+		"id":   p.id(),
+		"attributes": map[string]interface{}{
+			"slug":              p.slug,
+			"name":              p.slug,
+			"private":           true,
+			"archived":          false,
+			"type":              "file",
+			"tags":              []string{},
+			"datetime_created":  formatTime(p.created),
+			"datetime_modified": formatTime(p.created),
+		},
+		"relationships": map[string]interface{}{
+			"organization": singular("organizations", p.organization.id()),
+			"source_language": singular(
+				"languages", fmt.Sprintf("l:%s", p.sourceLanguage),
+			),
+			"languages": map[string]interface{}{"links": map[string]string{
+				"self": fmt.Sprintf(
+					"/projects/%s/relationships/languages", p.id(),
+				),
+				"related": fmt.Sprintf("/projects/%s/languages", p.id()),
+			}},
+		},
+	}
+}
+
+type resource struct {
+	project    *project
+	slug       string
+	name       string
+	i18nFormat string
+	base       *resource
+	created    time.Time
+
+	source        []byte
+	sourceUpdated time.Time
+	// By language code
+	translations        map[string][]byte
+	translationsUpdated map[string]time.Time
+}
+
+func (r *resource) id() string {
+	return fmt.Sprintf("%s:r:%s", r.project.id(), r.slug)
+}
+
+func (r *resource) payload() map[string]interface{} {
+	sourceStrings := countStrings(r.source)
+	relationships := map[string]interface{}{
+		"project":     singular("projects", r.project.id()),
+		"i18n_format": singular("i18n_formats", r.i18nFormat),
+	}
+	if r.base != nil {
+		relationships["base"] = singular("resources", r.base.id())
+	}
+	return map[string]interface{}{
+		"type": "resources",
+		"id":   r.id(),
+		"attributes": map[string]interface{}{
+			"slug":                r.slug,
+			"name":                r.name,
+			"accept_translations": true,
+			"categories":          []string{},
+			"priority":            "normal",
+			"i18n_version":        2,
+			"i18n_options":        map[string]interface{}{},
+			"string_count":        len(sourceStrings),
+			"word_count":          countWords(sourceStrings),
+			"datetime_created":    formatTime(r.created),
+			"datetime_modified":   formatTime(r.sourceUpdated),
+		},
+		"relationships": relationships,
+	}
+}
+
+// The stats of the resource for 'languageCode'
+func (r *resource) statsPayload(languageCode string) map[string]interface{} {
+	source := countStrings(r.source)
+	total := len(source)
+	translated := 0
+	lastUpdate := r.sourceUpdated
+	if languageCode == r.project.sourceLanguage {
+		translated = total
+	} else if content, exists := r.translations[languageCode]; exists {
+		for key := range countStrings(content) {
+			if _, exists := source[key]; exists {
+				translated++
+			}
+		}
+		if r.translationsUpdated[languageCode].After(lastUpdate) {
+			lastUpdate = r.translationsUpdated[languageCode]
+		}
+	}
+	words := countWords(source)
+	translatedWords := 0
+	if total > 0 {
+		translatedWords = words * translated / total
+	}
+	return map[string]interface{}{
+		"type": "resource_language_stats",
+		"id":   fmt.Sprintf("%s:l:%s", r.id(), languageCode),
+		"attributes": map[string]interface{}{
+			"last_update":             formatTime(lastUpdate),
+			"last_translation_update": formatTime(lastUpdate),
+			"last_review_update":      formatTime(lastUpdate),
+			"last_proofread_update":   formatTime(lastUpdate),
+			"total_strings":           total,
+			"total_words":             words,
+			"translated_strings":      translated,
+			"translated_words":        translatedWords,
+			"untranslated_strings":    total - translated,
+			"untranslated_words":      words - translatedWords,
+			"reviewed_strings":        0,
+			"reviewed_words":          0,
+			"proofread_strings":       0,
+			"proofread_words":         0,
+		},
+		"relationships": map[string]interface{}{
+			"resource": singular("resources", r.id()),
+			"language": singular(
+				"languages", fmt.Sprintf("l:%s", languageCode),
+			),
+		},
+	}
+}
+
+type language struct {
+	code string
+	name string
+}
+
+func (l *language) payload() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "languages",
+		"id":   fmt.Sprintf("l:%s", l.code),
+		"attributes": map[string]interface{}{
+			"code":            l.code,
+			"name":            l.name,
+			"rtl":             l.code == "ar",
+			"plural_equation": "(n != 1)",
+			"plural_rules":    map[string]string{"one": "n is 1", "other": "everything else"},
+		},
+	}
+}
+
+func singular(Type, id string) map[string]interface{} {
+	return map[string]interface{}{
+		"data":  map[string]string{"type": Type, "id": id},
+		"links": map[string]string{"related": fmt.Sprintf("/%s/%s", Type, id)},
+	}
+}
+
+/*
+The strings of a file, by key. JSON files are flattened (nested keys are
+joined with '.'); for anything else, every non-empty line that doesn't start
+with '#' is a string.
+*/
+func countStrings(content []byte) map[string]string {
+	result := make(map[string]string)
+	var data interface{}
+	if json.Unmarshal(content, &data) == nil {
+		if _, isObject := data.(map[string]interface{}); isObject {
+			flattenJSON("", data, result)
+			return result
+		}
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			result[line] = line
+		}
+	}
+	return result
+}
+
+func flattenJSON(prefix string, value interface{}, result map[string]string) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, item := range value {
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			flattenJSON(key, item, result)
+		}
+	case nil:
+	default:
+		result[prefix] = fmt.Sprint(value)
+	}
+}
+
+func countWords(values map[string]string) int {
+	result := 0
+	for _, value := range values {
+		result += len(strings.Fields(value))
+	}
+	return result
+}
+
+/*
+AddOrganization
+Add an organization, unless it already exists
+*/
+func (server *Server) AddOrganization(slug string) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	server.addOrganization(slug)
+}
+
+func (server *Server) addOrganization(slug string) *organization {
+	for _, o := range server.organizations {
+		if o.slug == slug {
+			return o
+		}
+	}
+	o := &organization{slug: slug}
+	server.organizations = append(server.organizations, o)
+	return o
+}
+
+/*
+AddProject
+Add a project with a source language and target languages (by code), along
+with its organization if it doesn't exist yet. If the project already
+exists, the target languages are added to it.
+*/
+func (server *Server) AddProject(
+	organizationSlug, slug, sourceLanguage string, languages ...string,
+) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	o := server.addOrganization(organizationSlug)
+	p := server.findProject(fmt.Sprintf("%s:p:%s", o.id(), slug))
+	if p == nil {
+		p = &project{
+			organization:   o,
+			slug:           slug,
+			sourceLanguage: sourceLanguage,
+			created:        time.Now(),
+		}
+		server.projects = append(server.projects, p)
+	}
+	for _, code := range languages {
+		p.addLanguage(code)
+	}
+}
+
+func (p *project) addLanguage(code string) {
+	if code == p.sourceLanguage {
+		return
+	}
+	for _, existing := range p.languages {
+		if existing == code {
+			return
+		}
+	}
+	p.languages = append(p.languages, code)
+	sort.Strings(p.languages)
+}
+
+/*
+AddResource
+Add a resource with its source file to an existing project
+*/
+func (server *Server) AddResource(
+	projectId, slug, i18nFormat string, source []byte,
+) error {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	p := server.findProject(projectId)
+	if p == nil {
+		return fmt.Errorf("project '%s' does not exist", projectId)
+	}
+	if server.findResource(fmt.Sprintf("%s:r:%s", projectId, slug)) != nil {
+		return fmt.Errorf("resource '%s' already exists", slug)
+	}
+	now := time.Now()
+	server.resources = append(server.resources, &resource{
+		project:             p,
+		slug:                slug,
+		name:                slug,
+		i18nFormat:          i18nFormat,
+		created:             now,
+		source:              source,
+		sourceUpdated:       now,
+		translations:        make(map[string][]byte),
+		translationsUpdated: make(map[string]time.Time),
+	})
+	return nil
+}
+
+/*
+AddLanguage
+Make a language known to the server, on top of the default ones
+*/
+func (server *Server) AddLanguage(code, name string) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	if server.findLanguage(code) == nil {
+		server.languages = append(server.languages, &language{code, name})
+	}
+}
+
+/*
+SourceFile
+The last source file uploaded for a resource, by its ID (eg
+'o:org:p:project:r:resource')
+*/
+func (server *Server) SourceFile(resourceId string) ([]byte, bool) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	r := server.findResource(resourceId)
+	if r == nil {
+		return nil, false
+	}
+	return r.source, true
+}
+
+/*
+TranslationFile
+The last translation file uploaded for a resource and a language code
+*/
+func (server *Server) TranslationFile(
+	resourceId, languageCode string,
+) ([]byte, bool) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	r := server.findResource(resourceId)
+	if r == nil {
+		return nil, false
+	}
+	content, exists := r.translations[languageCode]
+	return content, exists
+}
+
+/*
+SetTranslationFile
+Replace the translations of a resource for a language, as if translators
+had worked on them
+*/
+func (server *Server) SetTranslationFile(
+	resourceId, languageCode string, content []byte,
+) error {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	r := server.findResource(resourceId)
+	if r == nil {
+		return fmt.Errorf("resource '%s' does not exist", resourceId)
+	}
+	r.translations[languageCode] = content
+	r.translationsUpdated[languageCode] = time.Now()
+	r.project.addLanguage(languageCode)
+	return nil
+}
+
+// The IDs of all resources, sorted
+func (server *Server) ResourceIds() []string {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	var result []string
+	for _, r := range server.resources {
+		result = append(result, r.id())
+	}
+	sort.Strings(result)
+	return result
+}
+
+func (server *Server) findOrganization(id string) *organization {
+	for _, o := range server.organizations {
+		if o.id() == id {
+			return o
+		}
+	}
+	return nil
+}
+
+func (server *Server) findProject(id string) *project {
+	for _, p := range server.projects {
+		if p.id() == id {
+			return p
+		}
+	}
+	return nil
+}
+
+func (server *Server) findResource(id string) *resource {
+	for _, r := range server.resources {
+		if r.id() == id {
+			return r
+		}
+	}
+	return nil
+}
+
+func (server *Server) findLanguage(code string) *language {
+	for _, l := range server.languages {
+		if l.code == code {
+			return l
+		}
+	}
+	return nil
+}
+
+func (server *Server) listOrganizations(w http.ResponseWriter, r *http.Request) {
+	var items []interface{}
+	for _, o := range server.organizations {
+		if slug := r.URL.Query().Get("filter[slug]"); slug == "" || slug == o.slug {
+			items = append(items, o.payload())
+		}
+	}
+	server.writePage(w, r, items)
+}
+
+func (server *Server) getOrganization(w http.ResponseWriter, id string) {
+	o := server.findOrganization(id)
+	if o == nil {
+		writeError(w, 404, "not_found", "Organization not found")
+		return
+	}
+	writeData(w, 200, o.payload())
+}
+
+func (server *Server) listProjects(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("filter[organization]") == "" {
+		writeError(w, 400, "invalid", "'filter[organization]' is required")
+		return
+	}
+	var items []interface{}
+	for _, p := range server.projects {
+		if p.organization.id() != query.Get("filter[organization]") {
+			continue
+		}
+		if slug := query.Get("filter[slug]"); slug != "" && slug != p.slug {
+			continue
+		}
+		items = append(items, p.payload())
+	}
+	server.writePage(w, r, items)
+}
+
+func (server *Server) getProject(w http.ResponseWriter, id string) {
+	p := server.findProject(id)
+	if p == nil {
+		writeError(w, 404, "not_found", "Project not found")
+		return
+	}
+	writeData(w, 200, p.payload())
+}
+
+func (server *Server) listProjectLanguages(
+	w http.ResponseWriter, r *http.Request, id string,
+) {
+	p := server.findProject(id)
+	if p == nil {
+		writeError(w, 404, "not_found", "Project not found")
+		return
+	}
+	var items []interface{}
+	for _, code := range p.languages {
+		l := server.findLanguage(code)
+		if l == nil {
+			l = &language{code: code, name: code}
+		}
+		items = append(items, l.payload())
+	}
+	server.writePage(w, r, items)
+}
+
+func (server *Server) changeProjectLanguages(
+	w http.ResponseWriter, r *http.Request, id string,
+) {
+	p := server.findProject(id)
+	if p == nil {
+		writeError(w, 404, "not_found", "Project not found")
+		return
+	}
+	var payload struct {
+		Data []identifier `json:"data"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		writeError(w, 400, "invalid", err.Error())
+		return
+	}
+	var codes []string
+	for _, item := range payload.Data {
+		code := strings.TrimPrefix(item.Id, "l:")
+		if server.findLanguage(code) == nil {
+			writeError(w, 400, "invalid", fmt.Sprintf(
+				"Language '%s' does not exist", item.Id,
+			))
+			return
+		}
+		codes = append(codes, code)
+	}
+	switch r.Method {
+	case "PATCH":
+		p.languages = nil
+		fallthrough
+	case "POST":
+		for _, code := range codes {
+			p.addLanguage(code)
+		}
+	case "DELETE":
+		var remaining []string
+		for _, existing := range p.languages {
+			removed := false
+			for _, code := range codes {
+				removed = removed || code == existing
+			}
+			if !removed {
+				remaining = append(remaining, existing)
+			}
+		}
+		p.languages = remaining
+	}
+	w.WriteHeader(204)
+}
+
+func (server *Server) listLanguages(w http.ResponseWriter, r *http.Request) {
+	var items []interface{}
+	for _, l := range server.languages {
+		items = append(items, l.payload())
+	}
+	// Like the real API, languages are not paginated
+	writeData(w, 200, items)
+}
+
+func (server *Server) getLanguage(w http.ResponseWriter, id string) {
+	l := server.findLanguage(strings.TrimPrefix(id, "l:"))
+	if l == nil {
+		writeError(w, 404, "not_found", "Language not found")
+		return
+	}
+	writeData(w, 200, l.payload())
+}
+
+func (server *Server) listI18nFormats(w http.ResponseWriter, r *http.Request) {
+	organizationId := r.URL.Query().Get("filter[organization]")
+	if server.findOrganization(organizationId) == nil {
+		writeError(w, 400, "invalid", "'filter[organization]' is invalid")
+		return
+	}
+	var items []interface{}
+	for _, format := range i18nFormats {
+		items = append(items, map[string]interface{}{
+			"type": "i18n_formats",
+			"id":   format[0],
+			"attributes": map[string]interface{}{
+				"name":            format[0],
+				"description":     format[0],
+				"file_extensions": format[1:],
+				"media_type":      "text/plain",
+			},
+		})
+	}
+	writeData(w, 200, items)
+}
+
+func (server *Server) listResources(w http.ResponseWriter, r *http.Request) {
+	projectId := r.URL.Query().Get("filter[project]")
+	if projectId == "" {
+		writeError(w, 400, "invalid", "'filter[project]' is required")
+		return
+	}
+	var items []interface{}
+	for _, resource := range server.resources {
+		if resource.project.id() == projectId {
+			items = append(items, resource.payload())
+		}
+	}
+	server.writePage(w, r, items)
+}
+
+func (server *Server) getResource(w http.ResponseWriter, id string) {
+	resource := server.findResource(id)
+	if resource == nil {
+		writeError(w, 404, "not_found", "Resource not found")
+		return
+	}
+	writeData(w, 200, resource.payload())
+}
+
+func (server *Server) createResource(w http.ResponseWriter, r *http.Request) {
+	payload, err := readPayload(r)
+	if err != nil {
+		writeError(w, 400, "invalid", err.Error())
+		return
+	}
+	p := server.findProject(payload.related("project"))
+	if p == nil {
+		writeError(w, 400, "invalid", "Project does not exist")
+		return
+	}
+	i18nFormat := payload.related("i18n_format")
+	known := false
+	for _, format := range i18nFormats {
+		known = known || format[0] == i18nFormat
+	}
+	if !known {
+		writeError(w, 400, "invalid", fmt.Sprintf(
+			"'%s' is not a supported i18n format", i18nFormat,
+		))
+		return
+	}
+	slug, _ := payload.Data.Attributes["slug"].(string)
+	name, _ := payload.Data.Attributes["name"].(string)
+	if slug == "" {
+		writeError(w, 400, "invalid", "'slug' is required")
+		return
+	}
+	if name == "" {
+		name = slug
+	}
+	if server.findResource(fmt.Sprintf("%s:r:%s", p.id(), slug)) != nil {
+		writeError(w, 409, "conflict", fmt.Sprintf(
+			"Resource '%s' already exists", slug,
+		))
+		return
+	}
+	var base *resource
+	if baseId := payload.related("base"); baseId != "" {
+		base = server.findResource(baseId)
+		if base == nil {
+			writeError(w, 400, "invalid", "Base resource does not exist")
+			return
+		}
+	}
+	now := time.Now()
+	created := &resource{
+		project:             p,
+		slug:                slug,
+		name:                name,
+		i18nFormat:          i18nFormat,
+		base:                base,
+		created:             now,
+		sourceUpdated:       now,
+		translations:        make(map[string][]byte),
+		translationsUpdated: make(map[string]time.Time),
+	}
+	server.resources = append(server.resources, created)
+	writeData(w, 201, created.payload())
+}
+
+func (server *Server) updateResource(
+	w http.ResponseWriter, r *http.Request, id string,
+) {
+	resource := server.findResource(id)
+	if resource == nil {
+		writeError(w, 404, "not_found", "Resource not found")
+		return
+	}
+	payload, err := readPayload(r)
+	if err != nil {
+		writeError(w, 400, "invalid", err.Error())
+		return
+	}
+	if name, ok := payload.Data.Attributes["name"].(string); ok && name != "" {
+		resource.name = name
+	}
+	if baseId := payload.related("base"); baseId != "" {
+		base := server.findResource(baseId)
+		if base == nil || base == resource {
+			writeError(w, 400, "invalid", "Invalid base resource")
+			return
+		}
+		resource.base = base
+	}
+	writeData(w, 200, resource.payload())
+}
+
+func (server *Server) deleteResource(w http.ResponseWriter, id string) {
+	for i, resource := range server.resources {
+		if resource.id() == id {
+			server.resources = append(
+				server.resources[:i], server.resources[i+1:]...,
+			)
+			w.WriteHeader(204)
+			return
+		}
+	}
+	writeError(w, 404, "not_found", "Resource not found")
+}
+
+func (server *Server) listStats(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	p := server.findProject(query.Get("filter[project]"))
+	if p == nil {
+		writeError(w, 400, "invalid", "'filter[project]' is invalid")
+		return
+	}
+	languageCode := strings.TrimPrefix(query.Get("filter[language]"), "l:")
+	var items []interface{}
+	for _, resource := range server.resources {
+		if resource.project != p {
+			continue
+		}
+		if id := query.Get("filter[resource]"); id != "" && id != resource.id() {
+			continue
+		}
+		for _, code := range append([]string{p.sourceLanguage}, p.languages...) {
+			if languageCode == "" || languageCode == code {
+				items = append(items, resource.statsPayload(code))
+			}
+		}
+	}
+	server.writePage(w, r, items)
+}
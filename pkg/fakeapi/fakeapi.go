@@ -0,0 +1,297 @@
+/*
+Package fakeapi
+A stateful, in-memory fake of the subset of the Transifex REST API (v3) that
+the client uses: organizations, projects and their languages, languages,
+resources, resource language stats, i18n formats, async source and
+translation uploads and downloads (with redirects to the downloaded files)
+and resource merges. It is meant for running the client end-to-end without
+network access or a Transifex account.
+
+Async jobs finish the first time they are polled. Uploaded files are kept
+as they are and downloads return them: translations that were never uploaded
+(and pseudo-translations) are downloaded as the source file. Strings are
+counted by flattening JSON files or, for other formats, by counting non-empty
+lines that don't start with '#'.
+
+Usage:
+
+	server := fakeapi.New()
+	server.AddOrganization("myorg")
+	server.AddProject("myorg", "myproject", "en", "el", "fr")
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+	api := jsonapi.Connection{Host: ts.URL, Token: "anything", ...}
+*/
+package fakeapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Server struct {
+	// If set, requests need to be authenticated with this token; otherwise
+	// any token is accepted
+	Token string
+
+	// Respond to every Nth request that creates an async job with '429 Too
+	// Many Requests', to simulate throttling; 0 disables throttling
+	ThrottleEvery int
+	// The 'Retry-After' header of throttled responses, in seconds
+	RetryAfter int
+
+	// How many items to return per page of a list
+	PageSize int
+
+	// If set, a line is written here for every request
+	Log io.Writer
+
+	mutex         sync.Mutex
+	organizations []*organization
+	projects      []*project
+	resources     []*resource
+	languages     []*language
+	jobs          map[string]*job
+	files         map[string][]byte
+	jobCount      int
+	counter       int
+}
+
+func New() *Server {
+	server := &Server{
+		RetryAfter: 1,
+		PageSize:   100,
+		jobs:       make(map[string]*job),
+		files:      make(map[string][]byte),
+	}
+	for _, code := range defaultLanguages {
+		server.languages = append(
+			server.languages, &language{code: code[0], name: code[1]},
+		)
+	}
+	return server
+}
+
+// Like http.Error, but with a {json:api} error payload
+func writeError(w http.ResponseWriter, status int, code, detail string) {
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"errors": []map[string]string{{
+			"status": strconv.Itoa(status),
+			"code":   code,
+			"title":  http.StatusText(status),
+			"detail": detail,
+		}},
+	})
+}
+
+func writeData(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (recorder *statusRecorder) WriteHeader(status int) {
+	recorder.status = status
+	recorder.ResponseWriter.WriteHeader(status)
+}
+
+func (server *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	recorder := &statusRecorder{ResponseWriter: w, status: 200}
+	server.mutex.Lock()
+	server.serve(recorder, r)
+	server.mutex.Unlock()
+	if server.Log != nil {
+		fmt.Fprintf(
+			server.Log, "%s %s %s - %d\n",
+			time.Now().Format("15:04:05"), r.Method, r.URL.RequestURI(),
+			recorder.status,
+		)
+	}
+}
+
+func (server *Server) serve(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	for i, part := range parts {
+		parts[i], _ = url.PathUnescape(part)
+	}
+
+	// Downloaded files are served without authentication, like the URLs the
+	// real API redirects to
+	if len(parts) == 2 && parts[0] == "_files" && r.Method == "GET" {
+		content, exists := server.files[parts[1]]
+		if !exists {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(content)
+		return
+	}
+
+	if server.Token != "" &&
+		r.Header.Get("Authorization") != "Bearer "+server.Token {
+		writeError(w, 401, "unauthorized", "Authentication credentials "+
+			"were not provided or are invalid")
+		return
+	}
+
+	if r.Method == "POST" && len(parts) == 1 &&
+		strings.Contains(parts[0], "_async_") {
+		server.jobCount++
+		if server.ThrottleEvery > 0 &&
+			server.jobCount%server.ThrottleEvery == 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(server.RetryAfter))
+			writeError(w, 429, "throttled", "Request was throttled")
+			return
+		}
+	}
+
+	// Eg "GET projects/*/languages"; the '*' is always parts[1]
+	route := fmt.Sprintf("%s %s", r.Method, parts[0])
+	if len(parts) > 1 {
+		route = strings.Join(append([]string{route, "*"}, parts[2:]...), "/")
+	}
+
+	switch route {
+	case "GET organizations":
+		server.listOrganizations(w, r)
+	case "GET organizations/*":
+		server.getOrganization(w, parts[1])
+	case "GET projects":
+		server.listProjects(w, r)
+	case "GET projects/*":
+		server.getProject(w, parts[1])
+	case "GET projects/*/languages":
+		server.listProjectLanguages(w, r, parts[1])
+	case "POST projects/*/relationships/languages",
+		"PATCH projects/*/relationships/languages",
+		"DELETE projects/*/relationships/languages":
+		server.changeProjectLanguages(w, r, parts[1])
+	case "GET languages":
+		server.listLanguages(w, r)
+	case "GET languages/*":
+		server.getLanguage(w, parts[1])
+	case "GET i18n_formats":
+		server.listI18nFormats(w, r)
+	case "GET resources":
+		server.listResources(w, r)
+	case "POST resources":
+		server.createResource(w, r)
+	case "GET resources/*":
+		server.getResource(w, parts[1])
+	case "PATCH resources/*":
+		server.updateResource(w, r, parts[1])
+	case "DELETE resources/*":
+		server.deleteResource(w, parts[1])
+	case "GET resource_language_stats":
+		server.listStats(w, r)
+	case "POST resource_strings_async_uploads":
+		server.createSourceUpload(w, r)
+	case "POST resource_translations_async_uploads":
+		server.createTranslationUpload(w, r)
+	case "POST resource_strings_async_downloads":
+		server.createDownload(w, r, false)
+	case "POST resource_translations_async_downloads":
+		server.createDownload(w, r, true)
+	case "POST resource_async_merges":
+		server.createMerge(w, r)
+	case "GET resource_strings_async_uploads/*",
+		"GET resource_translations_async_uploads/*",
+		"GET resource_strings_async_downloads/*",
+		"GET resource_translations_async_downloads/*",
+		"GET resource_async_merges/*":
+		server.pollJob(w, parts[0], parts[1])
+	default:
+		writeError(w, 404, "not_found", fmt.Sprintf(
+			"'%s %s' is not supported", r.Method, r.URL.Path,
+		))
+	}
+}
+
+/*
+Write the page of 'items' that the 'page[cursor]' parameter of the request
+asks for, with a link to the next one if there is one
+*/
+func (server *Server) writePage(
+	w http.ResponseWriter, r *http.Request, items []interface{},
+) {
+	offset, _ := strconv.Atoi(r.URL.Query().Get("page[cursor]"))
+	if offset < 0 || offset > len(items) {
+		offset = len(items)
+	}
+	end := offset + server.PageSize
+	if server.PageSize <= 0 || end > len(items) {
+		end = len(items)
+	}
+	links := map[string]string{}
+	if end < len(items) {
+		query := r.URL.Query()
+		query.Set("page[cursor]", strconv.Itoa(end))
+		links["next"] = fmt.Sprintf("%s?%s", r.URL.Path, query.Encode())
+	}
+	page := items[offset:end]
+	if page == nil {
+		page = []interface{}{}
+	}
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"data": page, "links": links,
+	})
+}
+
+// The {json:api} document of a request's body
+type requestPayload struct {
+	Data struct {
+		Type          string                 `json:"type"`
+		Id            string                 `json:"id"`
+		Attributes    map[string]interface{} `json:"attributes"`
+		Relationships map[string]struct {
+			Data *identifier `json:"data"`
+		} `json:"relationships"`
+	} `json:"data"`
+}
+
+type identifier struct {
+	Type string `json:"type"`
+	Id   string `json:"id"`
+}
+
+func readPayload(r *http.Request) (*requestPayload, error) {
+	var payload requestPayload
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payload: %w", err)
+	}
+	return &payload, nil
+}
+
+// The ID of the singular relationship 'key' of 'payload', if it is set
+func (payload *requestPayload) related(key string) string {
+	relationship, exists := payload.Data.Relationships[key]
+	if !exists || relationship.Data == nil {
+		return ""
+	}
+	return relationship.Data.Id
+}
+
+func (server *Server) nextId(prefix string) string {
+	server.counter++
+	return fmt.Sprintf("%s_%d", prefix, server.counter)
+}
+
+func formatTime(value time.Time) string {
+	return value.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,217 @@
+package fakeapi
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/transifex/cli/pkg/jsonapi"
+	"github.com/transifex/cli/pkg/txapi"
+)
+
+func getTestConnection(t *testing.T, server *Server) *jsonapi.Connection {
+	t.Helper()
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+	return &jsonapi.Connection{Host: ts.URL, Token: "secret"}
+}
+
+func getTestFile(t *testing.T, content string) *jsonapi.File {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "file")
+	err := os.WriteFile(path, []byte(content), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &jsonapi.File{Path: path}
+}
+
+func TestProjectsAndResources(t *testing.T) {
+	server := New()
+	server.PageSize = 1
+	server.AddProject("org", "proj", "en", "el", "fr")
+	api := getTestConnection(t, server)
+
+	organization, err := txapi.GetOrganization(api, "org")
+	if err != nil || organization == nil {
+		t.Fatalf("Could not get organization: %v", err)
+	}
+	project, err := txapi.GetProject(api, organization, "proj")
+	if err != nil || project == nil {
+		t.Fatalf("Could not get project: %v", err)
+	}
+	languages, err := txapi.GetProjectLanguages(project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(languages) != 2 || languages["el"] == nil || languages["fr"] == nil {
+		t.Errorf("Got wrong project languages %v", languages)
+	}
+
+	for _, slug := range []string{"a", "b"} {
+		_, err = txapi.CreateResource(
+			api, project.Id, slug, slug, "KEYVALUEJSON", "",
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err = txapi.CreateResource(api, project.Id, "a", "a", "KEYVALUEJSON", "")
+	if err == nil {
+		t.Error("Did not get error when creating a resource twice")
+	}
+
+	// With a page size of 1, this follows the 'next' links
+	resources, err := txapi.GetResources(api, project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resources) != 2 {
+		t.Errorf("Got %d resources, expected 2", len(resources))
+	}
+	resource, err := txapi.GetResourceById(api, "o:org:p:proj:r:b")
+	if err != nil || resource == nil {
+		t.Fatalf("Could not get resource: %v", err)
+	}
+	stats, err := txapi.GetResourceStats(api, resource, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 3 || stats["l:en"] == nil || stats["l:el"] == nil {
+		t.Errorf("Got wrong stats %v", stats)
+	}
+
+	err = txapi.DeleteResource(api, resource)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resource, err = txapi.GetResourceById(api, "o:org:p:proj:r:b")
+	if err != nil || resource != nil {
+		t.Errorf("Resource was not deleted: %v, %v", resource, err)
+	}
+}
+
+func TestUploadAndDownload(t *testing.T) {
+	server := New()
+	server.AddProject("org", "proj", "en", "el")
+	err := server.AddResource(
+		"o:org:p:proj", "res", "KEYVALUEJSON", []byte(`{"a": "A", "b": "B"}`),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := getTestConnection(t, server)
+	resource, err := txapi.GetResourceById(api, "o:org:p:proj:r:res")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	upload, err := txapi.UploadSource(
+		api, resource,
+		getTestFile(t, `{"a": "A", "b": "BB", "c": "C"}`),
+		false, false,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = txapi.PollSourceUpload(context.Background(), upload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var attributes txapi.ResourceStringAsyncUploadAttributes
+	err = upload.MapAttributes(&attributes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attributes.Details.StringsCreated != 1 ||
+		attributes.Details.StringsUpdated != 1 ||
+		attributes.Details.StringsSkipped != 1 {
+		t.Errorf("Got wrong upload details %+v", attributes.Details)
+	}
+
+	err = server.SetTranslationFile(
+		"o:org:p:proj:r:res", "el", []byte(`{"a": "Α"}`),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats, err := txapi.GetResourceStats(api, resource, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var statsAttributes txapi.ResourceLanguageStatsAttributes
+	err = stats["l:el"].MapAttributes(&statsAttributes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if statsAttributes.TotalStrings != 3 || statsAttributes.TranslatedStrings != 1 {
+		t.Errorf("Got wrong stats %+v", statsAttributes)
+	}
+
+	download, err := txapi.CreateResourceStringsAsyncDownload(
+		api, resource, "text", "default", false,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := txapi.GetResourceStringsDownloadContent(
+		context.Background(), download,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != `{"a": "A", "b": "BB", "c": "C"}` {
+		t.Errorf("Downloaded wrong source file '%s'", content)
+	}
+
+	upload, err = txapi.UploadSource(
+		api, resource, getTestFile(t, `{"a": `), false, false,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = txapi.PollSourceUpload(context.Background(), upload)
+	if err == nil {
+		t.Error("Did not get error when uploading an invalid file")
+	}
+}
+
+func TestAuthenticationAndThrottling(t *testing.T) {
+	server := New()
+	server.Token = "other"
+	server.AddProject("org", "proj", "en")
+	err := server.AddResource("o:org:p:proj", "res", "PO", []byte("a\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := getTestConnection(t, server)
+
+	_, err = txapi.GetResourceById(api, "o:org:p:proj:r:res")
+	var apiError *jsonapi.Error
+	if !errors.As(err, &apiError) || apiError.StatusCode != 401 {
+		t.Errorf("Did not get authentication error: %v", err)
+	}
+
+	server.Token = "secret"
+	server.ThrottleEvery = 2
+	resource, err := txapi.GetResourceById(api, "o:org:p:proj:r:res")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = txapi.CreateResourceStringsAsyncDownload(
+		api, resource, "text", "default", false,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = txapi.CreateResourceStringsAsyncDownload(
+		api, resource, "text", "default", false,
+	)
+	var throttleError *jsonapi.ThrottleError
+	if !errors.As(err, &throttleError) || throttleError.RetryAfter != 1 {
+		t.Errorf("Did not get throttled: %v", err)
+	}
+}
@@ -0,0 +1,309 @@
+package fakeapi
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+/*
+job
+An async upload, download or merge. Its work is done the first time it is
+polled, so that, like with the real API, its effects (eg updated stats) are
+only visible once the client has seen it finish.
+*/
+type job struct {
+	kind         string
+	id           string
+	resource     *resource
+	languageCode string
+	created      time.Time
+	done         bool
+
+	// Uploads
+	content []byte
+	details map[string]int
+	errors  []map[string]string
+
+	// Downloads
+	pseudo          bool
+	contentEncoding string
+}
+
+func (j *job) status() string {
+	if !j.done {
+		return "pending"
+	} else if len(j.errors) > 0 {
+		return "failed"
+	} else if j.kind == "resource_async_merges" {
+		return "COMPLETED"
+	}
+	return "succeeded"
+}
+
+func (j *job) payload() map[string]interface{} {
+	attributes := map[string]interface{}{
+		"status":        j.status(),
+		"date_created":  formatTime(j.created),
+		"date_modified": formatTime(j.created),
+	}
+	if j.details != nil {
+		attributes["details"] = j.details
+	}
+	if j.errors != nil {
+		attributes["errors"] = j.errors
+	}
+	relationships := map[string]interface{}{
+		"resource": singular("resources", j.resource.id()),
+	}
+	if j.languageCode != "" {
+		relationships["language"] = singular(
+			"languages", fmt.Sprintf("l:%s", j.languageCode),
+		)
+	}
+	return map[string]interface{}{
+		"type":          j.kind,
+		"id":            j.id,
+		"attributes":    attributes,
+		"relationships": relationships,
+	}
+}
+
+func (server *Server) addJob(w http.ResponseWriter, j *job) {
+	j.id = server.nextId(strings.TrimPrefix(j.kind, "resource_"))
+	j.created = time.Now()
+	server.jobs[j.id] = j
+	writeData(w, 202, j.payload())
+}
+
+/*
+Read the multipart payload of an upload: the file in 'content' and the
+resource (and, for translations, the language) it is for
+*/
+func (server *Server) readUpload(
+	w http.ResponseWriter, r *http.Request, withLanguage bool,
+) (*resource, string, []byte, bool) {
+	file, _, err := r.FormFile("content")
+	if err != nil {
+		writeError(w, 400, "invalid", "'content' is required")
+		return nil, "", nil, false
+	}
+	defer file.Close()
+	content, err := io.ReadAll(file)
+	if err != nil {
+		writeError(w, 400, "invalid", err.Error())
+		return nil, "", nil, false
+	}
+	target := server.findResource(r.FormValue("resource"))
+	if target == nil {
+		writeError(w, 400, "invalid", "Resource does not exist")
+		return nil, "", nil, false
+	}
+	languageCode := ""
+	if withLanguage {
+		languageCode = strings.TrimPrefix(r.FormValue("language"), "l:")
+		if !target.project.hasLanguage(languageCode) {
+			writeError(w, 400, "invalid", fmt.Sprintf(
+				"Language '%s' is not a target language of the project",
+				languageCode,
+			))
+			return nil, "", nil, false
+		}
+	}
+	return target, languageCode, content, true
+}
+
+func (p *project) hasLanguage(code string) bool {
+	for _, existing := range p.languages {
+		if existing == code {
+			return true
+		}
+	}
+	return false
+}
+
+func (server *Server) createSourceUpload(w http.ResponseWriter, r *http.Request) {
+	target, _, content, ok := server.readUpload(w, r, false)
+	if ok {
+		server.addJob(w, &job{
+			kind:     "resource_strings_async_uploads",
+			resource: target,
+			content:  content,
+		})
+	}
+}
+
+func (server *Server) createTranslationUpload(
+	w http.ResponseWriter, r *http.Request,
+) {
+	target, languageCode, content, ok := server.readUpload(w, r, true)
+	if ok {
+		server.addJob(w, &job{
+			kind:         "resource_translations_async_uploads",
+			resource:     target,
+			languageCode: languageCode,
+			content:      content,
+		})
+	}
+}
+
+func (server *Server) createDownload(
+	w http.ResponseWriter, r *http.Request, translations bool,
+) {
+	payload, err := readPayload(r)
+	if err != nil {
+		writeError(w, 400, "invalid", err.Error())
+		return
+	}
+	target := server.findResource(payload.related("resource"))
+	if target == nil {
+		writeError(w, 400, "invalid", "Resource does not exist")
+		return
+	}
+	kind := "resource_strings_async_downloads"
+	languageCode := ""
+	if translations {
+		kind = "resource_translations_async_downloads"
+		languageCode = strings.TrimPrefix(payload.related("language"), "l:")
+		if languageCode != target.project.sourceLanguage &&
+			!target.project.hasLanguage(languageCode) {
+			writeError(w, 400, "invalid", fmt.Sprintf(
+				"Language '%s' is not a language of the project",
+				languageCode,
+			))
+			return
+		}
+	}
+	pseudo, _ := payload.Data.Attributes["pseudo"].(bool)
+	contentEncoding, _ := payload.Data.Attributes["content_encoding"].(string)
+	server.addJob(w, &job{
+		kind:            kind,
+		resource:        target,
+		languageCode:    languageCode,
+		pseudo:          pseudo,
+		contentEncoding: contentEncoding,
+	})
+}
+
+func (server *Server) createMerge(w http.ResponseWriter, r *http.Request) {
+	payload, err := readPayload(r)
+	if err != nil {
+		writeError(w, 400, "invalid", err.Error())
+		return
+	}
+	target := server.findResource(payload.related("resource"))
+	if target == nil {
+		writeError(w, 400, "invalid", "Resource does not exist")
+		return
+	}
+	if target.base == nil {
+		writeError(w, 400, "invalid", "Resource is not a branch of another "+
+			"resource")
+		return
+	}
+	server.addJob(w, &job{kind: "resource_async_merges", resource: target})
+}
+
+func (server *Server) pollJob(w http.ResponseWriter, kind, id string) {
+	j, exists := server.jobs[id]
+	if !exists || j.kind != kind {
+		writeError(w, 404, "not_found", "Job not found")
+		return
+	}
+	if !j.done {
+		j.done = true
+		server.runJob(j)
+	}
+	if strings.HasSuffix(kind, "_downloads") && j.status() == "succeeded" {
+		w.Header().Set("Location", fmt.Sprintf("/_files/%s", j.id))
+		w.WriteHeader(303)
+		return
+	}
+	writeData(w, 200, j.payload())
+}
+
+func (server *Server) runJob(j *job) {
+	target := j.resource
+	now := time.Now()
+	switch j.kind {
+	case "resource_strings_async_uploads":
+		if isJSONFormat(target.i18nFormat) && !json.Valid(j.content) {
+			j.errors = []map[string]string{{
+				"code":   "parse_error",
+				"detail": "The file is not valid JSON",
+			}}
+			return
+		}
+		previous := countStrings(target.source)
+		current := countStrings(j.content)
+		j.details = map[string]int{
+			"strings_created": 0, "strings_updated": 0,
+			"strings_deleted": 0, "strings_skipped": 0,
+		}
+		for key, value := range current {
+			if old, exists := previous[key]; !exists {
+				j.details["strings_created"]++
+			} else if old != value {
+				j.details["strings_updated"]++
+			} else {
+				j.details["strings_skipped"]++
+			}
+		}
+		for key := range previous {
+			if _, exists := current[key]; !exists {
+				j.details["strings_deleted"]++
+			}
+		}
+		target.source = j.content
+		target.sourceUpdated = now
+
+	case "resource_translations_async_uploads":
+		source := countStrings(target.source)
+		previous := countStrings(target.translations[j.languageCode])
+		j.details = map[string]int{
+			"translations_created": 0, "translations_updated": 0,
+		}
+		for key, value := range countStrings(j.content) {
+			if _, exists := source[key]; !exists {
+				continue
+			}
+			if old, exists := previous[key]; !exists {
+				j.details["translations_created"]++
+			} else if old != value {
+				j.details["translations_updated"]++
+			}
+		}
+		target.translations[j.languageCode] = j.content
+		target.translationsUpdated[j.languageCode] = now
+
+	case "resource_strings_async_downloads",
+		"resource_translations_async_downloads":
+		content := target.source
+		if translation, exists := target.translations[j.languageCode]; exists &&
+			!j.pseudo {
+			content = translation
+		}
+		if j.contentEncoding == "base64" {
+			content = []byte(base64.StdEncoding.EncodeToString(content))
+		}
+		server.files[j.id] = content
+
+	case "resource_async_merges":
+		base := target.base
+		base.source = target.source
+		base.sourceUpdated = now
+		for languageCode, content := range target.translations {
+			base.translations[languageCode] = content
+			base.translationsUpdated[languageCode] = now
+		}
+	}
+}
+
+func isJSONFormat(i18nFormat string) bool {
+	return i18nFormat == "KEYVALUEJSON" || i18nFormat == "STRUCTURED_JSON" ||
+		i18nFormat == "CHROME"
+}
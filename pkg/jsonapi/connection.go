@@ -2,6 +2,7 @@ package jsonapi
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -14,6 +15,11 @@ type Connection struct {
 	Token   string
 	Client  http.Client
 	Headers map[string]string
+	Retry   *RetryPolicy
+
+	// If set, cancelling it aborts requests that are in progress (and stops
+	// retries)
+	Context context.Context
 
 	// Used for testing
 	RequestMethod func(method, path string,
@@ -25,9 +31,57 @@ func (c *Connection) request(
 	path string,
 	payload []byte,
 	contentType string,
+) ([]byte, error) {
+	return c.streamRequest(
+		method,
+		path,
+		func() (io.ReadCloser, int64, error) {
+			return io.NopCloser(bytes.NewReader(payload)), int64(len(payload)), nil
+		},
+		contentType,
+	)
+}
+
+/*
+Like 'request', but the payload is read from the reader returned by 'open'
+while the request is being sent. 'open' is called once for every attempt and
+must also return the size of the payload, or -1 if it is not known.
+*/
+func (c *Connection) streamRequest(
+	method,
+	path string,
+	open func() (io.ReadCloser, int64, error),
+	contentType string,
+) ([]byte, error) {
+	var body []byte
+	err := c.Retry.Do(c.getContext(), method, func() error {
+		payload, size, err := open()
+		if err != nil {
+			return err
+		}
+		defer payload.Close()
+		body, err = c.requestOnce(method, path, payload, size, contentType)
+		return err
+	})
+	return body, err
+}
+
+func (c *Connection) requestOnce(
+	method,
+	path string,
+	payload io.Reader,
+	size int64,
+	contentType string,
 ) ([]byte, error) {
 	if c.RequestMethod != nil {
-		return c.RequestMethod(method, path, payload, contentType)
+		data, err := io.ReadAll(payload)
+		if err != nil {
+			return nil, err
+		}
+		if len(data) == 0 {
+			data = nil
+		}
+		return c.RequestMethod(method, path, data, contentType)
 	}
 
 	if strings.HasPrefix(path, "/") {
@@ -42,10 +96,16 @@ func (c *Connection) request(
 		}
 	}
 
-	requestObj, err := http.NewRequest(method, path, bytes.NewReader(payload))
+	requestObj, err := http.NewRequestWithContext(
+		c.getContext(), method, path, payload,
+	)
 	if err != nil {
 		return nil, err
 	}
+	requestObj.ContentLength = size
+	if size == 0 {
+		requestObj.Body = http.NoBody
+	}
 
 	if contentType == "" {
 		contentType = "application/vnd.api+json"
@@ -72,12 +132,75 @@ func (c *Connection) request(
 
 	errorResponse := parseErrorResponse(response.StatusCode, body)
 	if errorResponse != nil {
+		errorResponse.RetryAfter = parseRetryAfter(response)
 		return nil, errorResponse
 	}
 
 	return body, nil
 }
 
+/*
+Download
+Returns the contents of an external URL, like the ones the API redirects to
+when a file download is ready. Authentication headers are not sent, redirects
+are followed and failures are retried according to the connection's retry
+policy.
+*/
+func (c *Connection) Download(url string) ([]byte, error) {
+	var body []byte
+	err := c.DownloadTo(url, func(reader io.Reader) error {
+		var err error
+		body, err = io.ReadAll(reader)
+		return err
+	}, nil)
+	return body, err
+}
+
+/*
+DownloadTo
+Like 'Download', but instead of returning the contents of the URL, it passes
+a reader for them to 'write' as they are being received, reporting the
+progress to 'progress' if set. If the attempt fails and is retried, 'write'
+will be called again with a new reader, so it should discard anything it did
+with the previous one.
+*/
+func (c *Connection) DownloadTo(
+	url string, write func(io.Reader) error, progress ProgressFunc,
+) error {
+	client := http.Client{Transport: c.Client.Transport, Timeout: c.Client.Timeout}
+	return c.Retry.Do(c.getContext(), "GET", func() error {
+		request, err := http.NewRequestWithContext(
+			c.getContext(), "GET", url, nil,
+		)
+		if err != nil {
+			return err
+		}
+		response, err := client.Do(request)
+		if err != nil {
+			return err
+		}
+		defer response.Body.Close()
+		if response.StatusCode != 200 {
+			return &Error{
+				StatusCode: response.StatusCode,
+				RetryAfter: parseRetryAfter(response),
+			}
+		}
+		return write(&progressReader{
+			reader:   response.Body,
+			total:    response.ContentLength,
+			progress: progress,
+		})
+	})
+}
+
+func (c *Connection) getContext() context.Context {
+	if c.Context == nil {
+		return context.Background()
+	}
+	return c.Context
+}
+
 /*
 Get
 Returns a Resource instance from the server based on its 'type' and 'id'
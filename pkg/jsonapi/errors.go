@@ -31,6 +31,9 @@ Example:
 type Error struct {
 	StatusCode int
 	Errors     []ErrorItem `json:"errors"`
+
+	// Seconds, from the 'Retry-After' header if the server sent one
+	RetryAfter int `json:"-"`
 }
 
 type ErrorItem struct {
@@ -95,3 +98,11 @@ func parseThrottleResponse(response *http.Response) *ThrottleError {
 	}
 	return &ThrottleError{retryAfter}
 }
+
+func parseRetryAfter(response *http.Response) int {
+	retryAfter, err := strconv.Atoi(response.Header.Get("Retry-After"))
+	if err != nil || retryAfter < 0 {
+		return 0
+	}
+	return retryAfter
+}
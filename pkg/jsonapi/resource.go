@@ -1,11 +1,9 @@
 package jsonapi
 
 import (
-	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"mime/multipart"
 	"strconv"
 )
 
@@ -178,9 +176,8 @@ func (r *Resource) SaveAsMultipart(fields []string) error {
 		method = "POST"
 		url = fmt.Sprintf("/%s", r.Type)
 	}
-	var payload bytes.Buffer
-	writer := multipart.NewWriter(&payload)
-	defer writer.Close()
+	builder := newMultipartBuilder()
+	writer := builder.writer
 
 	for _, field := range fields {
 		attribute, attributeExists := r.Attributes[field]
@@ -207,6 +204,11 @@ func (r *Resource) SaveAsMultipart(fields []string) error {
 				if err != nil {
 					return nil
 				}
+			case *File:
+				err := builder.addFile(field, data)
+				if err != nil {
+					return err
+				}
 			default:
 				return fmt.Errorf("field %s is not of type string or bytes",
 					field)
@@ -224,13 +226,13 @@ func (r *Resource) SaveAsMultipart(fields []string) error {
 			return fmt.Errorf("field %s is invalid", field)
 		}
 	}
-	err := writer.Close()
+	payload, err := builder.close()
 	if err != nil {
 		return err
 	}
 
-	body, err := r.API.request(
-		method, url, payload.Bytes(),
+	body, err := r.API.streamRequest(
+		method, url, payload.open,
 		fmt.Sprintf("multipart/form-data;boundary=%s", writer.Boundary()),
 	)
 
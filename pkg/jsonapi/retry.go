@@ -0,0 +1,139 @@
+package jsonapi
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"syscall"
+	"time"
+)
+
+var DefaultRetryStatusCodes = []int{500, 502, 503, 504}
+
+/*
+RetryPolicy
+Describes how requests that fail because of transient errors (5xx responses,
+connection resets, timeouts) are retried. The delay between attempts grows
+exponentially, starting from BaseDelay and capped to MaxDelay, with some random
+jitter so that parallel workers don't retry in lockstep. If the server sends a
+'Retry-After' header, it is respected instead.
+
+429 responses are not retried unless 429 is in StatusCodes; by default they are
+left to the caller (see ThrottleError).
+
+Only requests with idempotent methods (GET, HEAD, PUT, DELETE) are retried on
+transient errors, since a POST or PATCH may have reached the server even if its
+response was lost. Those are only retried on 429 responses, unless
+RetryNonIdempotent is set.
+*/
+type RetryPolicy struct {
+	MaxAttempts        int
+	BaseDelay          time.Duration
+	MaxDelay           time.Duration
+	StatusCodes        []int
+	RetryNonIdempotent bool
+}
+
+func NewRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts: 4,
+		BaseDelay:   time.Second,
+		MaxDelay:    30 * time.Second,
+		StatusCodes: DefaultRetryStatusCodes,
+	}
+}
+
+/*
+Do
+Calls 'do', which sends a request with 'method', until it succeeds, fails with
+an error that is not retryable, the attempts are exhausted or 'ctx' is
+cancelled. A nil policy makes a single attempt.
+*/
+func (policy *RetryPolicy) Do(
+	ctx context.Context, method string, do func() error,
+) error {
+	for attempt := 1; ; attempt++ {
+		err := do()
+		if err == nil || policy == nil || attempt >= policy.MaxAttempts ||
+			ctx.Err() != nil || !policy.ShouldRetry(method, err) {
+			return err
+		}
+		select {
+		case <-time.After(policy.Delay(attempt, err)):
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
+
+func (policy *RetryPolicy) ShouldRetry(method string, err error) bool {
+	var throttleError *ThrottleError
+	if errors.As(err, &throttleError) {
+		return policy.retryStatusCode(429)
+	}
+	if !isIdempotent(method) && !policy.RetryNonIdempotent {
+		return false
+	}
+	var apiError *Error
+	if errors.As(err, &apiError) {
+		return policy.retryStatusCode(apiError.StatusCode)
+	}
+	var netError net.Error
+	if errors.As(err, &netError) && netError.Timeout() {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.EOF)
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case "GET", "HEAD", "PUT", "DELETE":
+		return true
+	}
+	return false
+}
+
+func (policy *RetryPolicy) retryStatusCode(statusCode int) bool {
+	for _, code := range policy.StatusCodes {
+		if code == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+Delay
+How long to wait after the 'attempt'th attempt failed with 'err'.
+*/
+func (policy *RetryPolicy) Delay(attempt int, err error) time.Duration {
+	var retryAfter int
+	var throttleError *ThrottleError
+	var apiError *Error
+	if errors.As(err, &throttleError) {
+		retryAfter = throttleError.RetryAfter
+	} else if errors.As(err, &apiError) {
+		retryAfter = apiError.RetryAfter
+	}
+	if retryAfter > 0 {
+		return time.Duration(retryAfter) * time.Second
+	}
+
+	delay := policy.BaseDelay
+	for i := 1; i < attempt && delay < policy.MaxDelay; i++ {
+		delay *= 2
+	}
+	if policy.MaxDelay > 0 && delay > policy.MaxDelay {
+		delay = policy.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	// Wait somewhere between half and all of the computed delay
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
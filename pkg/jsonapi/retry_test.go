@@ -0,0 +1,217 @@
+package jsonapi
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func getTestRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   time.Millisecond,
+		MaxDelay:    time.Millisecond,
+		StatusCodes: DefaultRetryStatusCodes,
+	}
+}
+
+func TestRetryOnServerError(t *testing.T) {
+	mockData := MockData{
+		"/students/1": &MockEndpoint{
+			Requests: []MockRequest{
+				{Response: MockResponse{Status: 502}},
+				{Response: MockResponse{Status: 503}},
+				{Response: MockResponse{
+					Text: `{"data": {"type": "students", "id": "1"}}`,
+				}},
+			},
+		},
+	}
+	api := GetTestConnection(mockData)
+	api.Retry = getTestRetryPolicy()
+
+	student, err := api.Get("students", "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if student.Id != "1" {
+		t.Errorf("Got wrong student: %s", student.Id)
+	}
+	if mockData["/students/1"].Count != 3 {
+		t.Errorf("Expected 3 attempts, got %d", mockData["/students/1"].Count)
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	mockData := MockData{
+		"/students/1": &MockEndpoint{
+			Requests: []MockRequest{
+				{Response: MockResponse{Status: 502}},
+				{Response: MockResponse{Status: 502}},
+				{Response: MockResponse{Status: 502}},
+				{Response: MockResponse{Text: `{"data": {}}`}},
+			},
+		},
+	}
+	api := GetTestConnection(mockData)
+	api.Retry = getTestRetryPolicy()
+
+	_, err := api.Get("students", "1")
+	var e *Error
+	if !errors.As(err, &e) || e.StatusCode != 502 {
+		t.Errorf("Expected a 502 error, got %v", err)
+	}
+	if mockData["/students/1"].Count != 3 {
+		t.Errorf("Expected 3 attempts, got %d", mockData["/students/1"].Count)
+	}
+}
+
+func TestNoRetryOnClientError(t *testing.T) {
+	mockData := MockData{
+		"/students/1": &MockEndpoint{
+			Requests: []MockRequest{
+				{Response: MockResponse{Status: 404}},
+				{Response: MockResponse{Text: `{"data": {}}`}},
+			},
+		},
+	}
+	api := GetTestConnection(mockData)
+	api.Retry = getTestRetryPolicy()
+
+	_, err := api.Get("students", "1")
+	if err == nil {
+		t.Error("Expected an error")
+	}
+	if mockData["/students/1"].Count != 1 {
+		t.Errorf("Expected 1 attempt, got %d", mockData["/students/1"].Count)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	policy := &RetryPolicy{
+		MaxAttempts: 5,
+		BaseDelay:   time.Second,
+		MaxDelay:    4 * time.Second,
+		StatusCodes: DefaultRetryStatusCodes,
+	}
+	serverError := &Error{StatusCode: 502}
+
+	for attempt, maximum := range map[int]time.Duration{
+		1: time.Second,
+		2: 2 * time.Second,
+		3: 4 * time.Second,
+		4: 4 * time.Second,
+	} {
+		delay := policy.Delay(attempt, serverError)
+		if delay < maximum/2 || delay > maximum {
+			t.Errorf(
+				"Delay for attempt %d should be between %s and %s, got %s",
+				attempt, maximum/2, maximum, delay,
+			)
+		}
+	}
+
+	delay := policy.Delay(1, &Error{StatusCode: 503, RetryAfter: 7})
+	if delay != 7*time.Second {
+		t.Errorf("Retry-After was not respected, got %s", delay)
+	}
+}
+
+func TestDownloadRetries(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			if attempts == 1 {
+				w.WriteHeader(502)
+				return
+			}
+			_, _ = w.Write([]byte("file contents"))
+		},
+	))
+	defer server.Close()
+
+	api := Connection{Retry: getTestRetryPolicy()}
+	body, err := api.Download(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != "file contents" {
+		t.Errorf("Got wrong body: %s", body)
+	}
+	if attempts != 2 {
+		t.Errorf("Expected 2 attempts, got %d", attempts)
+	}
+}
+
+func TestNoRetryOnPostServerError(t *testing.T) {
+	getMockData := func() MockData {
+		return MockData{
+			"/students": &MockEndpoint{
+				Requests: []MockRequest{
+					{Response: MockResponse{Status: 502}},
+					{Response: MockResponse{
+						Text: `{"data": {"type": "students", "id": "1"}}`,
+					}},
+				},
+			},
+		}
+	}
+	mockData := getMockData()
+	api := GetTestConnection(mockData)
+	api.Retry = getTestRetryPolicy()
+
+	_, err := api.request("POST", "/students", []byte("{}"), "")
+	if err == nil {
+		t.Error("Expected an error")
+	}
+	if mockData["/students"].Count != 1 {
+		t.Errorf("Expected 1 attempt, got %d", mockData["/students"].Count)
+	}
+
+	mockData = getMockData()
+	api = GetTestConnection(mockData)
+	api.Retry = getTestRetryPolicy()
+	api.Retry.RetryNonIdempotent = true
+
+	_, err = api.request("POST", "/students", []byte("{}"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mockData["/students"].Count != 2 {
+		t.Errorf("Expected 2 attempts, got %d", mockData["/students"].Count)
+	}
+}
+
+func TestNoRetryOnPostWithLostResponse(t *testing.T) {
+	posts := 0
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == "POST" {
+				posts++
+			}
+			_, _ = io.ReadAll(r.Body)
+			// The request made it, but the connection drops before the
+			// response is sent
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			conn.Close()
+		},
+	))
+	defer server.Close()
+
+	api := Connection{Host: server.URL, Retry: getTestRetryPolicy()}
+	_, err := api.request("POST", "/students", []byte("{}"), "")
+	if err == nil {
+		t.Error("Expected an error")
+	}
+	if posts != 1 {
+		t.Errorf("Expected the POST to be sent once, got %d", posts)
+	}
+}
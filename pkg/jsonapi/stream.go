@@ -0,0 +1,163 @@
+package jsonapi
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"os"
+)
+
+/*
+ProgressFunc
+Called while a request body is being sent or a response body is being
+received, with the number of bytes transferred so far and the total number of
+bytes, or -1 if the total is not known.
+*/
+type ProgressFunc func(transferred, total int64)
+
+type progressReader struct {
+	reader      io.Reader
+	transferred int64
+	total       int64
+	progress    ProgressFunc
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		r.transferred += int64(n)
+		if r.progress != nil {
+			r.progress(r.transferred, r.total)
+		}
+	}
+	return n, err
+}
+
+/*
+File
+Can be used as the value of an attribute saved with 'SaveAsMultipart' so that
+the contents of the file are streamed from disk while the request is being
+sent instead of being loaded in memory. The file is reopened for every
+attempt, so requests with files can be retried.
+*/
+type File struct {
+	Path     string
+	Progress ProgressFunc
+}
+
+/*
+Part of a multipart request body: either bytes that are already in memory
+(field values, part headers, boundaries) or a file
+*/
+type multipartSegment struct {
+	data []byte
+	file *File
+}
+
+type multipartBody struct {
+	segments []multipartSegment
+}
+
+/*
+Open the files of the body and return a reader for the whole body along with
+its size. Closing the reader closes the files.
+*/
+func (body *multipartBody) open() (io.ReadCloser, int64, error) {
+	var readers []io.Reader
+	var files multiCloser
+	var size int64
+	for _, segment := range body.segments {
+		if segment.file == nil {
+			readers = append(readers, bytes.NewReader(segment.data))
+			size += int64(len(segment.data))
+			continue
+		}
+		file, err := os.Open(segment.file.Path)
+		if err != nil {
+			files.Close()
+			return nil, 0, err
+		}
+		files = append(files, file)
+		stat, err := file.Stat()
+		if err != nil {
+			files.Close()
+			return nil, 0, err
+		}
+		readers = append(readers, &progressReader{
+			reader:   file,
+			total:    stat.Size(),
+			progress: segment.file.Progress,
+		})
+		size += stat.Size()
+	}
+	return &multiReadCloser{io.MultiReader(readers...), files}, size, nil
+}
+
+/*
+Helper to build a multipartBody using a multipart.Writer: everything written
+to the writer is kept in memory, except for the files' contents which are
+only referenced
+*/
+type multipartBuilder struct {
+	buffer bytes.Buffer
+	body   multipartBody
+	writer *multipart.Writer
+}
+
+func newMultipartBuilder() *multipartBuilder {
+	builder := &multipartBuilder{}
+	builder.writer = multipart.NewWriter(&builder.buffer)
+	return builder
+}
+
+func (builder *multipartBuilder) addFile(field string, file *File) error {
+	_, err := builder.writer.CreateFormFile(field, fmt.Sprintf("%s.txt", field))
+	if err != nil {
+		return err
+	}
+	builder.flush()
+	builder.body.segments = append(
+		builder.body.segments, multipartSegment{file: file},
+	)
+	return nil
+}
+
+func (builder *multipartBuilder) flush() {
+	if builder.buffer.Len() == 0 {
+		return
+	}
+	data := make([]byte, builder.buffer.Len())
+	copy(data, builder.buffer.Bytes())
+	builder.buffer.Reset()
+	builder.body.segments = append(
+		builder.body.segments, multipartSegment{data: data},
+	)
+}
+
+func (builder *multipartBuilder) close() (*multipartBody, error) {
+	err := builder.writer.Close()
+	if err != nil {
+		return nil, err
+	}
+	builder.flush()
+	return &builder.body, nil
+}
+
+type multiCloser []io.Closer
+
+func (closers multiCloser) Close() error {
+	var result error
+	for _, closer := range closers {
+		err := closer.Close()
+		if err != nil && result == nil {
+			result = err
+		}
+	}
+	return result
+}
+
+type multiReadCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,113 @@
+package jsonapi
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSaveAsMultipartStreamsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "source.json")
+	err := os.WriteFile(path, []byte(`{"hello": "world"}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	attempts := 0
+	var contents, slug string
+	var contentLength int64
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			contentLength = r.ContentLength
+			file, _, err := r.FormFile("content")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			data, _ := io.ReadAll(file)
+			contents = string(data)
+			slug = r.FormValue("slug")
+			if attempts == 1 {
+				w.WriteHeader(502)
+				return
+			}
+			w.WriteHeader(201)
+			_, _ = w.Write([]byte(`{"data": {"type": "uploads", "id": "1"}}`))
+		},
+	))
+	defer server.Close()
+
+	var transferred, total int64
+	// The file has to be read again when the upload is retried
+	api := Connection{Host: server.URL, Retry: getTestRetryPolicy()}
+	api.Retry.RetryNonIdempotent = true
+	upload := Resource{
+		API:  &api,
+		Type: "uploads",
+		Attributes: map[string]interface{}{
+			"slug": "hello",
+			"content": &File{
+				Path: path,
+				Progress: func(t, n int64) {
+					transferred, total = t, n
+				},
+			},
+		},
+	}
+	err = upload.SaveAsMultipart(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if attempts != 2 {
+		t.Errorf("Expected 2 attempts, got %d", attempts)
+	}
+	if contents != `{"hello": "world"}` || slug != "hello" {
+		t.Errorf("Got wrong form: %s, %s", contents, slug)
+	}
+	if contentLength <= int64(len(contents)) {
+		t.Errorf("Content-Length was not set, got %d", contentLength)
+	}
+	if transferred != 18 || total != 18 {
+		t.Errorf("Got wrong progress: %d / %d", transferred, total)
+	}
+	if upload.Id != "1" {
+		t.Errorf("Got wrong id: %s", upload.Id)
+	}
+}
+
+func TestDownloadToReportsProgress(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Length", "13")
+			_, _ = w.Write([]byte("file contents"))
+		},
+	))
+	defer server.Close()
+
+	var transferred, total int64
+	var body strings.Builder
+	api := Connection{}
+	err := api.DownloadTo(
+		server.URL,
+		func(reader io.Reader) error {
+			_, err := io.Copy(&body, reader)
+			return err
+		},
+		func(t, n int64) { transferred, total = t, n },
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body.String() != "file contents" {
+		t.Errorf("Got wrong body: %s", body.String())
+	}
+	if transferred != 13 || total != 13 {
+		t.Errorf("Got wrong progress: %d / %d", transferred, total)
+	}
+}
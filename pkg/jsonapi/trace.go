@@ -0,0 +1,319 @@
+package jsonapi
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// How much of each request and response body is logged when bodies are traced
+const maxTracedBody = 4096
+
+const redacted = "REDACTED"
+
+/*
+TracingTransport
+An http.RoundTripper that logs every request that goes through it to 'Output':
+method, URL, request headers, status, how long it took until the response
+headers arrived and the response headers that identify the request on the
+server's side (eg 'X-Request-Id'). If 'Bodies' is set, the beginning of the
+request and response bodies is logged as well, without affecting how they
+are streamed.
+
+API tokens, headers that look like they carry credentials (eg 'X-Api-Key'),
+the headers in 'SecretHeaders' and the signatures of pre-signed URLs (like the
+ones file downloads redirect to) are redacted, so that the output can be
+shared with support.
+*/
+type TracingTransport struct {
+	Transport http.RoundTripper
+	Output    io.Writer
+	Bodies    bool
+	// Names of headers whose values are always redacted, eg the custom
+	// headers of the configuration, which often hold gateway keys
+	SecretHeaders []string
+
+	mutex sync.Mutex
+	count int
+}
+
+func (t *TracingTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	t.mutex.Lock()
+	t.count++
+	number := t.count
+	t.mutex.Unlock()
+
+	// The values of redacted headers are also hidden if they show up in
+	// bodies or errors
+	var secrets []string
+	authorization := request.Header.Get("Authorization")
+	if strings.HasPrefix(authorization, "Bearer ") {
+		secrets = append(secrets, strings.TrimPrefix(authorization, "Bearer "))
+	}
+	for name, values := range request.Header {
+		if t.isSecretHeader(name) {
+			secrets = append(secrets, values...)
+		}
+	}
+
+	var lines []string
+	lines = append(lines, fmt.Sprintf(
+		"--> #%d %s %s", number, request.Method, redactURL(request.URL),
+	))
+	lines = append(lines, t.formatHeaders(request.Header)...)
+	if t.Bodies && request.Body != nil && request.Body != http.NoBody {
+		// Don't change the caller's request, as the RoundTripper contract
+		// requires
+		request = request.Clone(request.Context())
+		var prefix []byte
+		prefix, request.Body = peekBody(request.Body)
+		lines = append(
+			lines, formatBody(prefix, request.ContentLength, secrets)...,
+		)
+	}
+	t.write(lines)
+
+	start := time.Now()
+	transport := t.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	response, err := transport.RoundTrip(request)
+	duration := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		t.write([]string{fmt.Sprintf(
+			"<-- #%d error after %s: %s",
+			number, duration, redactBody(err.Error(), secrets),
+		)})
+		return nil, err
+	}
+
+	lines = []string{fmt.Sprintf(
+		"<-- #%d %s (%s) %s %s",
+		number, response.Status, duration, request.Method,
+		redactURL(request.URL),
+	)}
+	var names []string
+	for name := range response.Header {
+		if isTracedResponseHeader(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value := response.Header.Get(name)
+		if name == "Location" {
+			value = redactURLString(value)
+		}
+		lines = append(lines, fmt.Sprintf("    %s: %s", name, value))
+	}
+	if t.Bodies && response.Body != nil {
+		var prefix []byte
+		prefix, response.Body = peekBody(response.Body)
+		lines = append(
+			lines, formatBody(prefix, response.ContentLength, secrets)...,
+		)
+	}
+	t.write(lines)
+	return response, nil
+}
+
+// Lines are written together so that concurrent requests don't interleave
+func (t *TracingTransport) write(lines []string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	timestamp := time.Now().Format("15:04:05.000")
+	var buffer bytes.Buffer
+	for _, line := range lines {
+		fmt.Fprintf(&buffer, "%s %s\n", timestamp, line)
+	}
+	t.Output.Write(buffer.Bytes()) //nolint:errcheck
+}
+
+func (t *TracingTransport) formatHeaders(header http.Header) []string {
+	var names []string
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var lines []string
+	for _, name := range names {
+		for _, value := range header[name] {
+			lines = append(lines, fmt.Sprintf(
+				"    %s: %s", name, t.redactHeader(name, value),
+			))
+		}
+	}
+	return lines
+}
+
+func (t *TracingTransport) redactHeader(name, value string) string {
+	if strings.ToLower(name) == "authorization" {
+		// Keep the scheme, eg 'Bearer'
+		parts := strings.SplitN(value, " ", 2)
+		if len(parts) == 2 {
+			return parts[0] + " " + redacted
+		}
+		return redacted
+	}
+	if t.isSecretHeader(name) {
+		return redacted
+	}
+	return value
+}
+
+func (t *TracingTransport) isSecretHeader(name string) bool {
+	for _, secretHeader := range t.SecretHeaders {
+		if strings.EqualFold(name, secretHeader) {
+			return true
+		}
+	}
+	lowerName := strings.ToLower(name)
+	for _, secret := range []string{
+		"token", "cookie", "key", "secret", "auth", "session", "signature",
+		"credential",
+	} {
+		if strings.Contains(lowerName, secret) {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+Response headers that help find a request in the server's (or the storage
+service's) logs, plus 'Location' for redirects and 'Retry-After' for
+throttling
+*/
+func isTracedResponseHeader(name string) bool {
+	lowerName := strings.ToLower(name)
+	return strings.Contains(lowerName, "request-id") ||
+		strings.Contains(lowerName, "requestid") ||
+		strings.Contains(lowerName, "correlation-id") ||
+		strings.Contains(lowerName, "trace-id") ||
+		lowerName == "x-amz-id-2" ||
+		lowerName == "x-amz-cf-id" ||
+		lowerName == "location" ||
+		lowerName == "retry-after"
+}
+
+/*
+Read the beginning of 'body' and return it along with a replacement for
+'body' that still yields everything, so that it can be passed on
+*/
+func peekBody(body io.ReadCloser) ([]byte, io.ReadCloser) {
+	prefix := make([]byte, maxTracedBody+1)
+	n, err := io.ReadFull(body, prefix)
+	prefix = prefix[:n]
+	var rest io.Reader = body
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		rest = &errorReader{err: err}
+	}
+	return prefix, struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(prefix), rest), body}
+}
+
+type errorReader struct {
+	err error
+}
+
+func (r *errorReader) Read(p []byte) (int, error) {
+	return 0, r.err
+}
+
+func formatBody(prefix []byte, size int64, secrets []string) []string {
+	if len(prefix) == 0 {
+		return nil
+	}
+	truncated := len(prefix) > maxTracedBody
+	if truncated {
+		prefix = prefix[:maxTracedBody]
+	}
+	text := redactBody(string(prefix), secrets)
+	lines := []string{"    body:"}
+	for _, line := range strings.Split(strings.TrimRight(text, "\r\n"), "\n") {
+		lines = append(lines, "    | "+strings.TrimRight(line, "\r"))
+	}
+	if truncated {
+		if size > 0 {
+			lines = append(lines, fmt.Sprintf(
+				"    (truncated, %d bytes in total)", size,
+			))
+		} else {
+			lines = append(lines, "    (truncated)")
+		}
+	}
+	return lines
+}
+
+var urlPattern = regexp.MustCompile(`https?://[^\s"'<>\\]+`)
+
+func redactBody(text string, secrets []string) string {
+	for _, secret := range secrets {
+		if secret != "" {
+			text = strings.ReplaceAll(text, secret, redacted)
+		}
+	}
+	return urlPattern.ReplaceAllStringFunc(text, redactURLString)
+}
+
+func redactURLString(value string) string {
+	parsed, err := url.Parse(value)
+	if err != nil {
+		return value
+	}
+	return redactURL(parsed)
+}
+
+/*
+Replace the values of query parameters that authorize access to a URL, like
+the 'X-Amz-Signature' and 'X-Amz-Credential' of pre-signed S3 URLs. The order
+of the parameters is preserved.
+*/
+func redactURL(value *url.URL) string {
+	if value.RawQuery == "" && value.User == nil {
+		return value.String()
+	}
+	redactedURL := *value
+	if redactedURL.User != nil {
+		redactedURL.User = url.User(redacted)
+	}
+	parts := strings.Split(redactedURL.RawQuery, "&")
+	for i, part := range parts {
+		if !strings.Contains(part, "=") {
+			continue
+		}
+		name := strings.SplitN(part, "=", 2)[0]
+		unescaped, err := url.QueryUnescape(name)
+		if err != nil {
+			unescaped = name
+		}
+		if isSecretParameter(unescaped) {
+			parts[i] = name + "=" + redacted
+		}
+	}
+	redactedURL.RawQuery = strings.Join(parts, "&")
+	return redactedURL.String()
+}
+
+func isSecretParameter(name string) bool {
+	lowerName := strings.ToLower(name)
+	for _, secret := range []string{
+		"signature", "credential", "token", "accesskey", "key-pair",
+		"policy", "auth",
+	} {
+		if strings.Contains(lowerName, secret) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,145 @@
+package jsonapi
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTracingTransportRedactsSecrets(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/downloads/1":
+				w.Header().Set("X-Request-Id", "abc123")
+				w.Header().Set("Location", server.URL+"/file?"+
+					"X-Amz-Credential=AKIA&X-Amz-Signature=deadbeef&lang=el")
+				w.WriteHeader(303)
+			case "/file":
+				_, _ = w.Write([]byte(`{"hello": "world"}`))
+			}
+		},
+	))
+	defer server.Close()
+
+	var output bytes.Buffer
+	api := Connection{
+		Host:  server.URL,
+		Token: "secrettoken",
+		Client: http.Client{Transport: &TracingTransport{
+			Transport: http.DefaultTransport,
+			Output:    &output,
+			Bodies:    true,
+		}},
+	}
+	_, err := api.request("GET", "/downloads/1", nil, "")
+	var redirectError *RedirectError
+	if !errors.As(err, &redirectError) {
+		t.Fatalf("Expected redirect, got %v", err)
+	}
+	body, err := api.Download(redirectError.Location)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != `{"hello": "world"}` {
+		t.Errorf("Tracing changed the response body: '%s'", body)
+	}
+
+	log := output.String()
+	for _, secret := range []string{"secrettoken", "AKIA", "deadbeef"} {
+		if strings.Contains(log, secret) {
+			t.Errorf("Secret '%s' was not redacted:\n%s", secret, log)
+		}
+	}
+	for _, expected := range []string{
+		"--> #1 GET " + server.URL + "/downloads/1",
+		"Authorization: Bearer REDACTED",
+		"<-- #1 303 See Other",
+		"X-Request-Id: abc123",
+		"X-Amz-Credential=REDACTED&X-Amz-Signature=REDACTED&lang=el",
+		"--> #2 GET " + server.URL + "/file?",
+		"<-- #2 200 OK",
+		`| {"hello": "world"}`,
+	} {
+		if !strings.Contains(log, expected) {
+			t.Errorf("Trace does not contain '%s':\n%s", expected, log)
+		}
+	}
+}
+
+func TestTracingTransportRedactsCustomHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			// Echo a header, like some gateways do in their errors
+			w.WriteHeader(403)
+			_, _ = w.Write([]byte("unknown tenant " + r.Header.Get("X-Tenant")))
+		},
+	))
+	defer server.Close()
+
+	var output bytes.Buffer
+	api := Connection{
+		Host: server.URL,
+		Headers: map[string]string{
+			"X-Tenant":      "acme-private",
+			"X-Gateway-Key": "gatewaysecret",
+			"Integration":   "txclient",
+		},
+		Client: http.Client{Transport: &TracingTransport{
+			Output:        &output,
+			Bodies:        true,
+			SecretHeaders: []string{"x-tenant"},
+		}},
+	}
+	_, _ = api.request("GET", "/organizations", nil, "")
+
+	log := output.String()
+	for _, secret := range []string{"acme-private", "gatewaysecret"} {
+		if strings.Contains(log, secret) {
+			t.Errorf("Secret '%s' was not redacted:\n%s", secret, log)
+		}
+	}
+	for _, expected := range []string{
+		"X-Tenant: REDACTED",
+		"X-Gateway-Key: REDACTED",
+		"Integration: txclient",
+		"| unknown tenant REDACTED",
+	} {
+		if !strings.Contains(log, expected) {
+			t.Errorf("Trace does not contain '%s':\n%s", expected, log)
+		}
+	}
+}
+
+func TestTracingTransportTruncatesBodies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(201)
+			_, _ = w.Write([]byte(`{"data": {"type": "uploads", "id": "1"}}`))
+		},
+	))
+	defer server.Close()
+
+	var output bytes.Buffer
+	api := Connection{
+		Host: server.URL,
+		Client: http.Client{Transport: &TracingTransport{
+			Output: &output,
+			Bodies: true,
+		}},
+	}
+	payload := bytes.Repeat([]byte("a"), maxTracedBody*2)
+	_, err := api.request("POST", "/uploads", payload, "text/plain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := output.String()
+	if strings.Contains(log, string(payload[:maxTracedBody+1])) ||
+		!strings.Contains(log, "(truncated, 8192 bytes in total)") {
+		t.Errorf("Request body was not truncated:\n%s", log)
+	}
+}
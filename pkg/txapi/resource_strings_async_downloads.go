@@ -1,14 +1,11 @@
 package txapi
 
 import (
-	"errors"
+	"context"
 	"fmt"
-	"io"
-	"net/http"
-	"os"
-	"path/filepath"
 	"time"
 
+	"github.com/transifex/cli/pkg/atomicfile"
 	"github.com/transifex/cli/pkg/jsonapi"
 )
 
@@ -33,39 +30,60 @@ func CreateResourceStringsAsyncDownload(
 	return download, err
 }
 
-func PollResourceStringsDownload(download *jsonapi.Resource, filePath string) error {
+func PollResourceStringsDownload(
+	ctx context.Context,
+	download *jsonapi.Resource,
+	filePath string,
+	options atomicfile.Options,
+	progress jsonapi.ProgressFunc,
+) error {
+	err := waitForResourceStringsDownload(ctx, download)
+	if err != nil || download.Redirect == "" {
+		return err
+	}
+	return downloadToFile(download, filePath, options, progress)
+}
+
+/*
+GetResourceStringsDownloadContent
+Like 'PollResourceStringsDownload', but returns the contents of the file
+instead of saving them
+*/
+func GetResourceStringsDownloadContent(
+	ctx context.Context, download *jsonapi.Resource,
+) ([]byte, error) {
+	err := waitForResourceStringsDownload(ctx, download)
+	if err != nil {
+		return nil, err
+	}
+	if download.Redirect == "" {
+		return nil, fmt.Errorf(
+			"download of resource '%s' did not return a file",
+			download.Relationships["resource"].DataSingular.Id,
+		)
+	}
+	body, err := download.API.Download(download.Redirect)
+	if err != nil {
+		return nil, fmt.Errorf("file download error: %w", err)
+	}
+	return body, nil
+}
+
+func waitForResourceStringsDownload(
+	ctx context.Context, download *jsonapi.Resource,
+) error {
 	backoff := getBackoff(nil)
 	for {
-		time.Sleep(time.Duration(backoff()) * time.Second)
-		err := download.Reload()
+		err := Sleep(ctx, time.Duration(backoff())*time.Second)
+		if err != nil {
+			return err
+		}
+		err = download.Reload()
 		if err != nil {
 			return err
 		}
 
 		if download.Redirect != "" {
-			resp, err := http.Get(download.Redirect)
-			if err != nil {
-				return err
-			}
-			if resp.StatusCode != 200 {
-				return errors.New("file download error")
-			}
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return err
-			}
-
-			dir := filepath.Dir(filePath)
-			err = os.MkdirAll(dir, os.ModePerm)
-			if err != nil {
-				return err
-			}
-
-			err = os.WriteFile(filePath, bodyBytes, 0644)
-			if err != nil {
-				return err
-			}
-			resp.Body.Close()
 			return nil
 		} else if download.Attributes["status"] == "failed" {
 			return fmt.Errorf(
@@ -1,8 +1,8 @@
 package txapi
 
 import (
+	"context"
 	"fmt"
-	"io"
 	"strings"
 	"time"
 
@@ -38,28 +38,23 @@ func (err *ResourceStringAsyncUploadAttributes) Error() string {
 func UploadSource(
 	api *jsonapi.Connection,
 	resource *jsonapi.Resource,
-	file io.Reader,
+	file *jsonapi.File,
 	replaceEditedStrings bool,
 	keepTranslations bool,
 ) (*jsonapi.Resource, error) {
-	data, err := io.ReadAll(file)
-	if err != nil {
-		return nil, err
-	}
-
 	upload := jsonapi.Resource{
 		API:  api,
 		Type: "resource_strings_async_uploads",
 		// Setting attributes directly here because POST and GET attributes are
 		// different
 		Attributes: map[string]interface{}{
-			"content":                data,
+			"content":                file,
 			"replace_edited_strings": replaceEditedStrings,
 			"keep_translations":      keepTranslations,
 		},
 	}
 	upload.SetRelated("resource", resource)
-	err = upload.SaveAsMultipart(nil)
+	err := upload.SaveAsMultipart(nil)
 	if err != nil {
 		return nil, err
 	}
@@ -67,11 +62,14 @@ func UploadSource(
 	return &upload, nil
 }
 
-func PollSourceUpload(upload *jsonapi.Resource) error {
+func PollSourceUpload(ctx context.Context, upload *jsonapi.Resource) error {
 	backoff := getBackoff(nil)
 	for {
-		time.Sleep(time.Duration(backoff()) * time.Second)
-		err := upload.Reload()
+		err := Sleep(ctx, time.Duration(backoff())*time.Second)
+		if err != nil {
+			return err
+		}
+		err = upload.Reload()
 		if err != nil {
 			return err
 		}
@@ -1,14 +1,11 @@
 package txapi
 
 import (
-	"errors"
+	"context"
 	"fmt"
-	"io"
-	"net/http"
-	"os"
-	"path/filepath"
 	"time"
 
+	"github.com/transifex/cli/pkg/atomicfile"
 	"github.com/transifex/cli/pkg/jsonapi"
 )
 
@@ -39,11 +36,20 @@ func CreateTranslationsAsyncDownload(
 	return download, err
 }
 
-func PollTranslationDownload(download *jsonapi.Resource, filePath string) error {
+func PollTranslationDownload(
+	ctx context.Context,
+	download *jsonapi.Resource,
+	filePath string,
+	options atomicfile.Options,
+	progress jsonapi.ProgressFunc,
+) error {
 	backoff := getBackoff(nil)
 	for {
-		time.Sleep(time.Duration(backoff()) * time.Second)
-		err := download.Reload()
+		err := Sleep(ctx, time.Duration(backoff())*time.Second)
+		if err != nil {
+			return err
+		}
+		err = download.Reload()
 		if err != nil {
 			return err
 		}
@@ -56,26 +62,5 @@ func PollTranslationDownload(download *jsonapi.Resource, filePath string) error
 			)
 		}
 	}
-	resp, err := http.Get(download.Redirect)
-	if err != nil {
-		return err
-	}
-	if resp.StatusCode != 200 {
-		return errors.New("file download error")
-	}
-	bodyBytes, err := io.ReadAll(resp.Body)
-	defer resp.Body.Close()
-	if err != nil {
-		return err
-	}
-	languageDirectory := filepath.Dir(filePath)
-	err = os.MkdirAll(languageDirectory, os.ModePerm)
-	if err != nil {
-		return err
-	}
-	err = os.WriteFile(filePath, bodyBytes, 0644)
-	if err != nil {
-		return err
-	}
-	return nil
+	return downloadToFile(download, filePath, options, progress)
 }
@@ -1,8 +1,8 @@
 package txapi
 
 import (
+	"context"
 	"fmt"
-	"io"
 	"strings"
 	"time"
 
@@ -13,14 +13,9 @@ func UploadTranslation(
 	api *jsonapi.Connection,
 	resource,
 	language *jsonapi.Resource,
-	file io.Reader,
+	file *jsonapi.File,
 	xliff bool,
 ) (*jsonapi.Resource, error) {
-	data, err := io.ReadAll(file)
-	if err != nil {
-		return nil, err
-	}
-
 	var fileType string
 	if xliff {
 		fileType = "xliff"
@@ -34,13 +29,13 @@ func UploadTranslation(
 		// Setting attributes directly here because POST and GET attributes are
 		// different
 		Attributes: map[string]interface{}{
-			"content":   data,
+			"content":   file,
 			"file_type": fileType,
 		},
 	}
 	upload.SetRelated("resource", resource)
 	upload.SetRelated("language", language)
-	err = upload.SaveAsMultipart(nil)
+	err := upload.SaveAsMultipart(nil)
 	if err != nil {
 		return nil, err
 	}
@@ -72,11 +67,16 @@ func (err *ResourceTranslationsAsyncUploadAttributes) Error() string {
 	return strings.Join(parts, ", ")
 }
 
-func PollTranslationUpload(upload *jsonapi.Resource) error {
+func PollTranslationUpload(
+	ctx context.Context, upload *jsonapi.Resource,
+) error {
 	backoff := getBackoff(nil)
 	for {
-		time.Sleep(time.Duration(backoff()) * time.Second)
-		err := upload.Reload()
+		err := Sleep(ctx, time.Duration(backoff())*time.Second)
+		if err != nil {
+			return err
+		}
+		err = upload.Reload()
 		if err != nil {
 			return err
 		}
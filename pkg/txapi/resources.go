@@ -1,6 +1,7 @@
 package txapi
 
 import (
+	"context"
 	"errors"
 	"time"
 
@@ -181,6 +182,7 @@ func GetResourceById(api *jsonapi.Connection, id string) (*jsonapi.Resource, err
 }
 
 func PollResourceMerge(
+	ctx context.Context,
 	merge *jsonapi.Resource,
 	duration time.Duration,
 ) error {
@@ -193,6 +195,9 @@ func PollResourceMerge(
 		if merge.Attributes["status"] == "COMPLETED" {
 			return nil
 		}
-		time.Sleep(duration)
+		err = Sleep(ctx, duration)
+		if err != nil {
+			return err
+		}
 	}
 }
@@ -1,7 +1,10 @@
 package txapi
 
 import (
+	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/transifex/cli/pkg/jsonapi"
 )
@@ -392,3 +395,31 @@ func TestDeleteResource(t *testing.T) {
 		t.Errorf("Got error while deleting resource: %s", err)
 	}
 }
+
+func TestPollResourceMergeCancelled(t *testing.T) {
+	mockData := jsonapi.MockData{
+		"/resource_async_merges/merge_1": &jsonapi.MockEndpoint{
+			Requests: []jsonapi.MockRequest{
+				{Response: jsonapi.MockResponse{
+					Text: `{"data": {"type": "resource_async_merges",
+					                 "id": "merge_1",
+					                 "attributes": {"status": "PENDING"}}}`,
+				}},
+			},
+		},
+	}
+	api := jsonapi.GetTestConnection(mockData)
+	merge := &jsonapi.Resource{
+		API: &api, Type: "resource_async_merges", Id: "merge_1",
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	err := PollResourceMerge(ctx, merge, time.Hour)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the poll to be cancelled, got %v", err)
+	}
+}
@@ -1,5 +1,15 @@
 package txapi
 
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/transifex/cli/pkg/atomicfile"
+	"github.com/transifex/cli/pkg/jsonapi"
+)
+
 /*
 Return a function that returns the next item from 'pool' every time. When 'pool' runs
 out, keep returning the last item forever.
@@ -31,3 +41,40 @@ func getBackoff(pool []int) func() int {
 		}
 	}
 }
+
+/*
+Sleep
+Wait for 'duration' or until 'ctx' is done, whichever comes first. Returns the
+context's error in the second case.
+*/
+func Sleep(ctx context.Context, duration time.Duration) error {
+	select {
+	case <-time.After(duration):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+/*
+Stream the file that 'download' was redirected to into 'filePath', replacing
+it atomically
+*/
+func downloadToFile(
+	download *jsonapi.Resource,
+	filePath string,
+	options atomicfile.Options,
+	progress jsonapi.ProgressFunc,
+) error {
+	err := download.API.DownloadTo(
+		download.Redirect,
+		func(reader io.Reader) error {
+			return atomicfile.WriteFrom(filePath, reader, 0644, options)
+		},
+		progress,
+	)
+	if err != nil {
+		return fmt.Errorf("file download error: %w", err)
+	}
+	return nil
+}
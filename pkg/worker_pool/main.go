@@ -9,7 +9,7 @@ Usage:
 		i int
 	}
 
-	func (task *Task) Run(send func(string), abort funct()) {
+	func (task *Task) Run(ctx context.Context, send func(string), abort funct()) {
 		send(fmt.Sprintf("Processing task %d\n", task.i))
 		time.Sleep(time.Duration(5) * time.Second)
 		send(fmt.Sprintf("Processed task %d\n", task.i))
@@ -18,7 +18,7 @@ Usage:
 	func main() {
 		numWorkers := 5
 		numTasks := 40
-		pool := worker_pool.New(numWorkers, numTasks)
+		pool := worker_pool.New(context.Background(), numWorkers, numTasks, false)
 		for i := 0; i < numTasks; i++ {
 			pool.Add(&Task{i})
 		}
@@ -76,7 +76,7 @@ done, you can check the IsAborted field to see if any of the tasks aborted.
 		i int
 	}
 
-	func (task Task) Run(send func(string), abort func()) {
+	func (task Task) Run(ctx context.Context, send func(string), abort func()) {
 		if task.i == 20 {
 			abort()
 			return
@@ -85,7 +85,7 @@ done, you can check the IsAborted field to see if any of the tasks aborted.
 	}
 
 	func main() {
-		pool := worker_pool.New(5, 40)
+		pool := worker_pool.New(context.Background(), 5, 40, false)
 		for i := 0; i < 40; i++ {
 			pool.Add(Task{i})
 		}
@@ -95,13 +95,21 @@ done, you can check the IsAborted field to see if any of the tasks aborted.
 			fmt.Pritnln("Something went wrong")
 		}
 	}
+
+The context passed to 'New' is handed to every task. Once it is cancelled (eg
+because the user pressed Ctrl-C), the workers stop picking up new tasks and
+tasks in progress are expected to return as soon as they can. After the pool is
+done, 'IsCancelled' will be true and 'NotStarted' will return the tasks that
+never ran, so that they can be reported.
 */
 
 package worker_pool
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -111,7 +119,7 @@ import (
 )
 
 type Task interface {
-	Run(send func(string), abort func())
+	Run(ctx context.Context, send func(string), abort func())
 }
 
 type taskContainer_t struct {
@@ -125,6 +133,7 @@ type message_t struct {
 }
 
 type Pool struct {
+	ctx              context.Context
 	numWorkers       int
 	numTasks         int
 	taskChannel      chan taskContainer_t
@@ -132,12 +141,18 @@ type Pool struct {
 	outerWaitGroup   sync.WaitGroup
 	counter          int
 	forceNotTerminal bool
+	notStarted       []taskContainer_t
+	notStartedMutex  sync.Mutex
 
-	IsAborted bool
+	IsAborted   bool
+	IsCancelled bool
 }
 
-func New(numWorkers, numTasks int, forceNotTerminal bool) *Pool {
+func New(
+	ctx context.Context, numWorkers, numTasks int, forceNotTerminal bool,
+) *Pool {
 	var pool Pool
+	pool.ctx = ctx
 	pool.numWorkers = numWorkers
 	pool.numTasks = numTasks
 	pool.taskChannel = make(chan taskContainer_t, numTasks)
@@ -165,11 +180,15 @@ func (pool *Pool) Start() {
 	for i := 0; i < pool.numWorkers; i++ {
 		go func() {
 			for taskContainer := range pool.taskChannel {
-				if !pool.IsAborted {
+				if !pool.IsAborted && pool.ctx.Err() == nil {
 					send := func(body string) {
 						messageChannel <- message_t{taskContainer.i, body}
 					}
-					taskContainer.task.Run(send, pool.abort)
+					taskContainer.task.Run(pool.ctx, send, pool.abort)
+				} else {
+					pool.notStartedMutex.Lock()
+					pool.notStarted = append(pool.notStarted, taskContainer)
+					pool.notStartedMutex.Unlock()
 				}
 				if !pool.forceNotTerminal && isatty.IsTerminal(os.Stdout.Fd()) {
 					atomic.AddInt32(&finishedTasks, 1)
@@ -186,6 +205,7 @@ func (pool *Pool) Start() {
 	waitChannel := make(chan struct{})
 	go func() {
 		pool.innerWaitGroup.Wait()
+		pool.IsCancelled = pool.ctx.Err() != nil
 		waitChannel <- struct{}{}
 	}()
 
@@ -235,6 +255,25 @@ func (pool *Pool) abort() {
 	pool.IsAborted = true
 }
 
+/*
+NotStarted
+Returns the tasks that were never run because the pool was aborted or its
+context was cancelled, in the order they were added. Only meaningful after the
+pool is done.
+*/
+func (pool *Pool) NotStarted() []Task {
+	pool.notStartedMutex.Lock()
+	defer pool.notStartedMutex.Unlock()
+	sort.Slice(pool.notStarted, func(i, j int) bool {
+		return pool.notStarted[i].i < pool.notStarted[j].i
+	})
+	var result []Task
+	for _, taskContainer := range pool.notStarted {
+		result = append(result, taskContainer.task)
+	}
+	return result
+}
+
 func (pool *Pool) Wait() <-chan struct{} {
 	waitChannel := make(chan struct{})
 	go func() {